@@ -0,0 +1,413 @@
+// Package abc reads tunes in ABC notation, with their header fields, quoted
+// chord symbols such as "Am" and melody notes, and writes them back
+// transposed or with chord symbols added.
+package abc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pitch is a written pitch. Octave 4 is the octave of middle C, written "C"
+// in ABC; "c" is octave 5.
+type Pitch struct {
+	Step   string // C, D, E, F, G, A or B
+	Alter  int    // semitones, e.g. -1 for flat
+	Octave int
+}
+
+// Key returns the MIDI key number of the pitch, where middle C is 60.
+func (p Pitch) Key() int {
+	return 12*(p.Octave+1) + naturals[strings.IndexByte(letters, p.Step[0])] + p.Alter
+}
+
+// Name returns the pitch name without an octave, e.g. "F#" or "Bb".
+func (p Pitch) Name() string {
+	switch {
+	case p.Alter > 0:
+		return p.Step + strings.Repeat("#", p.Alter)
+	case p.Alter < 0:
+		return p.Step + strings.Repeat("b", -p.Alter)
+	}
+	return p.Step
+}
+
+// Note is a melody note as it sounds, with the key signature and any
+// accidentals earlier in the bar applied. Bar counts from 1; Line and Column
+// are 1-based.
+type Note struct {
+	Pitch
+	Bar    int
+	Line   int
+	Column int
+}
+
+// Chord is a quoted chord symbol. Column counts characters up to the opening
+// quote.
+type Chord struct {
+	Name   string
+	Bar    int
+	Line   int
+	Column int
+}
+
+// Field is a header line such as "T:The Kesh".
+type Field struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// Tune is one tune of an ABC file, from its X: field to the next blank line.
+type Tune struct {
+	Fields []Field // header fields, up to and including K:
+	Key    Key     // the key at the start of the tune body
+	Chords []Chord
+	Notes  []Note // grace notes are left out
+	tokens []token
+}
+
+// File is a parsed ABC file. It keeps the original text so that Write changes
+// nothing but the notes, chord symbols and keys it is asked to.
+type File struct {
+	Tunes []*Tune
+	lines []string
+}
+
+// SyntaxError reports malformed ABC at a line and column.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokenNote tokenKind = iota
+	tokenChord
+	tokenKey
+	tokenBar
+)
+
+// token is an element of a tune body that Write may change, located by
+// line index and byte offsets.
+type token struct {
+	kind       tokenKind
+	line       int
+	start, end int
+	bar        int
+	pitch      Pitch // tokenNote
+	explicit   bool  // tokenNote written with an accidental
+	grace      bool  // tokenNote inside {}
+	insertAt   int   // tokenNote: where a chord symbol may be added
+	chord      int   // tokenChord: index into Tune.Chords
+	key        Key   // tokenKey
+}
+
+// Field returns the value of the tune's first header field with the given
+// name, e.g. "T".
+func (t *Tune) Field(name string) (string, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Parse reads an ABC file. A tune starts at an X: field, or at a K: field
+// outside any tune, and ends at a blank line. Text between tunes is kept but
+// not read. Malformed tunes return a *SyntaxError.
+func Parse(r io.Reader) (*File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{lines: strings.Split(string(data), "\n")}
+
+	var p *parser
+	for i, line := range f.lines {
+		trimmed := strings.TrimSpace(line)
+		name, value, isField := splitField(line)
+		switch {
+		case isField && name == "X", p == nil && isField && name == "K":
+			p = &parser{tune: &Tune{}}
+			f.Tunes = append(f.Tunes, p.tune)
+		case p == nil:
+			continue
+		case trimmed == "":
+			p = nil
+			continue
+		}
+		if err := p.parseLine(i, line, name, value, isField); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// splitField splits a field line such as "T:Title" into its name and value.
+func splitField(line string) (string, string, bool) {
+	if len(line) < 2 || line[1] != ':' {
+		return "", "", false
+	}
+	c := line[0]
+	if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+		return "", "", false
+	}
+	return line[:1], strings.TrimSpace(line[2:]), true
+}
+
+type parser struct {
+	tune     *Tune
+	inBody   bool
+	key      Key
+	sig      [7]int
+	bar      int
+	barUsed  bool // whether the current bar has notes or rests
+	barAlter map[[2]int]int
+	inChord  int // offset of an open [ chord, or -1
+	inGrace  bool
+}
+
+func (p *parser) parseLine(i int, line, name, value string, isField bool) error {
+	lineNum := i + 1
+	if strings.HasPrefix(strings.TrimSpace(line), "%") {
+		return nil
+	}
+	if isField {
+		if name == "K" {
+			start := 2 + strings.Index(line[2:], value)
+			if value == "" {
+				start = len(line)
+			}
+			k, err := p.setKey(value, lineNum, start)
+			if err != nil {
+				return err
+			}
+			p.addToken(token{kind: tokenKey, line: i, start: start, end: start + len(value), key: k})
+			if !p.inBody {
+				p.tune.Key = k
+			}
+		}
+		if !p.inBody {
+			p.tune.Fields = append(p.tune.Fields, Field{Name: name, Value: value, Line: lineNum})
+			if name == "K" {
+				p.inBody = true
+				p.bar = 1
+				p.inChord = -1
+			}
+		}
+		return nil
+	}
+	if !p.inBody {
+		return nil
+	}
+	return p.parseMusic(i, line)
+}
+
+func (p *parser) setKey(value string, lineNum, start int) (Key, error) {
+	k, err := ParseKey(value)
+	if err != nil {
+		return Key{}, &SyntaxError{Line: lineNum, Column: start + 1, Msg: err.Error()}
+	}
+	p.key, p.sig = k, k.Signature()
+	return k, nil
+}
+
+func (p *parser) addToken(t token) {
+	t.bar = p.bar
+	p.tune.tokens = append(p.tune.tokens, t)
+}
+
+// parseMusic reads the notes, chord symbols, inline K: fields and bar lines of
+// a line of music. Decorations, annotations, rests, durations and other
+// symbols are skipped.
+func (p *parser) parseMusic(i int, line string) error {
+	lineNum := i + 1
+	column := func(offset int) int { return utf8.RuneCountInString(line[:offset]) + 1 }
+
+	for pos := 0; pos < len(line); {
+		c := line[pos]
+		switch {
+		case c == '%':
+			return nil
+		case c == '"':
+			end := strings.IndexByte(line[pos+1:], '"')
+			if end < 0 {
+				return &SyntaxError{Line: lineNum, Column: column(pos), Msg: "unterminated chord symbol"}
+			}
+			end += pos + 1
+			text := line[pos+1 : end]
+			if text != "" && strings.IndexByte("^_<>@", text[0]) < 0 {
+				p.tune.Chords = append(p.tune.Chords, Chord{Name: text, Bar: p.bar, Line: lineNum, Column: column(pos)})
+				p.addToken(token{kind: tokenChord, line: i, start: pos + 1, end: end, chord: len(p.tune.Chords) - 1})
+			}
+			pos = end + 1
+		case c == '!' || c == '+':
+			end := strings.IndexByte(line[pos+1:], c)
+			if end < 0 {
+				pos++
+				continue
+			}
+			pos += end + 2
+		case c == '{':
+			p.inGrace = true
+			pos++
+		case c == '}':
+			p.inGrace = false
+			pos++
+		case c == '[' && pos+2 < len(line) && isLetter(line[pos+1]) && line[pos+2] == ':':
+			end := strings.IndexByte(line[pos:], ']')
+			if end < 0 {
+				return &SyntaxError{Line: lineNum, Column: column(pos), Msg: "unterminated inline field"}
+			}
+			end += pos
+			if line[pos+1] == 'K' {
+				value := strings.TrimSpace(line[pos+3 : end])
+				start := pos + 3 + strings.Index(line[pos+3:end], value)
+				k, err := p.setKey(value, lineNum, start)
+				if err != nil {
+					return err
+				}
+				p.addToken(token{kind: tokenKey, line: i, start: start, end: start + len(value), key: k})
+			}
+			pos = end + 1
+		case c == '[' && pos+1 < len(line) && line[pos+1] >= '0' && line[pos+1] <= '9':
+			pos++ // a repeat ending such as [2
+		case c == '[' && (pos+1 >= len(line) || line[pos+1] != '|'):
+			p.inChord = pos
+			pos++
+		case c == ']' && p.inChord >= 0:
+			p.inChord = -1
+			pos++
+		case c == '|' || c == ':' || c == '[' || c == ']':
+			end := pos
+			for end < len(line) && isBarLine(line, pos, end) {
+				end++
+			}
+			if strings.IndexByte(line[pos:end], '|') >= 0 || end-pos > 1 {
+				p.barLine(i, pos, end)
+			}
+			pos = end
+		case c == '^' || c == '_' || c == '=' || isNoteLetter(c):
+			end, err := p.note(i, line, pos)
+			if err != nil {
+				return err
+			}
+			pos = end
+		case c == 'z' || c == 'Z' || c == 'x' || c == 'X':
+			p.barUsed = true
+			pos++
+		default:
+			pos++
+		}
+	}
+	return nil
+}
+
+// isBarLine reports whether line[end] continues the bar line that starts at
+// pos, as in "|", "||", ":|:", "|]" or "[|". A "[" only belongs to it when a
+// "|" follows, so that "|[K:D]" and "|[CE]" are left alone.
+func isBarLine(line string, pos, end int) bool {
+	switch line[end] {
+	case '|', ':':
+		return true
+	case ']':
+		return end > pos && line[end-1] == '|'
+	case '[':
+		return end+1 < len(line) && line[end+1] == '|'
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+func isNoteLetter(c byte) bool {
+	return (c >= 'A' && c <= 'G') || (c >= 'a' && c <= 'g')
+}
+
+// barLine ends the current bar, unless it is still empty as with a bar line
+// at the start of a tune, and clears the bar's accidentals.
+func (p *parser) barLine(i, start, end int) {
+	p.addToken(token{kind: tokenBar, line: i, start: start, end: end})
+	if p.barUsed {
+		p.bar++
+		p.barUsed = false
+	}
+	p.barAlter = nil
+}
+
+// note reads a note's accidentals, letter and octave marks starting at pos and
+// returns the offset after them. The duration that follows is skipped by
+// parseMusic.
+func (p *parser) note(i int, line string, pos int) (int, error) {
+	end := pos
+	alter, explicit := 0, false
+	for end < len(line) && strings.IndexByte("^_=", line[end]) >= 0 {
+		switch line[end] {
+		case '^':
+			alter++
+		case '_':
+			alter--
+		}
+		explicit = true
+		end++
+	}
+	if end >= len(line) || !isNoteLetter(line[end]) {
+		if explicit {
+			return 0, &SyntaxError{Line: i + 1, Column: utf8.RuneCountInString(line[:pos]) + 1, Msg: "accidental without a note"}
+		}
+		return pos + 1, nil
+	}
+
+	letter := line[end]
+	octave := 4
+	if letter >= 'a' {
+		octave = 5
+		letter -= 'a' - 'A'
+	}
+	end++
+	for end < len(line) && (line[end] == '\'' || line[end] == ',') {
+		if line[end] == '\'' {
+			octave++
+		} else {
+			octave--
+		}
+		end++
+	}
+
+	idx := strings.IndexByte(letters, letter)
+	if p.barAlter == nil {
+		p.barAlter = make(map[[2]int]int)
+	}
+	place := [2]int{idx, octave}
+	if explicit {
+		p.barAlter[place] = alter
+	} else if a, ok := p.barAlter[place]; ok {
+		alter = a
+	} else {
+		alter = p.sig[idx]
+	}
+
+	pitch := Pitch{Step: string(letter), Alter: alter, Octave: octave}
+	t := token{kind: tokenNote, line: i, start: pos, end: end, pitch: pitch, explicit: explicit, grace: p.inGrace, insertAt: pos}
+	if p.inChord >= 0 {
+		t.insertAt = p.inChord
+	}
+	p.addToken(t)
+	if !p.inGrace {
+		p.tune.Notes = append(p.tune.Notes, Note{Pitch: pitch, Bar: p.bar, Line: i + 1, Column: utf8.RuneCountInString(line[:pos]) + 1})
+		p.barUsed = true
+	}
+	return end, nil
+}
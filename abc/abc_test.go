@@ -0,0 +1,191 @@
+package abc
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTune = "% session tunes\n" +
+	"X:1\n" +
+	"T:Test Reel\n" +
+	"M:4/4\n" +
+	"L:1/8\n" +
+	"K:D\n" +
+	"|:\"D\"DFA d2 fe|\"A7\"e^c _B=c {g}A4|[DFA]2 \"^fine\"z2 !trill!c2 C,2:|\n" +
+	"w: la la\n" +
+	"[K:Am] A2 c2 e2 ^G2|\n" +
+	"\n" +
+	"Notes between tunes [C]\n"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	f, err := Parse(strings.NewReader(testTune))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+	if len(f.Tunes) != 1 {
+		t.Fatalf("Parse() found %d tunes, want 1", len(f.Tunes))
+	}
+	tune := f.Tunes[0]
+	if title, _ := tune.Field("T"); title != "Test Reel" {
+		t.Errorf("Field(T) = %q, want Test Reel", title)
+	}
+	if tune.Key.Name() != "D Major" {
+		t.Errorf("Key = %s, want D Major", tune.Key.Name())
+	}
+
+	type chordAt struct {
+		name              string
+		bar, line, column int
+	}
+	var chords []chordAt
+	for _, c := range tune.Chords {
+		chords = append(chords, chordAt{c.Name, c.Bar, c.Line, c.Column})
+	}
+	expectedChords := []chordAt{{"D", 1, 7, 3}, {"A7", 2, 7, 16}}
+	if !reflect.DeepEqual(chords, expectedChords) {
+		t.Errorf("Chords = %v, want %v", chords, expectedChords)
+	}
+
+	var notes []string
+	var bars []int
+	for _, n := range tune.Notes {
+		notes = append(notes, n.Name())
+		bars = append(bars, n.Bar)
+	}
+	expectedNotes := []string{"D", "F#", "A", "D", "F#", "E", "E", "C#", "Bb", "C", "A", "D", "F#", "A", "C#", "C#", "A", "C", "E", "G#"}
+	if !reflect.DeepEqual(notes, expectedNotes) {
+		t.Errorf("Notes = %v, want %v", notes, expectedNotes)
+	}
+	expectedBars := []int{1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 2, 3, 3, 3, 3, 3, 4, 4, 4, 4}
+	if !reflect.DeepEqual(bars, expectedBars) {
+		t.Errorf("Bars = %v, want %v", bars, expectedBars)
+	}
+	if key := tune.Notes[15].Key(); key != 49 {
+		t.Errorf("C, = %d, want 49", key)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value string
+		name  string
+		sig   [7]int
+	}{
+		{"G", "G Major", [7]int{0, 0, 0, 1, 0, 0, 0}},
+		{"Am", "A Minor", [7]int{}},
+		{"D mix", "D Mixolydian", [7]int{0, 0, 0, 1, 0, 0, 0}},
+		{"Bb dorian", "Bb Dorian", [7]int{0, -1, -1, 0, 0, -1, -1}},
+		{"F#m clef=bass", "F# Minor", [7]int{1, 0, 0, 1, 1, 0, 0}},
+		{"D ^g", "D Major", [7]int{1, 0, 0, 1, 1, 0, 0}},
+		{"none", "none", [7]int{}},
+		{"", "C Major", [7]int{}},
+	}
+	for _, tt := range tests {
+		k, err := ParseKey(tt.value)
+		if err != nil {
+			t.Errorf("ParseKey(%q) returned error: %v", tt.value, err)
+			continue
+		}
+		if k.Name() != tt.name || k.Signature() != tt.sig {
+			t.Errorf("ParseKey(%q) = %s %v, want %s %v", tt.value, k.Name(), k.Signature(), tt.name, tt.sig)
+		}
+	}
+
+	if _, err := ParseKey("Q"); err == nil {
+		t.Error("ParseKey(Q): expected an error")
+	}
+}
+
+func TestKeyTranspose(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value     string
+		semitones int
+		expected  string
+	}{
+		{"D", 2, "E"},
+		{"Am", 1, "Bbm"},
+		{"Em", 2, "F#m"},
+		{"G mix", -1, "F# mix"},
+		{"D dor", 1, "Eb dor"},
+		{"F", 1, "Gb"},
+	}
+	for _, tt := range tests {
+		k, _ := ParseKey(tt.value)
+		if got := k.Transpose(tt.semitones).String(); got != tt.expected {
+			t.Errorf("Transpose(%q, %d) = %q, want %q", tt.value, tt.semitones, got, tt.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"X:1\nK:G\nAB \"Am cd|\n", 3, 4},
+		{"X:1\nK:Q\n", 2, 3},
+		{"X:1\nK:G\nAB ^|\n", 3, 4},
+		{"X:1\nK:G\nAB [K:G\n", 3, 4},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q): expected a *SyntaxError, got %v", tt.input, err)
+			continue
+		}
+		if syntaxErr.Line != tt.expectedLine || syntaxErr.Column != tt.expectedColumn {
+			t.Errorf("Parse(%q): error at %d:%d, want %d:%d", tt.input, syntaxErr.Line, syntaxErr.Column, tt.expectedLine, tt.expectedColumn)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	f, err := Parse(strings.NewReader(testTune))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	var unchanged bytes.Buffer
+	if err := f.Write(&unchanged, WriteOptions{}); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if unchanged.String() != testTune {
+		t.Errorf("Write() without changes = %q, want the input", unchanged.String())
+	}
+
+	var transposed bytes.Buffer
+	err = f.Write(&transposed, WriteOptions{
+		Transpose: 2,
+		Chord:     func(_ *Tune, c Chord, key Key) string { return "<" + c.Name + ">" },
+		Annotate: func(_ *Tune, bar int, key Key) string {
+			return key.Tonic() + "?"
+		},
+	})
+	if err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	expected := "% session tunes\n" +
+		"X:1\n" +
+		"T:Test Reel\n" +
+		"M:4/4\n" +
+		"L:1/8\n" +
+		"K:E\n" +
+		"|:\"<D>\"EGB e2 gf|\"<A7>\"f^d =c=d {a}B4|\"E?\"[EGB]2 \"^fine\"z2 !trill!d2 D,2:|\n" +
+		"w: la la\n" +
+		"[K:Bm] \"B?\"B2 d2 f2 ^A2|\n" +
+		"\n" +
+		"Notes between tunes [C]\n"
+	if transposed.String() != expected {
+		t.Errorf("Write() transposed =\n%s\nwant\n%s", transposed.String(), expected)
+	}
+}
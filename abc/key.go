@@ -0,0 +1,243 @@
+package abc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key is the value of a K: field: a tonic and a mode, which together give the
+// key signature.
+type Key struct {
+	Step  string // tonic letter; empty for K:none
+	Alter int    // tonic accidental, -1 or 1
+	Mode  string // major, minor, dorian, phrygian, lydian, mixolydian or locrian
+	// accidentals holds explicit accidentals such as "^f", by letter index.
+	accidentals map[int]int
+	suffix      string // everything written after the tonic
+}
+
+type mode struct {
+	name   string
+	degree int // scale degree of the tonic in its relative major, from 0
+	offset int // semitones from the relative major's tonic
+}
+
+var modes = []mode{
+	{"major", 0, 0},
+	{"dorian", 1, 2},
+	{"phrygian", 2, 4},
+	{"lydian", 3, 5},
+	{"mixolydian", 4, 7},
+	{"minor", 5, 9},
+	{"locrian", 6, 11},
+}
+
+// modeAbbreviations maps the first three letters of a mode, as ABC allows,
+// to its name.
+var modeAbbreviations = map[string]string{
+	"maj": "major", "ion": "major",
+	"min": "minor", "aeo": "minor", "m": "minor",
+	"dor": "dorian", "phr": "phrygian", "lyd": "lydian",
+	"mix": "mixolydian", "loc": "locrian",
+}
+
+const letters = "CDEFGAB"
+
+var naturals = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+// majorKeys spells the tonic of the major key on each pitch class, using the
+// signature with fewer accidentals and flats for Gb.
+var majorKeys = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// ParseKey parses the value of a K: field, e.g. "G", "Am", "D mix", "Bb
+// dorian" or "none". Anything after the mode, such as "clef=bass", is kept
+// for writing; explicit accidentals such as "^f _b" alter the signature.
+// "HP" and "Hp", the bagpipe keys, have no tonic; "Hp" sharpens F and C.
+func ParseKey(value string) (Key, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "" || strings.HasPrefix(value, "clef="):
+		return Key{Step: "C", Mode: "major", suffix: prefixSpace(value)}, nil
+	case strings.HasPrefix(strings.ToLower(value), "none"):
+		return Key{Mode: "major", suffix: value}, nil
+	case strings.HasPrefix(value, "HP"):
+		return Key{Mode: "major", suffix: value}, nil
+	case strings.HasPrefix(value, "Hp"):
+		return Key{Mode: "major", accidentals: map[int]int{0: 1, 3: 1}, suffix: value}, nil
+	}
+
+	if strings.IndexByte(letters, value[0]) < 0 {
+		return Key{}, fmt.Errorf("invalid key '%s'", value)
+	}
+	k := Key{Step: value[:1], Mode: "major"}
+	rest := value[1:]
+	if len(rest) > 0 && (rest[0] == '#' || rest[0] == 'b') {
+		k.Alter = 1
+		if rest[0] == 'b' {
+			k.Alter = -1
+		}
+		rest = rest[1:]
+	}
+	k.suffix = rest
+
+	words := strings.Fields(rest)
+	if len(words) > 0 {
+		word := strings.ToLower(words[0])
+		end := 0
+		for end < len(word) && word[end] >= 'a' && word[end] <= 'z' {
+			end++
+		}
+		if name, ok := modeName(word[:end]); ok {
+			k.Mode = name
+			words[0] = words[0][end:]
+		}
+	}
+	for _, word := range words {
+		if err := k.addAccidentals(word); err != nil {
+			return Key{}, err
+		}
+	}
+	return k, nil
+}
+
+func prefixSpace(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " " + s
+}
+
+func modeName(word string) (string, bool) {
+	if word == "m" {
+		return "minor", true
+	}
+	if len(word) < 3 {
+		return "", false
+	}
+	name, ok := modeAbbreviations[word[:3]]
+	return name, ok
+}
+
+// addAccidentals reads explicit accidentals such as "^f" or "_b_e" from a
+// word of the K: field. Words that are not accidentals, such as
+// "clef=bass", are ignored.
+func (k *Key) addAccidentals(word string) error {
+	if word == "" || strings.IndexByte("^_=", word[0]) < 0 {
+		return nil
+	}
+	for i := 0; i < len(word); {
+		alter, n := 0, 0
+		for i+n < len(word) && strings.IndexByte("^_=", word[i+n]) >= 0 {
+			switch word[i+n] {
+			case '^':
+				alter++
+			case '_':
+				alter--
+			}
+			n++
+		}
+		if n == 0 || i+n >= len(word) {
+			return fmt.Errorf("invalid accidental '%s' in key", word)
+		}
+		idx := strings.IndexByte(letters, strings.ToUpper(word[i+n : i+n+1])[0])
+		if idx < 0 {
+			return fmt.Errorf("invalid accidental '%s' in key", word)
+		}
+		if k.accidentals == nil {
+			k.accidentals = make(map[int]int)
+		}
+		k.accidentals[idx] = alter
+		i += n + 1
+	}
+	return nil
+}
+
+// None reports whether the key has no tonic, as with K:none.
+func (k Key) None() bool {
+	return k.Step == ""
+}
+
+// Tonic returns the tonic as a note name such as "F#" or "Bb".
+func (k Key) Tonic() string {
+	switch {
+	case k.Alter > 0:
+		return k.Step + strings.Repeat("#", k.Alter)
+	case k.Alter < 0:
+		return k.Step + strings.Repeat("b", -k.Alter)
+	}
+	return k.Step
+}
+
+// Name returns the key as "G Major", "E Minor" or "D Mixolydian", or "none".
+func (k Key) Name() string {
+	if k.None() {
+		return "none"
+	}
+	return k.Tonic() + " " + strings.ToUpper(k.Mode[:1]) + k.Mode[1:]
+}
+
+// String returns the key as written in a K: field.
+func (k Key) String() string {
+	return k.Tonic() + k.suffix
+}
+
+func (k Key) mode() mode {
+	for _, m := range modes {
+		if m.name == k.Mode {
+			return m
+		}
+	}
+	return modes[0]
+}
+
+// Signature returns the alteration of each letter, C to B, in the key.
+func (k Key) Signature() [7]int {
+	var sig [7]int
+	if !k.None() {
+		m := k.mode()
+		tonicIdx := strings.IndexByte(letters, k.Step[0])
+		relIdx := (tonicIdx - m.degree + 7) % 7
+		relValue := naturals[tonicIdx] + k.Alter - m.offset
+		major := []int{0, 2, 4, 5, 7, 9, 11}
+		for i, interval := range major {
+			idx := (relIdx + i) % 7
+			sig[idx] = wrapAlter(relValue + interval - naturals[idx])
+		}
+	}
+	for idx, alter := range k.accidentals {
+		sig[idx] = alter
+	}
+	return sig
+}
+
+// PitchClasses returns the pitch classes of the key's scale, from C to B.
+func (k Key) PitchClasses() []int {
+	sig := k.Signature()
+	pcs := make([]int, 7)
+	for i := range sig {
+		pcs[i] = ((naturals[i]+sig[i])%12 + 12) % 12
+	}
+	return pcs
+}
+
+// Transpose moves the tonic by semitones, keeping the mode. The new tonic is
+// spelled so that its signature has as few accidentals as possible.
+func (k Key) Transpose(semitones int) Key {
+	if k.None() {
+		return k
+	}
+	m := k.mode()
+	tonicIdx := strings.IndexByte(letters, k.Step[0])
+	relValue := ((naturals[tonicIdx]+k.Alter-m.offset+semitones)%12 + 12) % 12
+
+	rel := majorKeys[relValue]
+	idx := (strings.IndexByte(letters, rel[0]) + m.degree) % 7
+	k.Step = letters[idx : idx+1]
+	k.Alter = wrapAlter(relValue + m.offset - naturals[idx])
+	return k
+}
+
+// wrapAlter brings a difference in semitones into the range -6 to 5.
+func wrapAlter(d int) int {
+	return ((d+6)%12+12)%12 - 6
+}
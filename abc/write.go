@@ -0,0 +1,186 @@
+package abc
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteOptions configures Write.
+type WriteOptions struct {
+	// Transpose moves every note and K: field by this many semitones. Notes
+	// are spelled for the new key, with accidentals only where the new key
+	// signature or an earlier note in the bar needs them, or where the note
+	// was written with one.
+	Transpose int
+	// Chord returns the text for a chord symbol, given the written key at
+	// that point. Nil keeps chord symbols as they are.
+	Chord func(t *Tune, c Chord, key Key) string
+	// Annotate returns a chord symbol to add before the first note of a bar
+	// that has none, or "" to add nothing. Nil adds nothing.
+	Annotate func(t *Tune, bar int, key Key) string
+}
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// Write writes the file with the changes in opts. Header fields other than
+// K:, lyrics, comments, decorations, durations and text between tunes are
+// written exactly as read. Explicit accidentals in a K: field, such as
+// "^f", are not transposed.
+func (f *File) Write(w io.Writer, opts WriteOptions) error {
+	edits := make(map[int][]edit)
+	for _, t := range f.Tunes {
+		t.addEdits(opts, edits)
+	}
+
+	for i, line := range f.lines {
+		if lineEdits, ok := edits[i]; ok {
+			sort.SliceStable(lineEdits, func(a, b int) bool { return lineEdits[a].start < lineEdits[b].start })
+			var b strings.Builder
+			pos := 0
+			for _, e := range lineEdits {
+				b.WriteString(line[pos:e.start])
+				b.WriteString(e.text)
+				pos = e.end
+			}
+			b.WriteString(line[pos:])
+			line = b.String()
+		}
+		if i > 0 {
+			line = "\n" + line
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tune) addEdits(opts WriteOptions, edits map[int][]edit) {
+	add := func(tok token, start int, text string) {
+		edits[tok.line] = append(edits[tok.line], edit{start: start, end: tok.end, text: text})
+	}
+
+	hasChord := make(map[int]bool)
+	for _, c := range t.Chords {
+		hasChord[c.Bar] = true
+	}
+	annotated := make(map[int]bool)
+
+	var from, to Key // the key as read and as written
+	var sig [7]int
+	barAlter := make(map[[2]int]int)
+	for _, tok := range t.tokens {
+		switch tok.kind {
+		case tokenKey:
+			from, to = tok.key, tok.key.Transpose(opts.Transpose)
+			sig = to.Signature()
+			if opts.Transpose != 0 && !to.None() {
+				add(tok, tok.start, to.String())
+			}
+		case tokenBar:
+			barAlter = make(map[[2]int]int)
+		case tokenChord:
+			if opts.Chord != nil {
+				add(tok, tok.start, opts.Chord(t, t.Chords[tok.chord], to))
+			}
+		case tokenNote:
+			if opts.Annotate != nil && !tok.grace && !hasChord[tok.bar] && !annotated[tok.bar] {
+				annotated[tok.bar] = true
+				if name := opts.Annotate(t, tok.bar, to); name != "" {
+					edits[tok.line] = append(edits[tok.line], edit{start: tok.insertAt, end: tok.insertAt, text: `"` + name + `"`})
+				}
+			}
+			if opts.Transpose != 0 {
+				p := transposePitch(tok.pitch, opts.Transpose, from, to)
+				add(tok, tok.start, writeNote(p, sig, barAlter, tok.explicit))
+			}
+		}
+	}
+}
+
+// transposePitch moves a pitch by semitones. In a key it keeps the pitch's
+// degree of the scale, so that F# in D becomes G# in E; otherwise it is spelled
+// as a natural or, following the original, a sharp or flat.
+func transposePitch(p Pitch, semitones int, from, to Key) Pitch {
+	key := p.Key() + semitones
+	if !from.None() && !to.None() {
+		shift := strings.IndexByte(letters, to.Step[0]) - strings.IndexByte(letters, from.Step[0])
+		letter := (strings.IndexByte(letters, p.Step[0]) + shift + 7) % 7
+		if q, ok := spell(key, letter); ok {
+			return q
+		}
+	}
+
+	pc := mod(key, 12)
+	for letter, natural := range naturals {
+		if natural == pc {
+			q, _ := spell(key, letter)
+			return q
+		}
+	}
+	target := mod(pc-1, 12) // a sharp of the letter below
+	if p.Alter < 0 {
+		target = mod(pc+1, 12)
+	}
+	for letter, natural := range naturals {
+		if natural == target {
+			q, _ := spell(key, letter)
+			return q
+		}
+	}
+	return p
+}
+
+// spell writes a MIDI key with the given letter, reporting false when that
+// would take more than a double sharp or flat.
+func spell(key, letter int) (Pitch, bool) {
+	natural := naturals[letter]
+	octave := floorDiv(key-natural+6, 12) - 1
+	alter := key - 12*(octave+1) - natural
+	return Pitch{Step: letters[letter : letter+1], Alter: alter, Octave: octave}, alter >= -2 && alter <= 2
+}
+
+// writeNote writes a pitch in ABC, adding an accidental where the signature
+// and the accidentals earlier in the bar do not already give its alteration,
+// or where the original note had one.
+func writeNote(p Pitch, sig [7]int, barAlter map[[2]int]int, explicit bool) string {
+	idx := strings.IndexByte(letters, p.Step[0])
+	place := [2]int{idx, p.Octave}
+	current, ok := barAlter[place]
+	if !ok {
+		current = sig[idx]
+	}
+
+	var b strings.Builder
+	if explicit || p.Alter != current {
+		switch {
+		case p.Alter > 0:
+			b.WriteString(strings.Repeat("^", p.Alter))
+		case p.Alter < 0:
+			b.WriteString(strings.Repeat("_", -p.Alter))
+		default:
+			b.WriteString("=")
+		}
+		barAlter[place] = p.Alter
+	}
+	if p.Octave >= 5 {
+		b.WriteString(strings.ToLower(p.Step))
+		b.WriteString(strings.Repeat("'", p.Octave-5))
+	} else {
+		b.WriteString(p.Step)
+		b.WriteString(strings.Repeat(",", 4-p.Octave))
+	}
+	return b.String()
+}
+
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+func floorDiv(a, n int) int {
+	return (a - mod(a, n)) / n
+}
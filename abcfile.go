@@ -0,0 +1,323 @@
+// abcfile.go
+// This file contains the "abc" subcommand, which analyses the chord symbols
+// and melody of ABC tunes or writes them back transposed or with chord
+// symbols added.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"cordelia/abc"
+	"cordelia/theory"
+)
+
+func runABCCommand(args []string) {
+	fs := newCommandFlagSet("abc",
+		"cordelia abc <tunes.abc>",
+		"cordelia abc [--transpose <semitones>] [--annotate] [--out file] <tunes.abc>")
+	transpose := fs.Int("transpose", 0, "Write the tunes with notes, keys and chord symbols transposed by this many semitones.")
+	annotate := fs.Bool("annotate", false, "Write the tunes with the identified chord added to each bar without a chord symbol.")
+	out := fs.String("out", "", "File for the written tunes. Default: standard output.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one ABC file.")
+		exitCode = 1
+		return
+	}
+
+	filename := fs.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+		exitCode = 1
+		return
+	}
+	abcFile, err := abc.Parse(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", filename, err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{})
+	if *transpose != 0 || *annotate || *out != "" {
+		writeABC(a, abcFile, filename, *transpose, *annotate, *out)
+		return
+	}
+	analyzeTunes(a, abcFile, filename)
+}
+
+// analyzeTunes prints, for each tune, its chord symbols with their notes, the
+// chord identified in each bar of the melody, and the key estimate from both,
+// compared with the tune's K: field.
+func analyzeTunes(a *theory.Analyzer, f *abc.File, filename string) {
+	fmt.Printf("Processing %s...\n", filename)
+	if len(f.Tunes) == 0 {
+		fmt.Println("No tunes found.")
+		return
+	}
+
+	hasErrors := false
+	for i, tune := range f.Tunes {
+		fmt.Printf("\n%s\n", tuneHeading(tune, i))
+
+		var notes, chordRoots []theory.Note
+		if len(tune.Chords) > 0 {
+			fmt.Println("Chord Symbols:")
+		}
+		for _, c := range tune.Chords {
+			chord, err := progressionChordFromName(a, c.Name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s:%d:%d: %v\n", filename, c.Line, c.Column, err)
+				hasErrors = true
+				continue
+			}
+			notes = append(notes, chord.Notes...)
+			chordRoots = append(chordRoots, chord.Root)
+			fmt.Printf("[bar %d] %s -> %s\n", c.Bar, c.Name, theory.SliceToString(chord.Notes))
+		}
+
+		bars := notesByBar(tune)
+		if len(bars) > 0 {
+			fmt.Println("Bars:")
+		}
+		for _, bar := range bars {
+			barNotes := pitchesByBar(bar.pitches)
+			notes = append(notes, barNotes...)
+			id, _, _ := identifyBar(a, barNotes)
+			matchStrings := id.MatchStrings()
+			if len(matchStrings) == 0 {
+				fmt.Printf("[bar %d] %s -> No match found\n", bar.number, theory.SliceToString(barNotes))
+			} else {
+				fmt.Printf("[bar %d] %s -> %s\n", bar.number, theory.SliceToString(barNotes), strings.Join(matchStrings, ", "))
+			}
+		}
+
+		if len(notes) == 0 {
+			fmt.Println("No chord symbols or notes found.")
+			continue
+		}
+		estimate := a.EstimateKeys(notes)
+		printKeyEstimation(os.Stdout, a.Notation(), estimate)
+
+		var tonics []theory.Note
+		if len(tune.Notes) > 0 {
+			tonics = append(tonics, abcPitchToNote(tune.Notes[len(tune.Notes)-1].Pitch))
+		}
+		if len(chordRoots) > 0 {
+			tonics = append(tonics, chordRoots[0])
+		}
+		printDeclaredABCKey(os.Stdout, tune.Key, estimate, tonics)
+	}
+
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+// tuneHeading renders a tune as "Tune 1: Title (K: D)", numbered by its X:
+// field or else by its position in the file.
+func tuneHeading(tune *abc.Tune, index int) string {
+	number, ok := tune.Field("X")
+	if !ok {
+		number = fmt.Sprint(index + 1)
+	}
+	heading := "Tune " + number
+	if title, ok := tune.Field("T"); ok {
+		heading += ": " + title
+	}
+	if value, ok := tune.Field("K"); ok {
+		heading += fmt.Sprintf(" (K: %s)", value)
+	}
+	return heading
+}
+
+type abcBar struct {
+	number  int
+	pitches []abc.Pitch
+}
+
+// notesByBar groups a tune's melody notes by bar, in order.
+func notesByBar(tune *abc.Tune) []abcBar {
+	var bars []abcBar
+	for _, n := range tune.Notes {
+		if len(bars) == 0 || bars[len(bars)-1].number != n.Bar {
+			bars = append(bars, abcBar{number: n.Bar})
+		}
+		last := &bars[len(bars)-1]
+		last.pitches = append(last.pitches, n.Pitch)
+	}
+	return bars
+}
+
+// pitchesByBar converts a bar's pitches, lowest first, into unique pitch
+// classes.
+func pitchesByBar(pitches []abc.Pitch) []theory.Note {
+	sorted := append([]abc.Pitch(nil), pitches...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Key() < sorted[j].Key() })
+	notes := make([]theory.Note, len(sorted))
+	for i, p := range sorted {
+		notes[i] = abcPitchToNote(p)
+	}
+	return theory.Unique(notes)
+}
+
+func abcPitchToNote(p abc.Pitch) theory.Note {
+	return theory.Note{Original: p.Name(), Value: (p.Key()%12 + 12) % 12}
+}
+
+// identifyBar tries each note of a bar as the root, since a melody need not
+// start on or sit above its chord's root, and keeps the identification whose
+// largest match covers the most notes, preferring lower roots. It also
+// returns that match, and false when nothing matched.
+func identifyBar(a *theory.Analyzer, notes []theory.Note) (theory.Identification, theory.Match, bool) {
+	var best theory.Identification
+	var bestMatch theory.Match
+	found := false
+	for _, root := range notes {
+		id := a.IdentifyRoot(root, notes)
+		for _, m := range id.Matches {
+			if !found || len(m.Intervals) > len(bestMatch.Intervals) {
+				best, bestMatch, found = id, m, true
+			}
+		}
+	}
+	if !found && len(notes) > 0 {
+		best = a.IdentifyRoot(notes[0], notes)
+	}
+	return best, bestMatch, found
+}
+
+// printDeclaredABCKey compares the tune's K: field with the best-matching
+// keys of the estimate. Keys are compared by their notes, so a modal key such
+// as D Mixolydian matches G Major.
+func printDeclaredABCKey(w io.Writer, key abc.Key, estimate theory.KeyEstimate, tonics []theory.Note) {
+	if key.None() || len(estimate.Keys) == 0 {
+		return
+	}
+	declared := make(map[int]struct{})
+	for _, pc := range key.PitchClasses() {
+		declared[pc] = struct{}{}
+	}
+
+	keyNotes := make(map[string]map[int]struct{})
+	for _, k := range theory.DefaultKeys() {
+		keyNotes[k.Name] = k.Notes
+	}
+	match := ""
+	for _, k := range estimate.Keys {
+		if k.MatchCount != estimate.Keys[0].MatchCount {
+			break
+		}
+		// Relative keys share their notes; name the major one.
+		if sameNotes(keyNotes[k.Name], declared) && (match == "" || strings.HasSuffix(k.Name, " Major")) {
+			match = k.Name
+		}
+	}
+	switch {
+	case match != "" && (key.Mode == "major" || key.Mode == "minor"):
+		fmt.Fprintf(w, "\nDeclared Key: %s (matches the estimate)\n", key.Name())
+		return
+	case match != "":
+		fmt.Fprintf(w, "\nDeclared Key: %s (matches the estimate, same notes as %s)\n", key.Name(), match)
+		return
+	}
+	best, _ := estimate.Best(tonics...)
+	fmt.Fprintf(w, "\nDeclared Key: %s (differs from the estimate, %s)\n", key.Name(), best.Name)
+}
+
+func sameNotes(a, b map[int]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for v := range a {
+		if _, ok := b[v]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeABC writes the tunes transposed, with chord symbols respelled for the
+// new key, and with --annotate adds the chord identified in each bar that has
+// no chord symbol. Chord symbols that do not parse are reported and written
+// unchanged.
+func writeABC(a *theory.Analyzer, f *abc.File, filename string, semitones int, annotate bool, out string) {
+	w := io.Writer(os.Stdout)
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", out, err)
+			exitCode = 1
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+
+	hasErrors := false
+	opts := abc.WriteOptions{Transpose: semitones}
+	if semitones != 0 {
+		opts.Chord = func(_ *abc.Tune, c abc.Chord, key abc.Key) string {
+			transposed, err := a.TransposeChordName(c.Name, semitones, abcKeySpelling(key))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s:%d:%d: %v\n", filename, c.Line, c.Column, &theory.ChordNameError{Name: c.Name, Err: err})
+				hasErrors = true
+				return c.Name
+			}
+			return transposed
+		}
+	}
+	if annotate {
+		barChords := make(map[*abc.Tune]map[int]string)
+		for _, tune := range f.Tunes {
+			barChords[tune] = make(map[int]string)
+			for _, bar := range notesByBar(tune) {
+				if id, m, ok := identifyBar(a, pitchesByBar(bar.pitches)); ok {
+					barChords[tune][bar.number] = id.Symbol(m)
+				}
+			}
+		}
+		opts.Annotate = func(t *abc.Tune, bar int, key abc.Key) string {
+			symbol := barChords[t][bar]
+			if symbol == "" || semitones == 0 {
+				return symbol
+			}
+			transposed, err := a.TransposeChordName(symbol, semitones, abcKeySpelling(key))
+			if err != nil {
+				return ""
+			}
+			return transposed
+		}
+	}
+
+	if err := f.Write(w, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write ABC file: %v\n", err)
+		exitCode = 1
+		return
+	}
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+// abcKeySpelling returns the spelling of a key's signature.
+func abcKeySpelling(key abc.Key) theory.Spelling {
+	for _, alter := range key.Signature() {
+		switch {
+		case alter < 0:
+			return theory.SpellingFlats
+		case alter > 0:
+			return theory.SpellingSharps
+		}
+	}
+	return theory.SpellingAuto
+}
@@ -0,0 +1,187 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/cmplx"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testWAV builds a 16-bit stereo WAV file at 22050 Hz that plays each chord,
+// given as frequencies, for one second.
+func testWAV(chords ...[]float64) []byte {
+	const rate = 22050
+	var pcm bytes.Buffer
+	for _, freqs := range chords {
+		for i := 0; i < rate; i++ {
+			v := 0.0
+			for _, f := range freqs {
+				v += math.Sin(2*math.Pi*f*float64(i)/rate) / float64(len(freqs))
+			}
+			sample := int16(v * 0.5 * 32767)
+			binary.Write(&pcm, binary.LittleEndian, [2]int16{sample, sample})
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+pcm.Len()))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(1), uint16(2), uint32(rate), uint32(rate * 4), uint16(4), uint16(16)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(pcm.Len()))
+	b.Write(pcm.Bytes())
+	return b.Bytes()
+}
+
+var (
+	cMajor = []float64{261.63, 329.63, 392.00}
+	aMinor = []float64{220.00, 261.63, 329.63}
+)
+
+func TestReadWAV(t *testing.T) {
+	t.Parallel()
+	s, err := ReadWAV(bytes.NewReader(testWAV(cMajor)))
+	if err != nil {
+		t.Fatalf("ReadWAV() returned error: %v", err)
+	}
+	if s.SampleRate != 22050 || len(s.Samples) != 22050 {
+		t.Errorf("ReadWAV() = %d Hz, %d samples; want 22050 Hz, 22050 samples", s.SampleRate, len(s.Samples))
+	}
+	if d := s.Duration(); d != 1 {
+		t.Errorf("Duration() = %v, want 1", d)
+	}
+
+	for _, input := range []string{"", "RIFF\x04\x00\x00\x00WAVE", "RIFF\x00\x00\x00\x00AVI "} {
+		if _, err := ReadWAV(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadWAV(%q): expected an error", input)
+		}
+	}
+}
+
+func TestFFT(t *testing.T) {
+	t.Parallel()
+	x := make([]complex128, 8)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*2*float64(i)/8), 0)
+	}
+	fft(x)
+	for k, v := range x {
+		expected := 0.0
+		if k == 2 || k == 6 {
+			expected = 4
+		}
+		if math.Abs(cmplx.Abs(v)-expected) > 1e-9 {
+			t.Errorf("bin %d = %v, want magnitude %v", k, v, expected)
+		}
+	}
+}
+
+func TestChromagram(t *testing.T) {
+	t.Parallel()
+	s, _ := ReadWAV(bytes.NewReader(testWAV(cMajor)))
+	frames, err := Chromagram(s, ChromaOptions{})
+	if err != nil {
+		t.Fatalf("Chromagram() returned error: %v", err)
+	}
+	chroma := frames[2].Chroma
+	for pc, v := range chroma {
+		isChordTone := pc == 0 || pc == 4 || pc == 7
+		if isChordTone && v < 0.5 || !isChordTone && v > 0.3 {
+			t.Errorf("chroma[%d] = %.2f in %v", pc, v, chroma)
+		}
+	}
+
+	if _, err := Chromagram(s, ChromaOptions{FrameSize: 1000}); err == nil {
+		t.Error("Chromagram() with a frame size of 1000: expected an error")
+	}
+}
+
+func TestRecognize(t *testing.T) {
+	t.Parallel()
+	s, _ := ReadWAV(bytes.NewReader(testWAV(cMajor, aMinor, nil)))
+	frames, err := Chromagram(s, ChromaOptions{})
+	if err != nil {
+		t.Fatalf("Chromagram() returned error: %v", err)
+	}
+	templates := []Template{
+		{Name: "G", PitchClasses: []int{7, 11, 2}},
+		{Name: "C", PitchClasses: []int{0, 4, 7}},
+		{Name: "Am", PitchClasses: []int{9, 0, 4}},
+	}
+	segments := Recognize(frames, templates, RecognizeOptions{})
+
+	expected := []struct {
+		template   int
+		start, end float64
+	}{{1, 0, 1}, {2, 1, 2}, {-1, 2, 3}}
+	if len(segments) != len(expected) {
+		t.Fatalf("Recognize() returned %d segments, want %d: %+v", len(segments), len(expected), segments)
+	}
+	for i, e := range expected {
+		seg := segments[i]
+		if seg.Template != e.template || math.Abs(seg.Start-e.start) > 0.25 || math.Abs(seg.End-e.end) > 0.25 {
+			t.Errorf("segment %d = template %d at %.2f-%.2f, want %d at %.0f-%.0f", i, seg.Template, seg.Start, seg.End, e.template, e.start, e.end)
+		}
+		if seg.Template >= 0 && seg.Score < 0.8 {
+			t.Errorf("segment %d score = %.2f, want at least 0.8", i, seg.Score)
+		}
+	}
+}
+
+func TestArpeggioOrder(t *testing.T) {
+	t.Parallel()
+	keys := []int{60, 64, 67, 71}
+	tests := []struct {
+		arpeggio Arpeggio
+		expected []int
+	}{
+		{ArpeggioOff, []int{60, 64, 67, 71}},
+		{ArpeggioUp, []int{60, 64, 67, 71}},
+		{ArpeggioDown, []int{71, 67, 64, 60}},
+		{ArpeggioUpDown, []int{60, 64, 67, 71, 67, 64}},
+	}
+	for _, tt := range tests {
+		if got := arpeggioOrder(keys, tt.arpeggio); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("arpeggioOrder(%v) = %v, want %v", tt.arpeggio, got, tt.expected)
+		}
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	t.Parallel()
+	chords := [][]int{{60, 64, 67}, nil, {57, 60, 64}}
+	for _, arpeggio := range []Arpeggio{ArpeggioOff, ArpeggioUp} {
+		rendered := Render(chords, SynthOptions{SampleRate: 22050, BPM: 120, BeatsPerChord: 2, Arpeggio: arpeggio, Timbre: TimbreAdditive})
+		if d := rendered.Duration(); math.Abs(d-3.2) > 0.01 {
+			t.Errorf("Duration() = %v, want 3.2", d)
+		}
+
+		var b bytes.Buffer
+		if err := WriteWAV(&b, rendered); err != nil {
+			t.Fatalf("WriteWAV() returned error: %v", err)
+		}
+		s, err := ReadWAV(&b)
+		if err != nil {
+			t.Fatalf("ReadWAV() returned error: %v", err)
+		}
+		if len(s.Samples) != len(rendered.Samples) {
+			t.Fatalf("ReadWAV() read %d samples, want %d", len(s.Samples), len(rendered.Samples))
+		}
+
+		frames, _ := Chromagram(s, ChromaOptions{})
+		templates := []Template{{Name: "C", PitchClasses: []int{0, 4, 7}}, {Name: "Am", PitchClasses: []int{9, 0, 4}}}
+		var labels []int
+		for _, seg := range Recognize(frames, templates, RecognizeOptions{}) {
+			labels = append(labels, seg.Template)
+		}
+		if expected := []int{0, -1, 1}; !reflect.DeepEqual(labels, expected) {
+			t.Errorf("arpeggio %v: recognized %v, want %v", arpeggio, labels, expected)
+		}
+	}
+}
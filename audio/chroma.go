@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+)
+
+// ChromaOptions configures Chromagram. Zero values select the defaults.
+type ChromaOptions struct {
+	FrameSize int     // samples per FFT frame, a power of two; default 4096
+	HopSize   int     // samples between frame starts; default FrameSize/2
+	MinFreq   float64 // lowest frequency folded into the chroma; default 55 Hz
+	MaxFreq   float64 // highest frequency; default 5000 Hz
+	A4        float64 // tuning of A4; default 440 Hz
+}
+
+func (o ChromaOptions) withDefaults() ChromaOptions {
+	if o.FrameSize == 0 {
+		o.FrameSize = 4096
+	}
+	if o.HopSize == 0 {
+		o.HopSize = o.FrameSize / 2
+	}
+	if o.MinFreq == 0 {
+		o.MinFreq = 55
+	}
+	if o.MaxFreq == 0 {
+		o.MaxFreq = 5000
+	}
+	if o.A4 == 0 {
+		o.A4 = 440
+	}
+	return o
+}
+
+// Frame is the chroma of one analysis frame: the spectral energy of each
+// pitch class, C first, scaled so that the largest is 1. RMS is the frame's
+// loudness before scaling.
+type Frame struct {
+	Start  float64 // seconds
+	End    float64 // seconds; the start of the next frame
+	Chroma [12]float64
+	RMS    float64
+}
+
+// Chromagram cuts the signal into Hann-windowed frames, takes the magnitude
+// spectrum of each and adds every bin between MinFreq and MaxFreq to the
+// pitch class nearest its frequency.
+func Chromagram(s *Signal, opts ChromaOptions) ([]Frame, error) {
+	opts = opts.withDefaults()
+	n := opts.FrameSize
+	switch {
+	case n < 256 || n&(n-1) != 0:
+		return nil, errors.New("frame size must be a power of two of at least 256")
+	case opts.HopSize <= 0:
+		return nil, errors.New("hop size must be greater than zero")
+	case opts.MinFreq >= opts.MaxFreq:
+		return nil, errors.New("minimum frequency must be below the maximum")
+	}
+
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+	// binClass[k] is the pitch class of FFT bin k, or -1 outside the range.
+	binClass := make([]int, n/2)
+	for k := range binClass {
+		freq := float64(k) * float64(s.SampleRate) / float64(n)
+		binClass[k] = -1
+		if freq >= opts.MinFreq && freq <= opts.MaxFreq {
+			key := int(math.Round(69 + 12*math.Log2(freq/opts.A4)))
+			binClass[k] = ((key % 12) + 12) % 12
+		}
+	}
+
+	var frames []Frame
+	buf := make([]complex128, n)
+	hop := float64(opts.HopSize) / float64(s.SampleRate)
+	for start := 0; start < len(s.Samples); start += opts.HopSize {
+		sumSquares := 0.0
+		for i := range buf {
+			v := 0.0
+			if start+i < len(s.Samples) {
+				v = s.Samples[start+i]
+			}
+			sumSquares += v * v
+			buf[i] = complex(v*window[i], 0)
+		}
+		fft(buf)
+
+		f := Frame{
+			Start: float64(start) / float64(s.SampleRate),
+			RMS:   math.Sqrt(sumSquares / float64(n)),
+		}
+		f.End = f.Start + hop
+		for k, pc := range binClass {
+			if pc >= 0 {
+				f.Chroma[pc] += cmplx.Abs(buf[k])
+			}
+		}
+		normalize(&f.Chroma)
+		frames = append(frames, f)
+	}
+	if len(frames) > 0 {
+		frames[len(frames)-1].End = s.Duration()
+	}
+	return frames, nil
+}
+
+func normalize(chroma *[12]float64) {
+	largest := 0.0
+	for _, v := range chroma {
+		largest = math.Max(largest, v)
+	}
+	if largest > 0 {
+		for i := range chroma {
+			chroma[i] /= largest
+		}
+	}
+}
+
+// fft transforms x in place with the iterative radix-2 Cooley-Tukey
+// algorithm. len(x) must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = even+odd, even-odd
+				w *= step
+			}
+		}
+	}
+}
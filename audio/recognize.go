@@ -0,0 +1,199 @@
+package audio
+
+import "math"
+
+// Template is a chord to recognize, given by its pitch classes.
+type Template struct {
+	Name         string
+	PitchClasses []int
+}
+
+// RecognizeOptions configures Recognize. Zero values select the defaults.
+type RecognizeOptions struct {
+	// Smoothing is the number of frames around each frame whose most common
+	// chord replaces the frame's own; default 8.
+	Smoothing int
+	// MinDuration is the shortest segment in seconds; shorter segments are
+	// merged into the one before them. Default 0.25.
+	MinDuration float64
+	// Silence is the RMS level below which a frame has no chord; default 0.01.
+	Silence float64
+}
+
+func (o RecognizeOptions) withDefaults() RecognizeOptions {
+	if o.Smoothing == 0 {
+		o.Smoothing = 8
+	}
+	if o.MinDuration == 0 {
+		o.MinDuration = 0.25
+	}
+	if o.Silence == 0 {
+		o.Silence = 0.01
+	}
+	return o
+}
+
+// Segment is a span of time recognized as one chord. Template indexes the
+// templates passed to Recognize, or is -1 for silence. Score is the cosine
+// similarity, from 0 to 1, between the template, with its notes' harmonics,
+// and the segment's mean chroma.
+type Segment struct {
+	Start    float64
+	End      float64
+	Template int
+	Score    float64
+	Chroma   [12]float64
+	first    int // frame indexes
+	last     int
+}
+
+// Recognize labels each frame with the template closest to its chroma,
+// smooths the labels over time, joins frames with the same label into
+// segments and merges segments shorter than MinDuration into their
+// neighbours.
+func Recognize(frames []Frame, templates []Template, opts RecognizeOptions) []Segment {
+	opts = opts.withDefaults()
+	if len(frames) == 0 {
+		return nil
+	}
+
+	vectors := make([][12]float64, len(templates))
+	for i, t := range templates {
+		vectors[i] = templateVector(t)
+	}
+	labels := make([]int, len(frames))
+	for i, f := range frames {
+		labels[i] = -1
+		if f.RMS >= opts.Silence {
+			labels[i], _ = bestTemplate(f.Chroma, vectors)
+		}
+	}
+	labels = modeFilter(labels, opts.Smoothing)
+
+	var segments []Segment
+	for i, label := range labels {
+		if n := len(segments); n > 0 && segments[n-1].Template == label {
+			segments[n-1].last = i
+			continue
+		}
+		segments = append(segments, Segment{Template: label, first: i, last: i})
+	}
+
+	segments = mergeShort(segments, frames, opts.MinDuration)
+	for i := range segments {
+		seg := &segments[i]
+		seg.Start, seg.End = frames[seg.first].Start, frames[seg.last].End
+		for j := seg.first; j <= seg.last; j++ {
+			for pc, v := range frames[j].Chroma {
+				seg.Chroma[pc] += v
+			}
+		}
+		normalize(&seg.Chroma)
+		if seg.Template >= 0 {
+			seg.Score = cosine(seg.Chroma, vectors[seg.Template])
+		}
+	}
+	return segments
+}
+
+// harmonicOffsets are the pitch classes, above the fundamental, of its first
+// six harmonics.
+var harmonicOffsets = [6]int{0, 0, 7, 0, 4, 7}
+
+// templateVector returns the chroma a template's notes are expected to give:
+// each note adds its first six harmonics with amplitude 1/h, so that the
+// overtones of real instruments do not read as extra chord tones.
+func templateVector(t Template) [12]float64 {
+	var v [12]float64
+	for _, pc := range t.PitchClasses {
+		for h, offset := range harmonicOffsets {
+			v[((pc+offset)%12+12)%12] += 1 / float64(h+1)
+		}
+	}
+	return v
+}
+
+// modeFilter replaces each label with the most common label among the
+// width frames around it, keeping the label itself on ties, so that the
+// frames where one chord blends into the next do not form chords of their own.
+func modeFilter(labels []int, width int) []int {
+	filtered := make([]int, len(labels))
+	for i, label := range labels {
+		counts := make(map[int]int)
+		for j := max(i-width/2, 0); j <= min(i+(width-1)/2, len(labels)-1); j++ {
+			counts[labels[j]]++
+		}
+		best := label
+		for l, n := range counts {
+			if n > counts[best] || (n == counts[best] && l < best && best != label) {
+				best = l
+			}
+		}
+		filtered[i] = best
+	}
+	return filtered
+}
+
+// bestTemplate returns the template most similar to the chroma, the first
+// one on ties.
+func bestTemplate(chroma [12]float64, vectors [][12]float64) (int, float64) {
+	best, bestScore := -1, -1.0
+	for i, v := range vectors {
+		if score := cosine(chroma, v); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best, bestScore
+}
+
+func cosine(a, b [12]float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// mergeShort repeatedly merges the shortest segment under minDuration into
+// the segment before it, or after it for the first, joining neighbours that
+// end up with the same label.
+func mergeShort(segments []Segment, frames []Frame, minDuration float64) []Segment {
+	duration := func(s Segment) float64 { return frames[s.last].End - frames[s.first].Start }
+	for len(segments) > 1 {
+		shortest := -1
+		for i, s := range segments {
+			if duration(s) < minDuration && (shortest < 0 || duration(s) < duration(segments[shortest])) {
+				shortest = i
+			}
+		}
+		if shortest < 0 {
+			break
+		}
+
+		into := shortest - 1
+		if into < 0 {
+			into = 1
+		}
+		lo, hi := min(into, shortest), max(into, shortest)
+		merged := segments[into]
+		merged.first, merged.last = segments[lo].first, segments[hi].last
+		segments = append(segments[:lo], append([]Segment{merged}, segments[hi+1:]...)...)
+
+		// Join the merged segment with neighbours of the same label.
+		joined := segments[:0]
+		for _, s := range segments {
+			if n := len(joined); n > 0 && joined[n-1].Template == s.Template {
+				joined[n-1].last = s.last
+				continue
+			}
+			joined = append(joined, s)
+		}
+		segments = joined
+	}
+	return segments
+}
@@ -0,0 +1,227 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Arpeggio selects whether and how a chord's notes are played one after
+// another.
+type Arpeggio int
+
+const (
+	// ArpeggioOff plays every note of the chord together.
+	ArpeggioOff Arpeggio = iota
+	// ArpeggioUp starts the notes one by one from the lowest.
+	ArpeggioUp
+	// ArpeggioDown starts the notes one by one from the highest.
+	ArpeggioDown
+	// ArpeggioUpDown plays the notes up and back down, without repeating the top.
+	ArpeggioUpDown
+)
+
+// ParseArpeggio converts "off", "up", "down" or "updown" into an Arpeggio.
+func ParseArpeggio(s string) (Arpeggio, error) {
+	switch s {
+	case "off":
+		return ArpeggioOff, nil
+	case "up":
+		return ArpeggioUp, nil
+	case "down":
+		return ArpeggioDown, nil
+	case "updown":
+		return ArpeggioUpDown, nil
+	}
+	return 0, fmt.Errorf("unknown arpeggio '%s' (expected off, up, down or updown)", s)
+}
+
+// Timbre selects the waveform of each voice.
+type Timbre int
+
+const (
+	// TimbreSine is a pure sine wave.
+	TimbreSine Timbre = iota
+	// TimbreAdditive adds six harmonics with falling amplitude for an
+	// organ-like tone.
+	TimbreAdditive
+)
+
+// ParseTimbre converts "sine" or "additive" into a Timbre.
+func ParseTimbre(s string) (Timbre, error) {
+	switch s {
+	case "sine":
+		return TimbreSine, nil
+	case "additive":
+		return TimbreAdditive, nil
+	}
+	return 0, fmt.Errorf("unknown timbre '%s' (expected sine or additive)", s)
+}
+
+// SynthOptions configures Render. Zero values select the defaults noted on
+// each field.
+type SynthOptions struct {
+	SampleRate    int     // default 44100
+	BPM           float64 // default 120
+	BeatsPerChord float64 // default 4
+	A4            float64 // default 440 Hz
+	Arpeggio      Arpeggio
+	Timbre        Timbre
+}
+
+func (o SynthOptions) withDefaults() SynthOptions {
+	if o.SampleRate <= 0 {
+		o.SampleRate = 44100
+	}
+	if o.BPM <= 0 {
+		o.BPM = 120
+	}
+	if o.BeatsPerChord <= 0 {
+		o.BeatsPerChord = 4
+	}
+	if o.A4 <= 0 {
+		o.A4 = 440
+	}
+	return o
+}
+
+// The envelope of every voice: a linear attack and decay to the sustain
+// level, then a linear release after the chord ends.
+const (
+	attackSeconds  = 0.01
+	decaySeconds   = 0.1
+	sustainLevel   = 0.7
+	releaseSeconds = 0.2
+)
+
+// Render plays the chords, each a list of MIDI keys, one after another. A
+// chord with no keys is a rest. Each chord lasts BeatsPerChord beats, and
+// its release rings into the next chord and past the end of the last one.
+func Render(chords [][]int, opts SynthOptions) *Signal {
+	opts = opts.withDefaults()
+	rate := float64(opts.SampleRate)
+	chordSeconds := opts.BeatsPerChord * 60 / opts.BPM
+	total := int((float64(len(chords))*chordSeconds + releaseSeconds) * rate)
+	s := &Signal{SampleRate: opts.SampleRate, Samples: make([]float64, total)}
+
+	for i, keys := range chords {
+		if len(keys) == 0 {
+			continue
+		}
+		order := arpeggioOrder(keys, opts.Arpeggio)
+		step := 0.0
+		if opts.Arpeggio != ArpeggioOff {
+			step = chordSeconds / float64(len(order))
+		}
+		gain := 0.8 / float64(len(keys))
+		chordStart := float64(i) * chordSeconds
+		for j, key := range order {
+			freq := opts.A4 * math.Pow(2, float64(key-69)/12)
+			start := chordStart + float64(j)*step
+			s.addVoice(freq, start, chordStart+chordSeconds, gain, opts.Timbre)
+		}
+	}
+
+	for i, v := range s.Samples {
+		s.Samples[i] = math.Max(-1, math.Min(1, v))
+	}
+	return s
+}
+
+// arpeggioOrder returns the keys in the order they start.
+func arpeggioOrder(keys []int, a Arpeggio) []int {
+	order := append([]int(nil), keys...)
+	switch a {
+	case ArpeggioDown:
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	case ArpeggioUpDown:
+		for i := len(keys) - 2; i > 0; i-- {
+			order = append(order, keys[i])
+		}
+	}
+	return order
+}
+
+// addVoice adds one note from start until end, plus its release.
+func (s *Signal) addVoice(freq, start, end, gain float64, timbre Timbre) {
+	rate := float64(s.SampleRate)
+	harmonics := 1
+	if timbre == TimbreAdditive {
+		harmonics = 6
+	}
+	norm := 0.0
+	for h := 1; h <= harmonics; h++ {
+		norm += 1 / float64(h)
+	}
+
+	first := int(start * rate)
+	last := min(int((end+releaseSeconds)*rate), len(s.Samples))
+	for n := first; n < last; n++ {
+		t := float64(n)/rate - start
+		level := envelope(t, end-start)
+		if level == 0 {
+			continue
+		}
+		v := 0.0
+		for h := 1; h <= harmonics; h++ {
+			if freq*float64(h) >= rate/2 {
+				break
+			}
+			v += math.Sin(2*math.Pi*freq*float64(h)*t) / float64(h)
+		}
+		s.Samples[n] += gain * level * v / norm
+	}
+}
+
+// envelope returns the level of a note t seconds after it starts, for a
+// note held for the given length.
+func envelope(t, length float64) float64 {
+	held := func(t float64) float64 {
+		switch {
+		case t < attackSeconds:
+			return t / attackSeconds
+		case t < attackSeconds+decaySeconds:
+			return 1 - (1-sustainLevel)*(t-attackSeconds)/decaySeconds
+		}
+		return sustainLevel
+	}
+	if t < length {
+		return held(t)
+	}
+	if t >= length+releaseSeconds {
+		return 0
+	}
+	return held(length) * (1 - (t-length)/releaseSeconds)
+}
+
+// WriteWAV writes the signal as a mono 16-bit PCM WAV file.
+func WriteWAV(w io.Writer, s *Signal) error {
+	dataSize := 2 * len(s.Samples)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+dataSize))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], formatPCM)
+	binary.LittleEndian.PutUint16(header[22:], 1) // channels
+	binary.LittleEndian.PutUint32(header[24:], uint32(s.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(2*s.SampleRate))
+	binary.LittleEndian.PutUint16(header[32:], 2) // block align
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(dataSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	data := make([]byte, dataSize)
+	for i, v := range s.Samples {
+		v = math.Max(-1, math.Min(1, v))
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(math.Round(v*32767))))
+	}
+	_, err := w.Write(data)
+	return err
+}
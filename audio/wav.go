@@ -0,0 +1,148 @@
+// Package audio reads and writes WAV files. It recognizes the chords in a
+// recording from a chromagram, a short-time Fourier transform folded into the
+// twelve pitch classes and matched against chord templates, and renders
+// chords with a simple synthesizer.
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Signal is mono audio as samples between -1 and 1.
+type Signal struct {
+	SampleRate int
+	Samples    []float64
+}
+
+// Duration returns the length of the signal in seconds.
+func (s *Signal) Duration() float64 {
+	return float64(len(s.Samples)) / float64(s.SampleRate)
+}
+
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xFFFE
+)
+
+type waveFormat struct {
+	format        int
+	channels      int
+	sampleRate    int
+	blockAlign    int
+	bitsPerSample int
+}
+
+// ReadWAV reads a RIFF WAVE file with integer PCM samples of 8, 16, 24 or 32
+// bits, or 32- or 64-bit float samples, and mixes its channels down to mono.
+// A data chunk that is shorter than its header says, as left by some
+// recorders, is read up to its last whole frame.
+func ReadWAV(r io.Reader) (*Signal, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+
+	var format *waveFormat
+	pos := 12
+	for pos+8 <= len(data) {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		if size < len(body) {
+			body = body[:size]
+		}
+		switch id {
+		case "fmt ":
+			if format, err = readFormat(body); err != nil {
+				return nil, err
+			}
+		case "data":
+			if format == nil {
+				return nil, errors.New("data chunk before fmt chunk")
+			}
+			return decodeSamples(body, format)
+		}
+		pos += 8 + size + size%2
+	}
+	if format == nil {
+		return nil, errors.New("missing fmt chunk")
+	}
+	return nil, errors.New("missing data chunk")
+}
+
+func readFormat(body []byte) (*waveFormat, error) {
+	if len(body) < 16 {
+		return nil, errors.New("fmt chunk too short")
+	}
+	f := &waveFormat{
+		format:        int(binary.LittleEndian.Uint16(body[0:2])),
+		channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+		sampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+		blockAlign:    int(binary.LittleEndian.Uint16(body[12:14])),
+		bitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+	if f.format == formatExtensible {
+		if len(body) < 26 {
+			return nil, errors.New("fmt chunk too short for WAVE_FORMAT_EXTENSIBLE")
+		}
+		// The sub-format GUID starts with the format code.
+		f.format = int(binary.LittleEndian.Uint16(body[24:26]))
+	}
+
+	switch {
+	case f.channels == 0 || f.sampleRate == 0:
+		return nil, errors.New("invalid fmt chunk")
+	case f.format == formatPCM && f.bitsPerSample != 8 && f.bitsPerSample != 16 && f.bitsPerSample != 24 && f.bitsPerSample != 32:
+		return nil, fmt.Errorf("unsupported PCM sample size %d bits", f.bitsPerSample)
+	case f.format == formatFloat && f.bitsPerSample != 32 && f.bitsPerSample != 64:
+		return nil, fmt.Errorf("unsupported float sample size %d bits", f.bitsPerSample)
+	case f.format != formatPCM && f.format != formatFloat:
+		return nil, fmt.Errorf("unsupported WAV format %d (expected PCM or float)", f.format)
+	}
+	if f.blockAlign < f.channels*f.bitsPerSample/8 {
+		f.blockAlign = f.channels * f.bitsPerSample / 8
+	}
+	return f, nil
+}
+
+func decodeSamples(body []byte, f *waveFormat) (*Signal, error) {
+	frames := len(body) / f.blockAlign
+	s := &Signal{SampleRate: f.sampleRate, Samples: make([]float64, frames)}
+	width := f.bitsPerSample / 8
+	for i := 0; i < frames; i++ {
+		sum := 0.0
+		for ch := 0; ch < f.channels; ch++ {
+			offset := i*f.blockAlign + ch*width
+			sum += decodeSample(body[offset:offset+width], f.format)
+		}
+		s.Samples[i] = sum / float64(f.channels)
+	}
+	return s, nil
+}
+
+func decodeSample(b []byte, format int) float64 {
+	if format == formatFloat {
+		if len(b) == 8 {
+			return math.Float64frombits(binary.LittleEndian.Uint64(b))
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	}
+	switch len(b) {
+	case 1:
+		return (float64(b[0]) - 128) / 128 // 8-bit PCM is unsigned
+	case 2:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 3:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / 8388608
+	}
+	return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+}
@@ -0,0 +1,154 @@
+// audiofile.go
+// This file contains the "wav" subcommand, which recognizes the chords in a
+// WAV recording from its chromagram, and the --wav-out export.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"cordelia/audio"
+	"cordelia/theory"
+)
+
+func runWavCommand(args []string) {
+	fs := newCommandFlagSet("wav", "cordelia wav [flags] <file.wav>")
+	frameSize := fs.Int("frame-size", 4096, "Samples per FFT frame (a power of two).")
+	hopSize := fs.Int("hop-size", 0, "Samples between frames. Default: half the frame size.")
+	smoothing := fs.Int("smoothing", 8, "Number of frames over which each frame takes the most common chord.")
+	minDuration := fs.Float64("min-duration", 0.25, "Shortest chord in seconds; shorter ones are merged into their neighbours.")
+	silence := fs.Float64("silence", 0.01, "RMS level (0-1) below which a frame is treated as silence.")
+	a4 := fs.Float64("a4", 440, "Tuning of A4 in Hz.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one WAV file.")
+		exitCode = 1
+		return
+	}
+	if *smoothing <= 0 || *minDuration < 0 || *silence < 0 || *a4 <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --smoothing and --a4 must be greater than zero, --min-duration and --silence at least zero.")
+		exitCode = 1
+		return
+	}
+
+	filename := fs.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	signal, err := audio.ReadWAV(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not read WAV file %s: %v\n", filename, err)
+		exitCode = 1
+		return
+	}
+	frames, err := audio.Chromagram(signal, audio.ChromaOptions{FrameSize: *frameSize, HopSize: *hopSize, A4: *a4})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v.\n", err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{})
+	fmt.Printf("Processing %s...\n", filename)
+
+	templates, chords := chordTemplates(a)
+	segments := audio.Recognize(frames, templates, audio.RecognizeOptions{
+		Smoothing: *smoothing,
+		// Zero would select the defaults; a tiny positive value turns merging
+		// and silence detection off instead.
+		MinDuration: max(*minDuration, 1e-9),
+		Silence:     max(*silence, 1e-12),
+	})
+	if len(segments) == 0 {
+		fmt.Println("No audio found.")
+		return
+	}
+
+	var allNotes []theory.Note
+	for i, seg := range segments {
+		span := fmt.Sprintf("%s-%s", formatTimestamp(seg.Start), formatTimestamp(seg.End))
+		if seg.Template < 0 {
+			fmt.Printf("[%d] %s -> No chord (silence)\n", i+1, span)
+			continue
+		}
+		chord := chords[seg.Template]
+		allNotes = append(allNotes, chord.Notes...)
+		fmt.Printf("[%d] %s %s -> %s %s (%.2f)\n", i+1, span, chord.Symbol, chord.Root.Original, chord.Quality, seg.Score)
+	}
+
+	if len(allNotes) == 0 {
+		fmt.Println("No chords found.")
+		return
+	}
+	printKeyEstimation(os.Stdout, a.Notation(), a.EstimateKeys(allNotes))
+}
+
+// chordTemplates builds a template for every dictionary chord on each of the
+// twelve roots, with the matching chords in the same order.
+func chordTemplates(a *theory.Analyzer) ([]audio.Template, []progressionChord) {
+	var templates []audio.Template
+	var chords []progressionChord
+	for pc := 0; pc < 12; pc++ {
+		root := theory.Note{Original: theory.NoteName(pc, false), Value: pc}
+		for _, c := range a.Dictionary() {
+			notes := theory.GenerateNotes(root, c.Intervals)
+			pitchClasses := make([]int, len(notes))
+			for i, n := range notes {
+				pitchClasses[i] = n.Value
+			}
+			symbol := root.Original + c.Suffix()
+			templates = append(templates, audio.Template{Name: symbol, PitchClasses: pitchClasses})
+			chords = append(chords, progressionChord{
+				Symbol:  symbol,
+				Root:    root,
+				Quality: c.Name,
+				Suffix:  c.Suffix(),
+				Notes:   notes,
+				Parsed:  theory.ChordSymbol{Root: root, Suffix: c.Suffix(), Chord: c},
+			})
+		}
+	}
+	return templates, chords
+}
+
+// --- WAV Export ---
+
+// writeWavExport voices the chords and renders them to the --wav-out file.
+func writeWavExport(e *exportFlags, chords []progressionChord) {
+	arpeggio, _ := audio.ParseArpeggio(*e.arpeggio)
+	timbre, _ := audio.ParseTimbre(*e.timbre)
+
+	voiced := make([][]int, len(chords))
+	for i, c := range chords {
+		voiced[i] = e.voice(c)
+	}
+	signal := audio.Render(voiced, audio.SynthOptions{
+		BPM:           *e.tempo,
+		BeatsPerChord: *e.beats,
+		Arpeggio:      arpeggio,
+		Timbre:        timbre,
+	})
+
+	file, err := os.Create(*e.wavOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", *e.wavOut, err)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	if err := audio.WriteWAV(file, signal); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write %s: %v\n", *e.wavOut, err)
+		exitCode = 1
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d chords to %s\n", len(chords), *e.wavOut)
+}
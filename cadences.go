@@ -0,0 +1,123 @@
+// cadences.go
+// This file contains the cadence output of keys and batch, which labels the
+// cadences of a progression in its estimated key.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"cordelia/theory"
+)
+
+// keyedProgression is a progression as chord symbols with the key it is
+// analysed in. Key is empty when no key was found. Chords whose notes
+// matched nothing have no intervals.
+type keyedProgression struct {
+	key     string
+	tonic   theory.Note
+	minor   bool
+	symbols []theory.ChordSymbol
+}
+
+// progressionInKey chooses the key that the cadences, chromatic chords and
+// Nashville numbers of a progression are all given in. Among the keys tied
+// for the most matches, the first in which V resolves to I in an authentic
+// cadence wins; without one, ties go to the first and last roots. When the
+// first chord, or else the last, is a major or minor triad on the key's
+// tonic, its mode is used.
+func progressionInKey(estimate theory.KeyEstimate, chords []progressionChord) keyedProgression {
+	var p keyedProgression
+	for _, c := range chords {
+		p.symbols = append(p.symbols, c.Parsed)
+	}
+	if len(chords) == 0 {
+		return p
+	}
+	best, ok := estimate.Best(chords[0].Root, chords[len(chords)-1].Root)
+	if !ok {
+		return p
+	}
+	symbols := p.symbols
+
+	candidates := []theory.KeyMatch{best}
+	for _, k := range estimate.Keys {
+		if k.MatchCount == best.MatchCount && k.Name != best.Name {
+			candidates = append(candidates, k)
+		}
+	}
+	for _, k := range candidates {
+		tonic, minor, err := theory.ParseKeyName(k.Name)
+		if err != nil {
+			continue
+		}
+		if resolvesToTonic(symbols, tonic, minor) {
+			best = k
+			break
+		}
+	}
+	tonic, minor, err := theory.ParseKeyName(best.Name)
+	if err != nil {
+		return p
+	}
+
+	// An opening or closing major or minor triad on the tonic sets the mode.
+	for _, s := range []theory.ChordSymbol{symbols[0], symbols[len(symbols)-1]} {
+		if s.Root.Value != tonic.Value {
+			continue
+		}
+		if s.Chord.Name != "Major Triad" && s.Chord.Name != "Minor Triad" {
+			continue
+		}
+		if chordMinor := s.Chord.Name == "Minor Triad"; chordMinor != minor {
+			for _, k := range estimate.Keys {
+				if t, m, err := theory.ParseKeyName(k.Name); err == nil && t.Value == tonic.Value && m == chordMinor {
+					best, tonic, minor = k, t, m
+					break
+				}
+			}
+		}
+		break
+	}
+	p.key, p.tonic, p.minor = best.Name, tonic, minor
+	return p
+}
+
+// resolvesToTonic reports whether the chords have an authentic cadence from
+// V to I in the key.
+func resolvesToTonic(symbols []theory.ChordSymbol, tonic theory.Note, minor bool) bool {
+	for _, c := range theory.DetectCadences(symbols, tonic, minor) {
+		authentic := c.Kind == theory.CadencePerfectAuthentic || c.Kind == theory.CadenceImperfectAuthentic
+		if authentic && (symbols[c.From].Root.Value-tonic.Value+12)%12 == 7 {
+			return true
+		}
+	}
+	return false
+}
+
+// printCadences writes the cadences of a progression in its key, with chord
+// names in notation n. Each is shown with the positions of its chords, such
+// as their places in the chord list or their batch line numbers, and
+// cadences between chords whose positions are not consecutive are left out.
+// Nothing is written without a key or cadences.
+func printCadences(w io.Writer, n theory.Notation, p keyedProgression, positions []int) {
+	if p.key == "" || len(p.symbols) < 2 {
+		return
+	}
+
+	header := false
+	for _, c := range theory.DetectCadences(p.symbols, p.tonic, p.minor) {
+		if positions[c.To]-positions[c.From] != 1 {
+			continue
+		}
+		if !header {
+			fmt.Fprintf(w, "\nCadences (%s):\n", n.Localize(p.key))
+			header = true
+		}
+		from, to := p.symbols[c.From], p.symbols[c.To]
+		fmt.Fprintf(w, "[%d-%d] %s: %s -> %s (%s -> %s)\n", positions[c.From], positions[c.To], c.Kind,
+			n.Localize(from.String()), n.Localize(to.String()),
+			theory.RomanNumeral(from, p.tonic, p.minor), theory.RomanNumeral(to, p.tonic, p.minor))
+	}
+}
@@ -0,0 +1,56 @@
+// cadences_test.go
+// This file contains the tests for the cadence output of batch files.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cordelia/theory"
+)
+
+// batchProgression analyses lines as the batch subcommand does, returning
+// the progression, its line numbers and the key estimate over all notes.
+func batchProgression(a *theory.Analyzer, lines []string) ([]progressionChord, []int, theory.KeyEstimate) {
+	var chords []progressionChord
+	var positions []int
+	var notes []theory.Note
+	for i, line := range lines {
+		result := a.AnalyzeLine(i+1, line)
+		if result.Err != nil {
+			continue
+		}
+		chords = append(chords, progressionChordFromLine(result))
+		positions = append(positions, i+1)
+		notes = append(notes, result.Notes...)
+	}
+	return chords, positions, a.EstimateKeys(notes)
+}
+
+func TestBatchCadencesInNotation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		notation theory.Notation
+		lines    []string
+		expected string
+	}{
+		{theory.NotationEnglish, []string{"A C# E", "B D# F#", "E G# B"}, "Cadences (E Major):\n[2-3] Perfect Authentic: B -> E (V -> I)\n"},
+		{theory.NotationGerman, []string{"A Cis E", "H Dis Fis", "E Gis H"}, "Cadences (E Major):\n[2-3] Perfect Authentic: H -> E (V -> I)\n"},
+		{theory.NotationSolfege, []string{"Sol Si Re", "Do Mi Sol"}, "Cadences (Do Major):\n[1-2] Perfect Authentic: Sol -> Do (V -> I)\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.notation.String(), func(t *testing.T) {
+			t.Parallel()
+			a := theory.NewAnalyzer(theory.Options{Notation: tt.notation})
+			chords, positions, estimate := batchProgression(a, tt.lines)
+			var buf bytes.Buffer
+			printCadences(&buf, a.Notation(), progressionInKey(estimate, chords), positions)
+			if got := strings.TrimPrefix(buf.String(), "\n"); got != tt.expected {
+				t.Errorf("Expected cadences %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package chordpro reads ChordPro songs, with inline chords such as
+// "[Am]lyrics [F]more" and directives such as "{key: Am}", and writes them
+// back with their chords replaced.
+package chordpro
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chord is an inline chord. Line and Column are 1-based; Column counts
+// characters up to the opening bracket.
+type Chord struct {
+	Name   string
+	Line   int
+	Column int
+	start  int // byte offsets of the name within its line
+	end    int
+}
+
+// Directive is a "{name: value}" line. Name is lower-cased.
+type Directive struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// Song is a parsed ChordPro file. It keeps the original text so that Write
+// changes nothing but the chords.
+type Song struct {
+	Chords     []Chord
+	Directives []Directive
+	lines      []string
+}
+
+// SyntaxError reports malformed ChordPro at a line and column.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Environments whose lines are not lyrics, so brackets in them are not chords.
+var verbatimSections = map[string]string{
+	"start_of_tab":  "end_of_tab",
+	"sot":           "eot",
+	"start_of_grid": "end_of_grid",
+	"sog":           "eog",
+}
+
+// Parse reads a ChordPro song. Comment lines ("#") and tab or grid sections
+// are kept but not searched for chords, and annotations such as "[*Riff]"
+// are skipped. Malformed brackets return a *SyntaxError.
+func Parse(r io.Reader) (*Song, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Song{lines: strings.Split(string(data), "\n")}
+
+	endOfSection := ""
+	for i, line := range s.lines {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+			d := parseDirective(trimmed, lineNum)
+			s.Directives = append(s.Directives, d)
+			if end, ok := verbatimSections[d.Name]; ok {
+				endOfSection = end
+			} else if d.Name == endOfSection {
+				endOfSection = ""
+			}
+			continue
+		}
+		if endOfSection != "" {
+			continue
+		}
+		chords, err := parseChords(line, lineNum)
+		if err != nil {
+			return nil, err
+		}
+		s.Chords = append(s.Chords, chords...)
+	}
+	return s, nil
+}
+
+func parseDirective(trimmed string, lineNum int) Directive {
+	body := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+	name, value := body, ""
+	if i := strings.IndexAny(body, ": \t"); i >= 0 {
+		name, value = body[:i], strings.TrimSpace(body[i+1:])
+	}
+	return Directive{Name: strings.ToLower(name), Value: value, Line: lineNum}
+}
+
+func parseChords(line string, lineNum int) ([]Chord, error) {
+	var chords []Chord
+	pos := 0
+	for {
+		open := strings.IndexByte(line[pos:], '[')
+		if open < 0 {
+			break
+		}
+		open += pos
+		column := utf8.RuneCountInString(line[:open]) + 1
+		closing := strings.IndexByte(line[open:], ']')
+		if closing < 0 {
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "unterminated chord"}
+		}
+		closing += open
+		name := line[open+1 : closing]
+		switch {
+		case strings.TrimSpace(name) == "":
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "empty chord"}
+		case strings.ContainsRune(name, '['):
+			return nil, &SyntaxError{Line: lineNum, Column: column, Msg: "unterminated chord"}
+		case !strings.HasPrefix(name, "*"):
+			chords = append(chords, Chord{Name: name, Line: lineNum, Column: column, start: open + 1, end: closing})
+		}
+		pos = closing + 1
+	}
+	return chords, nil
+}
+
+// Directive returns the value of the first directive with the given name.
+func (s *Song) Directive(name string) (string, bool) {
+	for _, d := range s.Directives {
+		if d.Name == name {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
+
+// Write writes the song with every chord replaced by the result of replace.
+// Lyrics, directives and line endings are written exactly as read.
+func (s *Song) Write(w io.Writer, replace func(Chord) string) error {
+	byLine := make(map[int][]Chord)
+	for _, c := range s.Chords {
+		byLine[c.Line] = append(byLine[c.Line], c)
+	}
+
+	for i, line := range s.lines {
+		if chords, ok := byLine[i+1]; ok {
+			var b strings.Builder
+			pos := 0
+			for _, c := range chords {
+				b.WriteString(line[pos:c.start])
+				b.WriteString(replace(c))
+				pos = c.end
+			}
+			b.WriteString(line[pos:])
+			line = b.String()
+		}
+		if i > 0 {
+			line = "\n" + line
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
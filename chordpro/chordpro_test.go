@@ -0,0 +1,94 @@
+package chordpro
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSong = "{title: Sample}\r\n" +
+	"{key: Am}\n" +
+	"# [Not] a chord\n" +
+	"[Am]Hello [F]dark – ness [C/G]\n" +
+	"[*Riff] Instrumental\n" +
+	"{sot}\n" +
+	"e|--[0]--|\n" +
+	"{eot}\n" +
+	"Ñoño [G7]end\n"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	s, err := Parse(strings.NewReader(testSong))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	type position struct {
+		name         string
+		line, column int
+	}
+	var got []position
+	for _, c := range s.Chords {
+		got = append(got, position{c.Name, c.Line, c.Column})
+	}
+	expected := []position{{"Am", 4, 1}, {"F", 4, 11}, {"C/G", 4, 26}, {"G7", 9, 6}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Chords = %v, want %v", got, expected)
+	}
+
+	if key, ok := s.Directive("key"); !ok || key != "Am" {
+		t.Errorf("Directive(key) = %q, %v; want Am", key, ok)
+	}
+	if title, _ := s.Directive("title"); title != "Sample" {
+		t.Errorf("Directive(title) = %q, want Sample", title)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+	}{
+		{"ok\nla [Am la", 2, 4},
+		{"[] empty", 1, 1},
+		{"[C[G] nested", 1, 1},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%q): expected a *SyntaxError, got %v", tt.input, err)
+			continue
+		}
+		if syntaxErr.Line != tt.expectedLine || syntaxErr.Column != tt.expectedColumn {
+			t.Errorf("Parse(%q): error at %d:%d, want %d:%d", tt.input, syntaxErr.Line, syntaxErr.Column, tt.expectedLine, tt.expectedColumn)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	s, err := Parse(strings.NewReader(testSong))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	var unchanged bytes.Buffer
+	if err := s.Write(&unchanged, func(c Chord) string { return c.Name }); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	if unchanged.String() != testSong {
+		t.Errorf("Write() with the same chords changed the song:\n%q", unchanged.String())
+	}
+
+	var replaced bytes.Buffer
+	s.Write(&replaced, func(c Chord) string { return strings.ToLower(c.Name) })
+	expected := strings.NewReplacer("[Am]", "[am]", "[F]", "[f]", "[C/G]", "[c/g]", "[G7]", "[g7]").Replace(testSong)
+	if replaced.String() != expected {
+		t.Errorf("Write() = %q, want %q", replaced.String(), expected)
+	}
+}
@@ -0,0 +1,240 @@
+// chordprofile.go
+// This file contains the "chordpro" subcommand, which analyses the inline
+// chords of a ChordPro song or writes the song back transposed.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"cordelia/chordpro"
+	"cordelia/theory"
+)
+
+func runChordProCommand(args []string) {
+	fs := newCommandFlagSet("chordpro",
+		"cordelia chordpro <song.cho>",
+		"cordelia chordpro --transpose <semitones> [--out file] <song.cho>")
+	transpose := fs.Int("transpose", 0, "Write the song with every chord transposed by this many semitones.")
+	out := fs.String("out", "", "File for the transposed song. Default: standard output.")
+	flats := fs.Bool("flats", false, "Spell transposed chords with flats.")
+	sharps := fs.Bool("sharps", false, "Spell transposed chords with sharps.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	spelling, err := spellingFromFlags(*flats, *sharps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one ChordPro file.")
+		exitCode = 1
+		return
+	}
+
+	filename := fs.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+		exitCode = 1
+		return
+	}
+	song, err := chordpro.Parse(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", filename, err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{})
+	if *transpose != 0 || *out != "" {
+		writeTransposedSong(a, song, filename, *transpose, spelling, *out)
+		return
+	}
+	analyzeSong(a, song, filename)
+}
+
+// analyzeSong prints every chord with its notes, then the key estimate and
+// how it compares with the song's {key:} directive. Chords are analysed as
+// written; a {transpose:} directive only adds the sounding chord name.
+func analyzeSong(a *theory.Analyzer, song *chordpro.Song, filename string) {
+	fmt.Printf("Processing %s...\n", filename)
+
+	var chords []progressionChord
+	hasErrors := false
+	for _, c := range song.Chords {
+		chord, err := progressionChordFromName(a, c.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s:%d:%d: %v\n", filename, c.Line, c.Column, err)
+			hasErrors = true
+			continue
+		}
+		chords = append(chords, chord)
+
+		line := fmt.Sprintf("[%d:%d] %s -> %s", c.Line, c.Column, c.Name, theory.SliceToString(chord.Notes))
+		if semitones := transposeAt(song, c.Line); semitones != 0 {
+			if sounding, err := a.TransposeChordName(c.Name, semitones, theory.SpellingAuto); err == nil {
+				line += fmt.Sprintf(" (sounds as %s)", sounding)
+			}
+		}
+		fmt.Println(line)
+	}
+
+	var notes []theory.Note
+	for _, c := range chords {
+		notes = append(notes, c.Notes...)
+	}
+	if len(notes) == 0 {
+		fmt.Println("No chords found.")
+	} else {
+		estimate := a.EstimateKeys(notes)
+		printKeyEstimation(os.Stdout, a.Notation(), estimate)
+		printDeclaredKey(os.Stdout, a, song, estimate)
+	}
+
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+// transposeAt returns the semitones set by the last valid {transpose:}
+// directive before the given line.
+func transposeAt(song *chordpro.Song, line int) int {
+	semitones := 0
+	for _, d := range song.Directives {
+		if d.Line > line {
+			break
+		}
+		if d.Name == "transpose" {
+			if n, err := strconv.Atoi(d.Value); err == nil {
+				semitones = n
+			}
+		}
+	}
+	return semitones
+}
+
+// printDeclaredKey compares the {key:} directive, a chord name such as "Am",
+// with the best-matching keys of the estimate.
+func printDeclaredKey(w io.Writer, a *theory.Analyzer, song *chordpro.Song, estimate theory.KeyEstimate) {
+	value, ok := song.Directive("key")
+	if !ok {
+		return
+	}
+	declared, err := keyFromChordName(a, value)
+	if err != nil {
+		fmt.Fprintf(w, "\nDeclared Key: %s (%v)\n", value, err)
+		return
+	}
+	printKeyComparison(w, a.Notation(), "Declared Key", declared, estimate)
+}
+
+// printKeyComparison reports whether a key name, as used by the key estimate,
+// is among the best-matching keys of the estimate. Ties for the best key
+// other than the declared one go to the given tonics.
+func printKeyComparison(w io.Writer, n theory.Notation, label, declared string, estimate theory.KeyEstimate, tonics ...theory.Note) {
+	for _, k := range estimate.Keys {
+		if k.MatchCount != estimate.Keys[0].MatchCount {
+			break
+		}
+		if k.Name == declared {
+			fmt.Fprintf(w, "\n%s: %s (matches the estimate)\n", label, n.Localize(declared))
+			return
+		}
+	}
+	best, _ := estimate.Best(tonics...)
+	fmt.Fprintf(w, "\n%s: %s (differs from the estimate, %s)\n", label, n.Localize(declared), n.Localize(best.Name))
+}
+
+// keyFromChordName turns a key written as a chord name ("A", "Am", "Bbm")
+// into a key name as used by the key estimate, e.g. "A Minor".
+func keyFromChordName(a *theory.Analyzer, name string) (string, error) {
+	root, chordDef, err := a.ParseChordName(name)
+	if err != nil {
+		return "", err
+	}
+	switch chordDef.Name {
+	case "Major Triad":
+		return theory.NoteName(root.Value, true) + " Major", nil
+	case "Minor Triad":
+		return theory.NoteName(root.Value, false) + " Minor", nil
+	}
+	return "", errors.New("expected a major or minor key")
+}
+
+// writeTransposedSong writes the song with every chord transposed. Chords that
+// do not parse are reported and written unchanged. Without --flats or
+// --sharps, chords are spelled for the key signature of the new key.
+func writeTransposedSong(a *theory.Analyzer, song *chordpro.Song, filename string, semitones int, spelling theory.Spelling, out string) {
+	w := io.Writer(os.Stdout)
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", out, err)
+			exitCode = 1
+			return
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if spelling == theory.SpellingAuto {
+		spelling = transposedSpelling(a, song, semitones)
+	}
+
+	hasErrors := false
+	err := song.Write(w, func(c chordpro.Chord) string {
+		transposed, err := a.TransposeChordName(c.Name, semitones, spelling)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s:%d:%d: %v\n", filename, c.Line, c.Column, &theory.ChordNameError{Name: c.Name, Err: err})
+			hasErrors = true
+			return c.Name
+		}
+		return transposed
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write transposed song: %v\n", err)
+		exitCode = 1
+		return
+	}
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+// transposedSpelling takes the song's key from its {key:} directive, or else
+// estimates it, moves it by semitones and returns the spelling of the new
+// key's signature.
+func transposedSpelling(a *theory.Analyzer, song *chordpro.Song, semitones int) theory.Spelling {
+	if value, ok := song.Directive("key"); ok {
+		if declared, err := keyFromChordName(a, value); err == nil {
+			tonic, minor, _ := theory.ParseKeyName(declared)
+			return theory.KeySpelling(tonic.Value+semitones, minor)
+		}
+	}
+
+	var chords []progressionChord
+	var notes []theory.Note
+	for _, c := range song.Chords {
+		if chord, err := progressionChordFromName(a, c.Name); err == nil {
+			chords = append(chords, chord)
+			notes = append(notes, chord.Notes...)
+		}
+	}
+	if len(chords) == 0 {
+		return theory.SpellingAuto
+	}
+	best, _ := a.EstimateKeys(notes).Best(chords[0].Root, chords[len(chords)-1].Root)
+	tonic, minor, err := theory.ParseKeyName(best.Name)
+	if err != nil {
+		return theory.SpellingAuto
+	}
+	return theory.KeySpelling(tonic.Value+semitones, minor)
+}
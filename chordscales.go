@@ -0,0 +1,146 @@
+// chordscales.go
+// This file contains the "chordscales" subcommand, which recommends scales
+// for improvising over chords, preferring scales diatonic to the key.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cordelia/theory"
+)
+
+func runChordScalesCommand(args []string) {
+	fs := newCommandFlagSet("chordscales",
+		"cordelia chordscales [--key <key>] <chord1> <chord2> ...",
+		"cordelia chordscales [--key <key>] --notes <note1>,<note2>,...")
+	key := fs.String("key", "", "Key to prefer diatonic scales for, as a chord name (\"G\", \"Em\") or a key name (\"G Major\"). Default: estimated from two or more chords.")
+	notes := fs.String("notes", "", "Comma-separated list of notes (e.g., \"C,E,G,Bb\") to identify one chord from instead of reading chord names.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 && *notes == "" {
+		fmt.Fprintln(os.Stderr, "Error: No chords provided.")
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Dictionary: theory.ChordScaleDictionary(), Notation: notation})
+	var symbols []theory.ChordSymbol
+	var names []string
+	if *notes != "" {
+		symbol, err := identifyChordSymbol(a, notesFromFlag(*notes, fs.Args()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		symbols = append(symbols, symbol)
+		names = append(names, notation.Localize(symbol.String()))
+	} else {
+		for _, name := range fs.Args() {
+			symbol, err := a.ParseChordSymbol(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+				exitCode = 1
+				return
+			}
+			symbols = append(symbols, symbol)
+			names = append(names, name)
+		}
+	}
+
+	keyName, estimated, err := progressionKey(a, *key, symbols)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	printProgressionKey(os.Stdout, notation, keyName, estimated)
+
+	for i, symbol := range symbols {
+		scales, err := a.ChordScales(symbol.Root, symbol.Chord, keyName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		fmt.Printf("%s (%s %s):\n", names[i], notation.NoteName(symbol.Root), symbol.Chord.Name)
+		printChordScales(os.Stdout, notation, symbol.Root, scales, keyName != "")
+	}
+}
+
+// progressionKey returns the key given by a --key flag value or, without
+// one, the best estimate over two or more chords, with ties going to the
+// first and then the last root. The key is empty when there is neither.
+func progressionKey(a *theory.Analyzer, value string, symbols []theory.ChordSymbol) (string, bool, error) {
+	if value != "" {
+		keyName, err := keyFromFlag(a, value)
+		if err != nil {
+			return "", false, fmt.Errorf("Error: Invalid key '%s': %v", value, err)
+		}
+		return keyName, false, nil
+	}
+	if len(symbols) < 2 {
+		return "", false, nil
+	}
+	var notes []theory.Note
+	for _, s := range symbols {
+		notes = append(notes, theory.GenerateNotes(s.Root, s.Chord.Intervals)...)
+	}
+	best, ok := a.EstimateKeys(notes).Best(symbols[0].Root, symbols[len(symbols)-1].Root)
+	return best.Name, ok, nil
+}
+
+// printProgressionKey writes the key returned by progressionKey, if any.
+func printProgressionKey(w io.Writer, n theory.Notation, keyName string, estimated bool) {
+	switch {
+	case estimated:
+		fmt.Fprintf(w, "Key: %s (estimated)\n", n.Localize(keyName))
+	case keyName != "":
+		fmt.Fprintf(w, "Key: %s\n", n.Localize(keyName))
+	}
+}
+
+// identifyChordSymbol identifies notes with the first as root and returns
+// the best match as a chord symbol.
+func identifyChordSymbol(a *theory.Analyzer, noteStrings []string) (theory.ChordSymbol, error) {
+	results, err := a.IdentifyStrings(noteStrings)
+	if err != nil {
+		return theory.ChordSymbol{}, err
+	}
+	id := results[0]
+	if len(id.Matches) == 0 {
+		return theory.ChordSymbol{}, fmt.Errorf("no chord found for %s", a.Notation().SliceToString(id.Notes))
+	}
+	best := id.Matches[0]
+	chordDef := theory.Chord{Name: best.Name, Suffixes: []string{best.Suffix}, Intervals: best.Intervals}
+	return theory.ChordSymbol{Root: id.Root, Suffix: best.Suffix, Chord: chordDef}, nil
+}
+
+// printChordScales writes one line per scale: its notes, avoid notes, the
+// tensions it suits and, when ranked for a key, how far it is from the key.
+func printChordScales(w io.Writer, n theory.Notation, root theory.Note, scales []theory.ChordScale, hasKey bool) {
+	for _, s := range scales {
+		line := fmt.Sprintf(" %s %s: %s", n.NoteName(root), s.Scale.Name, n.SliceToString(s.Notes))
+		if len(s.Avoid) > 0 {
+			line += "; avoid " + n.SliceToString(s.Avoid)
+		}
+		if s.Use != "" {
+			line += "; for " + s.Use
+		}
+		if hasKey {
+			line += "; " + keyFit(s.Outside)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
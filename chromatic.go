@@ -0,0 +1,52 @@
+// chromatic.go
+// This file contains the chromatic chord output of keys and batch, which
+// explains the chords of a progression that are not diatonic to its key.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"cordelia/theory"
+)
+
+// printChromaticChords labels the secondary dominants, secondary
+// leading-tone chords, borrowed chords and chromatic mediants of a
+// progression in its key, with their positions and chord names in notation
+// n. Nothing is written without a key or when every chord is diatonic or
+// unexplained.
+func printChromaticChords(w io.Writer, n theory.Notation, p keyedProgression, positions []int) {
+	if p.key == "" {
+		return
+	}
+	keyName, tonic, minor, symbols := p.key, p.tonic, p.minor, p.symbols
+	labels := theory.AnalyzeChromaticChords(symbols, tonic, minor)
+	if len(labels) == 0 {
+		return
+	}
+
+	parallel := theory.NoteName(tonic.Value, theory.IsFlat(tonic)) + " Minor"
+	if minor {
+		parallel = theory.NoteName(tonic.Value, theory.IsFlat(tonic)) + " Major"
+	}
+	fmt.Fprintf(w, "\nChromatic Chords (%s):\n", n.Localize(keyName))
+	for _, c := range labels {
+		var note string
+		switch c.Kind {
+		case theory.ChromaticSecondaryDominant:
+			note = "secondary dominant"
+		case theory.ChromaticSecondaryLeadingTone:
+			note = "secondary leading-tone chord"
+		case theory.ChromaticBorrowed:
+			note = "borrowed from " + n.Localize(parallel)
+		case theory.ChromaticMediant:
+			of := n.NoteName(tonic)
+			if c.Related >= 0 {
+				of = n.Localize(symbols[c.Related].String())
+			}
+			note = "chromatic mediant of " + of
+		}
+		fmt.Fprintf(w, "[%d] %s: %s, %s\n", positions[c.Index], n.Localize(symbols[c.Index].String()), c.Numeral, note)
+	}
+}
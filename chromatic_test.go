@@ -0,0 +1,38 @@
+// chromatic_test.go
+// This file contains the tests for the chromatic chord output of batch files.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"cordelia/theory"
+)
+
+func TestBatchChromaticChordsInNotation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		notation theory.Notation
+		lines    []string
+		expected string
+	}{
+		{theory.NotationGerman, []string{"C E G", "F A C", "D Fis A C", "G H D F", "C E G"}, "Chromatic Chords (C Major):\n[3] D7: V7/V, secondary dominant\n"},
+		{theory.NotationGerman, []string{"C E G", "As C Es", "B D F", "C E G"}, "Chromatic Chords (C Major):\n[2] As: bVI, borrowed from C Minor\n[3] B: bVII, borrowed from C Minor\n"},
+		{theory.NotationSolfege, []string{"Do Mi Sol", "Lab Do Mib", "Sib Re Fa", "Do Mi Sol"}, "Chromatic Chords (Do Major):\n[2] Lab: bVI, borrowed from Do Minor\n[3] Sib: bVII, borrowed from Do Minor\n"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.notation.String()+" "+tt.lines[1], func(t *testing.T) {
+			t.Parallel()
+			a := theory.NewAnalyzer(theory.Options{Notation: tt.notation})
+			chords, positions, estimate := batchProgression(a, tt.lines)
+			var buf bytes.Buffer
+			printChromaticChords(&buf, a.Notation(), progressionInKey(estimate, chords), positions)
+			if got := strings.TrimPrefix(buf.String(), "\n"); got != tt.expected {
+				t.Errorf("Expected chromatic chords %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,449 @@
+// commands.go
+// This file contains the subcommands of the CLI. Each subcommand has its own
+// flag set and help text; invocations without a subcommand fall back to the
+// legacy flags handled in main.go.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cordelia/theory"
+)
+
+// command is a CLI subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+// commands lists the subcommands in the order they appear in help output.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"identify", "Identify a chord from notes.", runIdentifyCommand},
+		{"keys", "Estimate the key from chord names or notes.", runKeysCommand},
+		{"batch", "Identify chords from a file of notes, one chord per line.", runBatchCommand},
+		{"parse", "Parse chord names into root, quality and intervals.", runParseCommand},
+		{"spell", "List the notes of each chord name.", runSpellCommand},
+		{"transpose", "Transpose chord names by a number of semitones.", runTransposeCommand},
+		{"nashville", "Read a chart of Nashville numbers in a key.", runNashvilleCommand},
+		{"chordscales", "Recommend scales for improvising over chords.", runChordScalesCommand},
+		{"scales", "Find the scales that contain a collection of notes.", runScalesCommand},
+		{"substitutes", "Suggest chord substitutions for a progression.", runSubstitutesCommand},
+		{"train", "Train a model of chord progressions from files.", runTrainCommand},
+		{"predict", "Predict the next chord of a progression.", runPredictCommand},
+		{"midi", "Identify chords over time in a Standard MIDI File.", runMidiCommand},
+		{"musicxml", "Read chord symbols and notes from a MusicXML score.", runMusicXMLCommand},
+		{"chordpro", "Analyse or transpose the chords of a ChordPro song.", runChordProCommand},
+		{"abc", "Analyse, transpose or add chords to ABC tunes.", runABCCommand},
+		{"wav", "Recognize chords over time in a WAV recording.", runWavCommand},
+		{"serve", "Run the HTTP JSON API.", runServe},
+		{"repl", "Start an interactive shell.", runRepl},
+		{"help", "Show help for a command.", runHelpCommand},
+	}
+}
+
+// lookupCommand finds a subcommand by name.
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// printCommandList writes the subcommand names and summaries.
+func printCommandList(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.summary)
+	}
+}
+
+// newCommandFlagSet creates a flag set whose usage message shows the given
+// synopsis lines before the flag defaults.
+func newCommandFlagSet(name string, synopsis ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of cordelia %s:\n", name)
+		for _, line := range synopsis {
+			fmt.Fprintf(fs.Output(), "  %s\n", line)
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseCommandFlags parses args into fs. It returns false when the command
+// should stop, having set exitCode: 0 for --help, 1 for invalid flags.
+func parseCommandFlags(fs *flag.FlagSet, args []string) bool {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			exitCode = 0
+		} else {
+			exitCode = 1
+		}
+		return false
+	}
+	return true
+}
+
+// --- Subcommands ---
+
+func runIdentifyCommand(args []string) {
+	fs := newCommandFlagSet("identify", "cordelia identify [flags] <note1> <note2> ...",
+		"cordelia identify --hz [--a4 <Hz>] <freq1> <freq2> ...")
+	notes := fs.String("notes", "", "Comma-separated list of notes (e.g., \"C,E,G,Bb\").")
+	inversions := fs.Bool("inversions", false, "Enable inversion detection by treating each note as a potential root.")
+	verbose := fs.Bool("verbose", false, "Show detailed matching logic, including failed checks.")
+	hz := fs.Bool("hz", false, "Treat arguments as frequencies in Hz and map them to the nearest notes.")
+	a4 := fs.Float64("a4", 440, "Tuning of A4 in Hz for --hz and --tuning.")
+	tolerance := fs.Float64("tolerance", 25, "Deviation in cents beyond which --hz flags a frequency as out of tune.")
+	tuningOpts := addTuningFlags(fs)
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+
+	freqOpts := frequencyOptions{a4: *a4, tolerance: *tolerance, notation: notation}
+	if err := validateFrequencyOptions(freqOpts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	tunings, ok := loadTunings(tuningOpts, notation, *a4)
+	if !ok {
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Inversions: *inversions, Notation: notation})
+	var results []theory.Identification
+	if *hz {
+		if *notes != "" || fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --hz expects frequencies as arguments.")
+			exitCode = 1
+			return
+		}
+		freqNotes, err := notesFromFrequencies(os.Stdout, fs.Args(), freqOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		results = a.Identify(freqNotes)
+	} else {
+		noteStrings := fs.Args()
+		if *notes != "" {
+			noteStrings = notesFromFlag(*notes, noteStrings)
+		}
+		if len(noteStrings) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No notes provided.")
+			exitCode = 1
+			return
+		}
+		if results, err = a.IdentifyStrings(noteStrings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+	}
+
+	for _, id := range results {
+		printIdentifications(os.Stdout, a, []theory.Identification{id}, *verbose)
+		if err := printTunings(os.Stdout, notation, tunings, rootFirst(id.Root, id.Notes)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+		}
+	}
+}
+
+// notesFromFlag splits a --notes value into notes, warning that it replaces
+// any positional arguments.
+func notesFromFlag(value string, args []string) []string {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: Both positional arguments and --notes provided; using --notes.")
+	}
+	return strings.Split(value, ",")
+}
+
+func runKeysCommand(args []string) {
+	fs := newCommandFlagSet("keys",
+		"cordelia keys <chord1> <chord2> ...",
+		"cordelia keys --notes <note1>,<note2>,...")
+	notes := fs.String("notes", "", "Comma-separated list of notes (e.g., \"C,E,G,Bb\") to estimate the key from instead of chord names.")
+	nashville := fs.Bool("nashville", false, "Also write the chords as Nashville numbers in the estimated key.")
+	voiceLeading := fs.Bool("voice-leading", false, "Compare each chord with the next: common tones, voice motion and the smoothest voicing.")
+	export := addExportFlags(fs)
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	fromNotes := *notes != ""
+	if fromNotes && (*nashville || *voiceLeading) {
+		fmt.Fprintln(os.Stderr, "Error: --nashville and --voice-leading require chord names, not --notes.")
+		exitCode = 1
+		return
+	}
+	if export.enabled() {
+		if fromNotes {
+			fmt.Fprintf(os.Stderr, "Error: %s requires chord names, not --notes.\n", export.outputFlag())
+			exitCode = 1
+			return
+		}
+		if err := export.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			return
+		}
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	if !fromNotes {
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No chord names provided for key estimation.")
+			exitCode = 1
+			return
+		}
+		runKeyEstimationFromArgs(a, fs.Args(), keyOptions{nashville: *nashville, voiceLeading: *voiceLeading, export: export})
+		return
+	}
+
+	parsed, err := notation.ParseNotes(notesFromFlag(*notes, fs.Args()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+	fmt.Printf("Processing Notes: %s\n", notation.SliceToString(parsed))
+	printKeyEstimation(os.Stdout, a.Notation(), a.EstimateKeys(parsed))
+}
+
+func runBatchCommand(args []string) {
+	fs := newCommandFlagSet("batch", "cordelia batch [flags] <file>")
+	keys := fs.Bool("keys", false, "Estimate the key from all notes in the file.")
+	format := fs.String("format", formatText, "Output format: text, csv or tsv.")
+	nashville := fs.Bool("nashville", false, "Also write the chords as Nashville numbers in the estimated key.")
+	voiceLeading := fs.Bool("voice-leading", false, "Compare each line with the next: common tones, voice motion and the smoothest voicing.")
+	export := addExportFlags(fs)
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one batch file.")
+		exitCode = 1
+		return
+	}
+	if err := validateFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if (*nashville || *voiceLeading) && *format != formatText {
+		fmt.Fprintln(os.Stderr, "Error: --nashville and --voice-leading require --format text.")
+		exitCode = 1
+		return
+	}
+	if export.enabled() {
+		if err := export.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			return
+		}
+	}
+
+	runBatchMode(theory.NewAnalyzer(theory.Options{Notation: notation}), fs.Arg(0), batchOptions{keys: *keys, nashville: *nashville, voiceLeading: *voiceLeading, format: *format, export: export})
+}
+
+func runParseCommand(args []string) {
+	fs := newCommandFlagSet("parse", "cordelia parse <chord1> <chord2> ...")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No chord names provided.")
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	for _, name := range fs.Args() {
+		root, chordDef, err := a.ParseChordName(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+			exitCode = 1
+			continue
+		}
+		printParsedChord(os.Stdout, notation, name, root, chordDef)
+	}
+}
+
+func runSpellCommand(args []string) {
+	fs := newCommandFlagSet("spell", "cordelia spell [--tuning <names>] <chord1> <chord2> ...")
+	a4 := fs.Float64("a4", 440, "Tuning of A4 in Hz for --tuning.")
+	tuningOpts := addTuningFlags(fs)
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No chord names provided.")
+		exitCode = 1
+		return
+	}
+	if *a4 <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --a4 must be greater than zero.")
+		exitCode = 1
+		return
+	}
+	tunings, ok := loadTunings(tuningOpts, notation, *a4)
+	if !ok {
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	for _, name := range fs.Args() {
+		root, chordDef, err := a.ParseChordName(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+			exitCode = 1
+			continue
+		}
+		notes := theory.GenerateNotes(root, chordDef.Intervals)
+		fmt.Printf("%s: %s\n", name, notation.SliceToString(notes))
+		if err := printTunings(os.Stdout, notation, tunings, notes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+		}
+	}
+}
+
+func runTransposeCommand(args []string) {
+	fs := newCommandFlagSet("transpose", "cordelia transpose --by <semitones> <chord1> <chord2> ...")
+	by := fs.Int("by", 0, "Number of semitones to transpose by (negative to go down).")
+	flats := fs.Bool("flats", false, "Spell transposed roots with flats.")
+	sharps := fs.Bool("sharps", false, "Spell transposed roots with sharps.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	spelling, err := spellingFromFlags(*flats, *sharps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No chord names provided.")
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	var transposed []string
+	for _, name := range fs.Args() {
+		t, err := a.TransposeChordName(name, *by, spelling)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+			exitCode = 1
+			return
+		}
+		transposed = append(transposed, t)
+	}
+	fmt.Println(strings.Join(transposed, " "))
+}
+
+// addNotationFlag defines the --notation flag of the commands that read and
+// print note and chord names.
+func addNotationFlag(fs *flag.FlagSet) *string {
+	return fs.String("notation", "english", "Note names for input and output: english, german, dutch or solfege.")
+}
+
+// spellingFromFlags converts the --flats and --sharps flags into a Spelling.
+func spellingFromFlags(flats, sharps bool) (theory.Spelling, error) {
+	switch {
+	case flats && sharps:
+		return 0, errors.New("Error: --flats and --sharps cannot be used together.")
+	case flats:
+		return theory.SpellingFlats, nil
+	case sharps:
+		return theory.SpellingSharps, nil
+	}
+	return theory.SpellingAuto, nil
+}
+
+func runHelpCommand(args []string) {
+	if len(args) == 0 {
+		defineFlags()
+		printUsage()
+		return
+	}
+	c, ok := lookupCommand(args[0])
+	if !ok || c.name == "help" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown command '%s'.\n", args[0])
+		exitCode = 1
+		return
+	}
+	c.run([]string{"--help"})
+}
+
+// printParsedChord writes the root, quality, intervals and notes of a parsed chord name.
+func printParsedChord(w io.Writer, n theory.Notation, name string, root theory.Note, chordDef theory.Chord) {
+	fmt.Fprintf(w, "Chord: %s\n", name)
+	fmt.Fprintf(w, "Root: %s\n", n.NoteName(root))
+	fmt.Fprintf(w, "Quality: %s\n", chordDef.Name)
+	fmt.Fprintf(w, "Intervals: %v\n", chordDef.Intervals)
+	fmt.Fprintf(w, "Notes: %s\n\n", n.SliceToString(theory.GenerateNotes(root, chordDef.Intervals)))
+}
@@ -0,0 +1,239 @@
+// export.go
+// This file contains the flags and progression model shared by the exporters
+// that keys and batch can write to (--midi-out, --musicxml-out, --lilypond-out,
+// --wav-out).
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"cordelia/audio"
+	"cordelia/midi"
+	"cordelia/theory"
+)
+
+// progressionChord is a chord symbol with its notes, root first, as written by
+// the exporters. Quality and Suffix come from the dictionary chord and are
+// empty when the notes matched nothing; Bass is set for slash chords. Parsed
+// is the chord as a theory.ChordSymbol, without intervals when nothing
+// matched, so analyses need not re-read Symbol in the input notation.
+type progressionChord struct {
+	Symbol  string
+	Root    theory.Note
+	Quality string
+	Suffix  string
+	Bass    *theory.Note
+	Notes   []theory.Note
+	Parsed  theory.ChordSymbol
+}
+
+// progressionFromChordNames spells each chord name with GenerateNotes.
+func progressionFromChordNames(a *theory.Analyzer, names []string) ([]progressionChord, error) {
+	var chords []progressionChord
+	for _, name := range names {
+		c, err := progressionChordFromName(a, name)
+		if err != nil {
+			return nil, err
+		}
+		chords = append(chords, c)
+	}
+	return chords, nil
+}
+
+// progressionChordFromName spells a chord name with GenerateNotes. A slash
+// bass such as "C/G" is put first in the notes. The error is a
+// *theory.ChordNameError.
+func progressionChordFromName(a *theory.Analyzer, name string) (progressionChord, error) {
+	symbol, err := a.ParseChordSymbol(name)
+	if err != nil {
+		return progressionChord{}, &theory.ChordNameError{Name: name, Err: err}
+	}
+	return progressionChordFromSymbol(name, symbol), nil
+}
+
+// progressionChordFromSymbol spells a parsed chord symbol, written as name.
+func progressionChordFromSymbol(name string, symbol theory.ChordSymbol) progressionChord {
+	c := progressionChord{
+		Symbol:  name,
+		Root:    symbol.Root,
+		Quality: symbol.Chord.Name,
+		Suffix:  symbol.Suffix,
+		Bass:    symbol.Bass,
+		Notes:   theory.GenerateNotes(symbol.Root, symbol.Chord.Intervals),
+		Parsed:  symbol,
+	}
+	if symbol.Bass != nil {
+		c.Notes = theory.Unique(append([]theory.Note{*symbol.Bass}, c.Notes...))
+	}
+	return c
+}
+
+// progressionChordFromLine uses the best match of a batch line, or the line's
+// own notes when nothing matched.
+func progressionChordFromLine(line theory.LineResult) progressionChord {
+	if len(line.Matches) == 0 {
+		return progressionChord{Symbol: line.Input, Root: line.Root, Notes: line.Notes, Parsed: theory.ChordSymbol{Root: line.Root}}
+	}
+	best := line.Matches[0]
+	return progressionChord{
+		Symbol:  line.Symbol(best),
+		Root:    line.Root,
+		Quality: best.Name,
+		Suffix:  best.Suffix,
+		Notes:   theory.GenerateNotes(line.Root, best.Intervals),
+		Parsed: theory.ChordSymbol{
+			Root:   line.Root,
+			Suffix: best.Suffix,
+			Chord:  theory.Chord{Name: best.Name, Suffixes: []string{best.Suffix}, Intervals: best.Intervals},
+		},
+	}
+}
+
+// spelling returns the note that names a MIDI key of the voiced chord: the
+// root or bass where they share its pitch class, and otherwise a flat name
+// when flats is set and a sharp name when it is not.
+func (c progressionChord) spelling(key int, flats bool) theory.Note {
+	pc := key % 12
+	switch {
+	case c.Bass != nil && c.Bass.Value == pc:
+		return *c.Bass
+	case c.Root.Value == pc && c.Root.Original != "":
+		return c.Root
+	}
+	return theory.Note{Original: theory.NoteName(pc, flats), Value: pc}
+}
+
+// flatSpellings decides, for each chord, whether its other notes are named
+// with flats: when its root is spelled with a flat, or when its root is
+// natural and more roots of the progression use flats than sharps.
+func flatSpellings(chords []progressionChord) []bool {
+	balance := 0
+	for _, c := range chords {
+		switch {
+		case theory.IsFlat(c.Root):
+			balance++
+		case strings.Contains(c.Root.Original, "#"):
+			balance--
+		}
+	}
+	flats := make([]bool, len(chords))
+	for i, c := range chords {
+		flats[i] = theory.IsFlat(c.Root) || (balance > 0 && !strings.Contains(c.Root.Original, "#"))
+	}
+	return flats
+}
+
+// spellingOf splits a note name into its letter and alteration in semitones.
+func spellingOf(n theory.Note) (string, int) {
+	step := strings.ToUpper(n.Original[:1])
+	rest := strings.ToLower(n.Original[1:])
+	return step, strings.Count(rest, "#") - strings.Count(rest, "b")
+}
+
+// exportFlags holds the flags shared by commands that can write their chords
+// to files.
+type exportFlags struct {
+	midiOut     *string
+	musicXMLOut *string
+	lilypondOut *string
+	wavOut      *string
+	tempo       *float64
+	beats       *float64
+	octave      *int
+	voicing     *string
+	symbols     *string
+	arpeggio    *string
+	timbre      *string
+}
+
+func addExportFlags(fs *flag.FlagSet) *exportFlags {
+	return &exportFlags{
+		midiOut:     fs.String("midi-out", "", "Write the chords to this Standard MIDI File."),
+		musicXMLOut: fs.String("musicxml-out", "", "Write the chords to this MusicXML file."),
+		lilypondOut: fs.String("lilypond-out", "", "Write the chords to this LilyPond (.ly) file."),
+		wavOut:      fs.String("wav-out", "", "Render the chords to this WAV file for audition."),
+		tempo:       fs.Float64("tempo", 120, "Tempo in BPM for --midi-out and --wav-out."),
+		beats:       fs.Float64("beats-per-chord", 4, "Length of each chord in beats for --midi-out and --wav-out."),
+		octave:      fs.Int("octave", 4, "Octave of each chord's root in exported files (4 puts C on middle C)."),
+		voicing:     fs.String("voicing", "close", "Chord voicing in exported files: close, open or drop2."),
+		symbols:     fs.String("symbols", "marker", "Meta-event for chord symbols in --midi-out: marker, lyric or none."),
+		arpeggio:    fs.String("arpeggio", "off", "Arpeggiate each chord in --wav-out: off, up, down or updown."),
+		timbre:      fs.String("timbre", "additive", "Voice of --wav-out: sine or additive."),
+	}
+}
+
+// enabled reports whether any output file was requested.
+func (e *exportFlags) enabled() bool {
+	return e != nil && e.outputFlag() != ""
+}
+
+// outputFlag names the first output flag that is set, for error messages.
+func (e *exportFlags) outputFlag() string {
+	switch {
+	case *e.midiOut != "":
+		return "--midi-out"
+	case *e.musicXMLOut != "":
+		return "--musicxml-out"
+	case *e.lilypondOut != "":
+		return "--lilypond-out"
+	case *e.wavOut != "":
+		return "--wav-out"
+	}
+	return ""
+}
+
+// validate checks the export flags, returning an error in the CLI's message style.
+func (e *exportFlags) validate() error {
+	if _, err := midi.ParseVoicing(*e.voicing); err != nil {
+		return fmt.Errorf("Error: %v.", err)
+	}
+	if _, err := midi.ParseSymbolEvent(*e.symbols); err != nil {
+		return fmt.Errorf("Error: %v.", err)
+	}
+	if _, err := audio.ParseArpeggio(*e.arpeggio); err != nil {
+		return fmt.Errorf("Error: %v.", err)
+	}
+	if _, err := audio.ParseTimbre(*e.timbre); err != nil {
+		return fmt.Errorf("Error: %v.", err)
+	}
+	if !(*e.tempo >= 20 && *e.tempo <= 400) {
+		return errors.New("Error: --tempo must be between 20 and 400.")
+	}
+	if !(*e.beats > 0 && *e.beats <= 64) {
+		return errors.New("Error: --beats-per-chord must be greater than zero and at most 64.")
+	}
+	if *e.octave < -1 || *e.octave > 9 {
+		return errors.New("Error: --octave must be between -1 and 9.")
+	}
+	return nil
+}
+
+// voice spreads a chord's notes into MIDI keys with the --octave and --voicing flags.
+func (e *exportFlags) voice(c progressionChord) []int {
+	voicing, _ := midi.ParseVoicing(*e.voicing)
+	pitchClasses := make([]int, len(c.Notes))
+	for i, n := range c.Notes {
+		pitchClasses[i] = n.Value
+	}
+	return midi.Voice(pitchClasses, *e.octave, voicing)
+}
+
+// write writes the chords to every requested output file.
+func (e *exportFlags) write(a *theory.Analyzer, chords []progressionChord) {
+	if *e.midiOut != "" {
+		writeMidiExport(e, chords)
+	}
+	if *e.musicXMLOut != "" {
+		writeMusicXMLExport(e, chords)
+	}
+	if *e.lilypondOut != "" {
+		writeLilyPondExport(e, a, chords)
+	}
+	if *e.wavOut != "" {
+		writeWavExport(e, chords)
+	}
+}
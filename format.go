@@ -0,0 +1,103 @@
+// format.go
+// This file contains the tabular (CSV/TSV) output for batch processing.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"cordelia/theory"
+)
+
+// Output formats accepted by the --format flag.
+const (
+	formatText = "text"
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+)
+
+// batchColumns is the header row for tabular batch output.
+var batchColumns = []string{"line", "input", "root", "intervals", "best_match", "all_matches", "subset", "error"}
+
+// keyColumns is the header row for the key estimation section.
+var keyColumns = []string{"key", "matches"}
+
+// batchTableWriter writes batch results as CSV or TSV rows.
+type batchTableWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+	notation      theory.Notation // for the note names in each row
+}
+
+func newBatchTableWriter(out io.Writer, format string) *batchTableWriter {
+	w := csv.NewWriter(out)
+	if format == formatTSV {
+		w.Comma = '\t'
+	}
+	return &batchTableWriter{w: w}
+}
+
+// WriteLine writes one row for an analysed batch line, emitting the header first.
+func (t *batchTableWriter) WriteLine(b theory.LineResult) {
+	if !t.headerWritten {
+		t.w.Write(batchColumns)
+		t.headerWritten = true
+	}
+	t.w.Write(batchRecord(b, t.notation))
+}
+
+// WriteKeys writes the key estimation results as a separate section,
+// separated from the line rows by an empty record.
+func (t *batchTableWriter) WriteKeys(estimate theory.KeyEstimate) {
+	if t.headerWritten {
+		t.w.Write(nil)
+	}
+	t.w.Write(keyColumns)
+	for _, km := range estimate.Keys {
+		t.w.Write([]string{t.notation.Localize(km.Name), strconv.Itoa(km.MatchCount)})
+	}
+}
+
+// Flush writes any buffered rows and reports the first write error.
+func (t *batchTableWriter) Flush() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+// batchRecord converts a batch line into its column values, with note names
+// in the given notation.
+func batchRecord(b theory.LineResult, n theory.Notation) []string {
+	record := []string{strconv.Itoa(b.LineNum), b.Input, "", "", "", "", "", ""}
+	if b.Err != nil {
+		record[7] = b.Err.Error()
+		return record
+	}
+
+	root := n.NoteName(b.Root)
+	record[2] = root
+	record[3] = intervalsToString(b.Intervals)
+	if len(b.Matches) > 0 {
+		best := b.Matches[0]
+		record[4] = fmt.Sprintf("%s %s", root, best.Name)
+		record[6] = strconv.FormatBool(b.IsSubset(best))
+	}
+	var names []string
+	for _, m := range b.Matches {
+		names = append(names, fmt.Sprintf("%s %s", root, m.Name))
+	}
+	record[5] = strings.Join(names, "; ")
+	return record
+}
+
+// intervalsToString renders intervals as space-separated semitone counts.
+func intervalsToString(intervals []int) string {
+	parts := make([]string, len(intervals))
+	for i, interval := range intervals {
+		parts[i] = strconv.Itoa(interval)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,60 @@
+// format_test.go
+// This file contains the tests for the tabular batch output.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"cordelia/theory"
+)
+
+func TestBatchTableWriter(t *testing.T) {
+	t.Parallel()
+	root := theory.Note{Original: "C", Value: 0}
+	lines := []theory.LineResult{
+		{
+			LineNum: 1,
+			Input:   "C E G Bb Db F#",
+			Identification: theory.Identification{
+				Notes:     make([]theory.Note, 6),
+				Root:      root,
+				Intervals: []int{0, 1, 4, 6, 7, 10},
+				Matches:   []theory.Match{{Name: "7(b9,#11)", Intervals: []int{0, 1, 4, 6, 7, 10}}, {Name: "Major Triad", Intervals: []int{0, 4, 7}}},
+			},
+		},
+		{LineNum: 2, Input: "X", Err: errors.New("invalid note 'X' in input")},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{formatCSV, "line,input,root,intervals,best_match,all_matches,subset,error\n" +
+			"1,C E G Bb Db F#,C,0 1 4 6 7 10,\"C 7(b9,#11)\",\"C 7(b9,#11); C Major Triad\",false,\n" +
+			"2,X,,,,,,invalid note 'X' in input\n"},
+		{formatTSV, "line\tinput\troot\tintervals\tbest_match\tall_matches\tsubset\terror\n" +
+			"1\tC E G Bb Db F#\tC\t0 1 4 6 7 10\tC 7(b9,#11)\tC 7(b9,#11); C Major Triad\tfalse\t\n" +
+			"2\tX\t\t\t\t\t\tinvalid note 'X' in input\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			table := newBatchTableWriter(&buf, tt.format)
+			for _, line := range lines {
+				table.WriteLine(line)
+			}
+			if err := table.Flush(); err != nil {
+				t.Fatalf("Flush() returned error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Expected output %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
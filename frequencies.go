@@ -0,0 +1,85 @@
+// frequencies.go
+// This file contains the --hz input mode, which maps frequencies to the
+// nearest notes before identifying the chord.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"cordelia/theory"
+)
+
+// frequencyOptions controls how frequencies are mapped to notes.
+type frequencyOptions struct {
+	a4        float64 // tuning of A4 in Hz
+	tolerance float64 // largest deviation in cents that is not flagged
+	notation  theory.Notation
+}
+
+// validateFrequencyOptions checks the --a4 and --tolerance flags.
+func validateFrequencyOptions(opts frequencyOptions) error {
+	if opts.a4 <= 0 {
+		return fmt.Errorf("Error: --a4 must be greater than zero.")
+	}
+	if opts.tolerance < 0 || opts.tolerance > 50 {
+		return fmt.Errorf("Error: --tolerance must be between 0 and 50 cents.")
+	}
+	return nil
+}
+
+// notesFromFrequencies maps each frequency to its nearest note and prints the
+// mapping, flagging frequencies further than the tolerance from any note. The
+// notes are returned from the lowest frequency up, without repeated pitch
+// classes, so that the lowest sounding note is taken as the root.
+func notesFromFrequencies(w io.Writer, args []string, opts frequencyOptions) ([]theory.Note, error) {
+	mapped := make([]theory.FrequencyNote, 0, len(args))
+	fmt.Fprintf(w, "Frequencies (A4 = %g Hz):\n", opts.a4)
+	for _, arg := range args {
+		freq, err := theory.ParseFrequency(arg)
+		if err != nil {
+			return nil, err
+		}
+		fn, err := theory.NoteFromFrequency(freq, opts.a4)
+		if err != nil {
+			return nil, err
+		}
+		name := opts.notation.Localize(fn.Name())
+		fmt.Fprintf(w, " %g Hz -> %s (%+.1f cents", freq, name, fn.Cents)
+		if max(fn.Cents, -fn.Cents) > opts.tolerance {
+			lo, hi := name, opts.notation.Localize(fn.Neighbor())
+			if fn.Cents < 0 {
+				lo, hi = hi, lo
+			}
+			fmt.Fprintf(w, ", out of tune: between %s and %s", lo, hi)
+		}
+		fmt.Fprintln(w, ")")
+		mapped = append(mapped, fn)
+	}
+	fmt.Fprintln(w, "---")
+
+	sort.SliceStable(mapped, func(i, j int) bool { return mapped[i].Frequency < mapped[j].Frequency })
+	seen := make(map[int]bool)
+	var notes []theory.Note
+	for _, fn := range mapped {
+		if !seen[fn.Note.Value] {
+			seen[fn.Note.Value] = true
+			notes = append(notes, fn.Note)
+		}
+	}
+	return notes, nil
+}
+
+// runFrequencyMode identifies the chord formed by the given frequencies.
+func runFrequencyMode(a *theory.Analyzer, args []string, opts frequencyOptions, verbose bool) {
+	notes, err := notesFromFrequencies(os.Stdout, args, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+	printIdentifications(os.Stdout, a, a.Identify(notes), verbose)
+}
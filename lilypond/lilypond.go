@@ -0,0 +1,146 @@
+// Package lilypond writes chord progressions as LilyPond source: a
+// \chordmode block of chord symbols above a staff of voiced chords.
+package lilypond
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Version is the LilyPond version written in the \version statement.
+const Version = "2.24.0"
+
+// Pitch is a written pitch. Octave 4 is the octave of middle C.
+type Pitch struct {
+	Step   string // C, D, E, F, G, A or B
+	Alter  int    // semitones, e.g. -1 for flat
+	Octave int
+}
+
+// Name returns the pitch name in LilyPond's default (Dutch) note names,
+// without an octave, e.g. "bes" or "fis".
+func (p Pitch) Name() string {
+	name := strings.ToLower(p.Step)
+	switch {
+	case p.Alter > 0:
+		name += strings.Repeat("is", p.Alter)
+	case p.Alter < 0:
+		name += strings.Repeat("es", -p.Alter)
+	}
+	return name
+}
+
+// String returns the pitch in absolute octave notation, where c' is middle C.
+func (p Pitch) String() string {
+	marks := p.Octave - 3
+	if marks >= 0 {
+		return p.Name() + strings.Repeat("'", marks)
+	}
+	return p.Name() + strings.Repeat(",", -marks)
+}
+
+// Chord is one whole-note chord of a progression.
+type Chord struct {
+	// Root and Modifier form the chord symbol, e.g. "a" and ":m7". A nil
+	// Root writes a rest in the chord names.
+	Root     *Pitch
+	Modifier string
+	Bass     *Pitch
+	// Pitches are written as a chord on the staff; none writes a rest.
+	Pitches []Pitch
+}
+
+// Key is a key signature.
+type Key struct {
+	Tonic Pitch // the octave is ignored
+	Minor bool
+}
+
+// Options configures Write.
+type Options struct {
+	Key *Key // nil leaves out \key
+}
+
+// Write writes a complete LilyPond file for the chords. The staff uses the
+// bass clef when the chords sit mostly below middle C.
+func Write(w io.Writer, chords []Chord, opts Options) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\\version %q\n\n", Version)
+
+	fmt.Fprintln(bw, "harmonies = \\chordmode {")
+	for _, c := range chords {
+		fmt.Fprintf(bw, "  %s\n", c.symbol())
+	}
+	fmt.Fprintln(bw, "}")
+	fmt.Fprintln(bw)
+
+	fmt.Fprintln(bw, "music = {")
+	if clefFor(chords) == "bass" {
+		fmt.Fprintln(bw, "  \\clef bass")
+	}
+	if opts.Key != nil {
+		mode := "\\major"
+		if opts.Key.Minor {
+			mode = "\\minor"
+		}
+		fmt.Fprintf(bw, "  \\key %s %s\n", opts.Key.Tonic.Name(), mode)
+	}
+	fmt.Fprintln(bw, "  \\time 4/4")
+	for _, c := range chords {
+		fmt.Fprintf(bw, "  %s\n", c.staffChord())
+	}
+	fmt.Fprintln(bw, "}")
+	fmt.Fprintln(bw)
+
+	fmt.Fprint(bw, `\score {
+  <<
+    \new ChordNames \harmonies
+    \new Staff \music
+  >>
+  \layout { }
+}
+`)
+	return bw.Flush()
+}
+
+// symbol renders the chord in \chordmode, e.g. "a1:m7/g".
+func (c Chord) symbol() string {
+	if c.Root == nil {
+		return "r1"
+	}
+	s := c.Root.Name() + "1" + c.Modifier
+	if c.Bass != nil {
+		s += "/" + c.Bass.Name()
+	}
+	return s
+}
+
+// staffChord renders the chord's pitches as a whole-note chord, e.g. "<c' e' g'>1".
+func (c Chord) staffChord() string {
+	if len(c.Pitches) == 0 {
+		return "r1"
+	}
+	names := make([]string, len(c.Pitches))
+	for i, p := range c.Pitches {
+		names[i] = p.String()
+	}
+	return "<" + strings.Join(names, " ") + ">1"
+}
+
+var stepValues = map[string]int{"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11}
+
+func clefFor(chords []Chord) string {
+	total, count := 0, 0
+	for _, c := range chords {
+		for _, p := range c.Pitches {
+			total += 12*(p.Octave+1) + stepValues[p.Step] + p.Alter
+			count++
+		}
+	}
+	if count > 0 && total/count < 60 {
+		return "bass"
+	}
+	return "treble"
+}
@@ -0,0 +1,69 @@
+package lilypond
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPitchString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pitch    Pitch
+		expected string
+	}{
+		{Pitch{Step: "C", Octave: 4}, "c'"},
+		{Pitch{Step: "B", Alter: -1, Octave: 3}, "bes"},
+		{Pitch{Step: "F", Alter: 1, Octave: 5}, "fis''"},
+		{Pitch{Step: "E", Alter: -2, Octave: 2}, "eeses,"},
+	}
+	for _, tt := range tests {
+		if got := tt.pitch.String(); got != tt.expected {
+			t.Errorf("%+v.String() = %q, want %q", tt.pitch, got, tt.expected)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	a := Pitch{Step: "A", Octave: 3}
+	g := Pitch{Step: "G", Octave: 3}
+	chords := []Chord{
+		{Root: &a, Modifier: ":m7", Bass: &g, Pitches: []Pitch{g, a, {Step: "C", Octave: 4}, {Step: "E", Octave: 4}}},
+		{Pitches: []Pitch{{Step: "C", Octave: 4}, {Step: "D", Octave: 4}}},
+		{},
+	}
+	key := &Key{Tonic: Pitch{Step: "A"}, Minor: true}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, chords, Options{Key: key}); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+	expected := `\version "2.24.0"
+
+harmonies = \chordmode {
+  a1:m7/g
+  r1
+  r1
+}
+
+music = {
+  \clef bass
+  \key a \minor
+  \time 4/4
+  <g a c' e'>1
+  <c' d'>1
+  r1
+}
+
+\score {
+  <<
+    \new ChordNames \harmonies
+    \new Staff \music
+  >>
+  \layout { }
+}
+`
+	if got := buf.String(); got != expected {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, expected)
+	}
+}
@@ -0,0 +1,97 @@
+// lilypondfile.go
+// This file contains the --lilypond-out export, which writes a progression as
+// LilyPond chord names above a staff.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"cordelia/lilypond"
+	"cordelia/theory"
+)
+
+// lilypondModifiers maps dictionary chord names to \chordmode modifiers.
+var lilypondModifiers = map[string]string{
+	"Major Triad":      "",
+	"Minor Triad":      ":m",
+	"Augmented Triad":  ":aug",
+	"Diminished Triad": ":dim",
+	"Dominant 7th":     ":7",
+	"Major 7th":        ":maj7",
+	"Minor 7th":        ":m7",
+	"Minor-Major 7th":  ":m7+",
+	"Sus2":             ":sus2",
+	"Sus4":             ":sus4",
+}
+
+func noteToLilyPitch(key int, n theory.Note) lilypond.Pitch {
+	step, alter := spellingOf(n)
+	p := noteToPitch(key, n)
+	return lilypond.Pitch{Step: step, Alter: alter, Octave: p.Octave}
+}
+
+// lilypondChord converts a progression chord for the LilyPond writer. Chords
+// with no LilyPond modifier, or that matched nothing, are shown as notes only.
+func (e *exportFlags) lilypondChord(c progressionChord, flats bool) lilypond.Chord {
+	var chord lilypond.Chord
+	if modifier, ok := lilypondModifiers[c.Quality]; ok && c.Quality != "" {
+		root := noteToLilyPitch(c.Root.Value, c.Root)
+		chord.Root = &root
+		chord.Modifier = modifier
+		if c.Bass != nil {
+			bass := noteToLilyPitch(c.Bass.Value, *c.Bass)
+			chord.Bass = &bass
+		}
+	}
+	for _, key := range e.voice(c) {
+		chord.Pitches = append(chord.Pitches, noteToLilyPitch(key, c.spelling(key, flats)))
+	}
+	return chord
+}
+
+// lilypondKey picks the key signature from a key estimate over the chords,
+// breaking ties in favour of the first and last roots.
+func lilypondKey(a *theory.Analyzer, chords []progressionChord) *lilypond.Key {
+	var notes []theory.Note
+	for _, c := range chords {
+		notes = append(notes, c.Notes...)
+	}
+	if len(notes) == 0 {
+		return nil
+	}
+	best, ok := a.EstimateKeys(notes).Best(chords[0].Root, chords[len(chords)-1].Root)
+	if !ok {
+		return nil
+	}
+	tonic, minor, err := theory.ParseKeyName(best.Name)
+	if err != nil {
+		return nil // a custom key without a tonic and mode
+	}
+	return &lilypond.Key{Tonic: noteToLilyPitch(tonic.Value, tonic), Minor: minor}
+}
+
+// writeLilyPondExport writes the chords to the --lilypond-out file.
+func writeLilyPondExport(e *exportFlags, a *theory.Analyzer, chords []progressionChord) {
+	flats := flatSpellings(chords)
+	lilyChords := make([]lilypond.Chord, len(chords))
+	for i, c := range chords {
+		lilyChords[i] = e.lilypondChord(c, flats[i])
+	}
+
+	file, err := os.Create(*e.lilypondOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", *e.lilypondOut, err)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	if err := lilypond.Write(file, lilyChords, lilypond.Options{Key: lilypondKey(a, chords)}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write %s: %v\n", *e.lilypondOut, err)
+		exitCode = 1
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d chords to %s\n", len(lilyChords), *e.lilypondOut)
+}
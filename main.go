@@ -1,9 +1,9 @@
 // main.go
-// This file contains the complete implementation for Cordelia, a command-line
-// chord and key identification utility.
+// This file contains the command-line entry point for Cordelia, a chord and key
+// identification utility. The note, chord and key logic lives in package theory.
 // Version: 0.4
-// To run, save this file and execute:
-// go run main.go -- [args]
+// To run, execute from the module directory:
+// go run . -- [args]
 
 package main
 
@@ -12,11 +12,11 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-//	"path/filepath"
-	"sort"
 	"strings"
-	"unicode"
+
+	"cordelia/theory"
 )
 
 // --- Global Variables ---
@@ -27,7 +27,15 @@ var (
 	batchFlag      string
 	keysFlag       bool
 	verboseFlag    bool
+	formatFlag     string
 	helpFlag       bool
+	hzFlag         bool
+	a4Flag         float64
+	toleranceFlag  float64
+	notationFlag   string
+	nashvilleFlag  bool
+	voiceLeadFlag  bool
+	export         *exportFlags
 
 	// exit is a hook for testing to intercept calls to os.Exit.
 	exit = os.Exit
@@ -39,6 +47,15 @@ var exitCode = 0
 // --- Main Function ---
 // Entry point of the application.
 func main() {
+	// Subcommands have their own flags; anything else uses the legacy flags below.
+	if len(os.Args) > 1 {
+		if cmd, ok := lookupCommand(os.Args[1]); ok {
+			cmd.run(os.Args[2:])
+			exit(exitCode)
+			return
+		}
+	}
+
 	// Setup and parse command-line flags.
 	setupFlags()
 
@@ -58,21 +75,29 @@ func main() {
 
 	// Determine the source of notes (flags vs. positional args).
 	args := flag.Args()
+	notation, _ := theory.ParseNotation(notationFlag)
+	analyzer := theory.NewAnalyzer(theory.Options{Inversions: inversionsFlag, Notation: notation})
 
 	// Decide program mode based on flags.
-	if keysFlag {
-		if batchFlag != "" {
-			// Key estimation from a batch file of notes.
-			runBatchMode(batchFlag)
-		} else {
-			// Key estimation from CLI args (chord names).
-			if len(args) == 0 {
-				fmt.Fprintln(os.Stderr, "Error: No chord names provided for key estimation.")
-				exit(1)
-				return
-			}
-			runKeyEstimationFromArgs(args)
+	if batchFlag != "" {
+		// Batch identification from a file of notes, with key estimation if --keys is set.
+		runBatchMode(analyzer, batchFlag, batchOptions{keys: keysFlag, nashville: nashvilleFlag, voiceLeading: voiceLeadFlag, format: formatFlag, export: export})
+	} else if keysFlag {
+		// Key estimation from CLI args (chord names).
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No chord names provided for key estimation.")
+			exit(1)
+			return
+		}
+		runKeyEstimationFromArgs(analyzer, args, keyOptions{nashville: nashvilleFlag, voiceLeading: voiceLeadFlag, export: export})
+	} else if hzFlag {
+		// Single chord identification from frequencies.
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: No frequencies provided.")
+			exit(1)
+			return
 		}
+		runFrequencyMode(analyzer, args, frequencyOptions{a4: a4Flag, tolerance: toleranceFlag, notation: notation}, verboseFlag)
 	} else {
 		// Single chord identification from notes.
 		noteStrings, err := getNoteStringsFromInput(args)
@@ -86,7 +111,7 @@ func main() {
 			exit(1)
 			return
 		}
-		runSingleChordMode(noteStrings)
+		runSingleChordMode(analyzer, noteStrings)
 	}
 
 	exit(exitCode)
@@ -94,39 +119,105 @@ func main() {
 
 // --- CLI & Program Flow ---
 
-// setupFlags defines and configures the command-line flags.
+// setupFlags defines and parses the legacy command-line flags.
 func setupFlags() {
+	defineFlags()
+	flag.Parse()
+}
+
+// defineFlags defines the legacy flags and the top-level usage message.
+func defineFlags() {
 	flag.StringVar(&notesFlag, "notes", "", "Comma-separated list of notes (e.g., \"C,E,G,Bb\").")
 	flag.BoolVar(&inversionsFlag, "inversions", false, "Enable inversion detection by treating each note as a potential root.")
 	flag.StringVar(&batchFlag, "batch", "", "Path to a file containing multiple chords (one chord per line, notes-based).")
 	flag.BoolVar(&keysFlag, "keys", false, "Enables key estimation.")
 	flag.BoolVar(&verboseFlag, "verbose", false, "Show detailed matching logic, including failed checks.")
+	flag.StringVar(&formatFlag, "format", formatText, "Output format for --batch: text, csv or tsv.")
+	flag.BoolVar(&hzFlag, "hz", false, "Treat arguments as frequencies in Hz and map them to the nearest notes.")
+	flag.Float64Var(&a4Flag, "a4", 440, "Tuning of A4 in Hz for --hz.")
+	flag.Float64Var(&toleranceFlag, "tolerance", 25, "Deviation in cents beyond which --hz flags a frequency as out of tune.")
+	flag.StringVar(&notationFlag, "notation", "english", "Note names for input and output: english, german, dutch or solfege.")
+	flag.BoolVar(&nashvilleFlag, "nashville", false, "With --keys or --batch, write the chords as Nashville numbers in the estimated key.")
+	flag.BoolVar(&voiceLeadFlag, "voice-leading", false, "With --keys or --batch, compare each chord with the next: common tones, voice motion and the smoothest voicing.")
 	flag.BoolVar(&helpFlag, "help", false, "Display usage information.")
+	export = addExportFlags(flag.CommandLine)
 
-	// Custom usage message to match the spec.
-	flag.Usage = func() {
-		appName := "cordelia"
-		fmt.Fprintf(os.Stderr, "Usage of %s:\n", appName)
-		fmt.Fprintf(os.Stderr, "  Identify a chord from notes: %s [flags] <note1> <note2> ...\n", appName)
-		fmt.Fprintf(os.Stderr, "  Estimate key from chords:    %s --keys <chord1> <chord2> ...\n", appName)
-		fmt.Fprintf(os.Stderr, "  Batch processing from file:  %s --batch <file> [flags]\n", appName)
-		fmt.Fprintln(os.Stderr, "\nFlags:")
-		flag.PrintDefaults()
-	}
+	flag.Usage = printUsage
+}
 
-	flag.Parse()
+// printUsage writes the top-level help: subcommands first, then the legacy flags.
+func printUsage() {
+	appName := "cordelia"
+	fmt.Fprintf(os.Stderr, "Usage of %s:\n", appName)
+	fmt.Fprintf(os.Stderr, "  %s <command> [flags] [arguments...]\n", appName)
+	fmt.Fprintf(os.Stderr, "  Run '%s help <command>' for the flags of a command.\n\n", appName)
+	printCommandList(os.Stderr)
+	fmt.Fprintln(os.Stderr, "\nLegacy invocations (still supported):")
+	fmt.Fprintf(os.Stderr, "  Identify a chord from notes: %s [flags] <note1> <note2> ...\n", appName)
+	fmt.Fprintf(os.Stderr, "  Identify from frequencies:   %s --hz <freq1> <freq2> ...\n", appName)
+	fmt.Fprintf(os.Stderr, "  Estimate key from chords:    %s --keys <chord1> <chord2> ...\n", appName)
+	fmt.Fprintf(os.Stderr, "  Batch processing from file:  %s --batch <file> [flags]\n", appName)
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
 }
 
 // validateFlags checks for invalid combinations of flags.
 func validateFlags() error {
-	if !keysFlag && batchFlag != "" {
-		// Allow batch mode without keys for just chord identification.
-		return nil
+	if err := validateFormat(formatFlag); err != nil {
+		return err
+	}
+	if _, err := notationFromFlag(notationFlag); err != nil {
+		return err
+	}
+	if formatFlag != formatText && batchFlag == "" {
+		return fmt.Errorf("Error: --format %s requires --batch.", formatFlag)
+	}
+	if hzFlag {
+		if keysFlag || batchFlag != "" || notesFlag != "" {
+			return fmt.Errorf("Error: --hz cannot be combined with --keys, --batch or --notes.")
+		}
+		if err := validateFrequencyOptions(frequencyOptions{a4: a4Flag, tolerance: toleranceFlag}); err != nil {
+			return err
+		}
+	}
+	for name, set := range map[string]bool{"--nashville": nashvilleFlag, "--voice-leading": voiceLeadFlag} {
+		if !set {
+			continue
+		}
+		if !keysFlag && batchFlag == "" {
+			return fmt.Errorf("Error: %s requires --keys or --batch.", name)
+		}
+		if formatFlag != formatText {
+			return fmt.Errorf("Error: %s requires --format text.", name)
+		}
+	}
+	if export.enabled() {
+		if !keysFlag && batchFlag == "" {
+			return fmt.Errorf("Error: %s requires --keys or --batch.", export.outputFlag())
+		}
+		return export.validate()
 	}
-	// No invalid combinations to check in v0.4
 	return nil
 }
 
+// validateFormat checks that a batch output format is supported.
+func validateFormat(format string) error {
+	switch format {
+	case formatText, formatCSV, formatTSV:
+		return nil
+	}
+	return fmt.Errorf("Error: Unknown format '%s' (expected text, csv or tsv).", format)
+}
+
+// notationFromFlag converts a --notation value into a Notation.
+func notationFromFlag(value string) (theory.Notation, error) {
+	n, err := theory.ParseNotation(value)
+	if err != nil {
+		return 0, fmt.Errorf("Error: Unknown notation '%s' (expected english, german, dutch or solfege).", value)
+	}
+	return n, nil
+}
+
 // getNoteStringsFromInput determines which notes to use based on flags and args.
 func getNoteStringsFromInput(posArgs []string) ([]string, error) {
 	// --notes flag takes precedence.
@@ -140,54 +231,85 @@ func getNoteStringsFromInput(posArgs []string) ([]string, error) {
 	return posArgs, nil
 }
 
+// keyOptions controls the output of runKeyEstimationFromArgs.
+type keyOptions struct {
+	nashville    bool
+	voiceLeading bool
+	export       *exportFlags
+}
+
 // runKeyEstimationFromArgs handles the new mode for key estimation from chord names.
-func runKeyEstimationFromArgs(chordNames []string) {
-	var allNotes []Note
+// Cadences and chromatic chords in the estimated key are labelled after the
+// estimate. The options add Nashville numbers and voice leading to the
+// output, and write the progression to the requested export files.
+func runKeyEstimationFromArgs(a *theory.Analyzer, chordNames []string, opts keyOptions) {
 	fmt.Printf("Processing Chords: %s\n", strings.Join(chordNames, " "))
 
-	for _, name := range chordNames {
-		root, chordDef, err := ParseChordName(name)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
-			exitCode = 1
-			return
+	estimate, err := a.EstimateKeysFromChordNames(chordNames)
+	if err != nil {
+		var nameErr *theory.ChordNameError
+		if errors.As(err, &nameErr) {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", nameErr.Name, nameErr.Err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		}
-
-		chordNotes := GenerateNotes(root, chordDef.Intervals)
-		allNotes = append(allNotes, chordNotes...)
+		exitCode = 1
+		return
 	}
 
-	printKeyEstimation(allNotes)
-}
+	printKeyEstimation(os.Stdout, a.Notation(), estimate)
 
-// runSingleChordMode processes a single set of notes for chord identification.
-func runSingleChordMode(noteStrings []string) {
-	notes, err := parseAndValidateNotes(noteStrings)
+	progression, err := progressionFromChordNames(a, chordNames)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		exitCode = 1
 		return
 	}
+	positions := make([]int, len(progression))
+	for i := range positions {
+		positions[i] = i + 1
+	}
+	keyed := progressionInKey(estimate, progression)
+	printCadences(os.Stdout, a.Notation(), keyed, positions)
+	printChromaticChords(os.Stdout, a.Notation(), keyed, positions)
+	if opts.nashville {
+		printNashvilleNumbers(os.Stdout, a.Notation(), keyed)
+	}
+	if opts.voiceLeading {
+		steps := make([]voiceLeadingStep, len(progression))
+		for i, c := range progression {
+			steps[i] = voiceLeadingStep{position: i + 1, name: c.Symbol, notes: c.Notes}
+		}
+		printVoiceLeading(os.Stdout, a.Notation(), steps)
+	}
+	if opts.export.enabled() {
+		opts.export.write(a, progression)
+	}
+}
 
-	rootsToTest := []Note{notes[0]}
-	if inversionsFlag {
-		rootsToTest = notes
+// runSingleChordMode processes a single set of notes for chord identification.
+func runSingleChordMode(a *theory.Analyzer, noteStrings []string) {
+	results, err := a.IdentifyStrings(noteStrings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
 	}
 
-	for _, root := range rootsToTest {
-		intervals := CalculateIntervals(root, notes)
-		matches := FindMatches(intervals)
+	printIdentifications(os.Stdout, a, results, verboseFlag)
+}
 
-		if verboseFlag {
-			printVerboseOutput(root, notes, intervals, matches)
-		} else {
-			printStandardOutput(root, notes, intervals, matches)
-		}
-	}
+// batchOptions controls the output of runBatchMode.
+type batchOptions struct {
+	keys         bool
+	nashville    bool
+	voiceLeading bool
+	format       string
+	export       *exportFlags
 }
 
 // runBatchMode processes a file line by line.
-func runBatchMode(filename string) {
+func runBatchMode(a *theory.Analyzer, filename string, opts batchOptions) {
 	file, err := os.Open(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
@@ -207,52 +329,49 @@ func runBatchMode(filename string) {
 		return
 	}
 
-	fmt.Printf("Processing %s...\n", filename)
+	var table *batchTableWriter
+	if opts.format == formatText {
+		fmt.Printf("Processing %s...\n", filename)
+	} else {
+		table = newBatchTableWriter(os.Stdout, opts.format)
+		table.notation = a.Notation()
+	}
+
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
-	var allNotes []Note
+	var allNotes []theory.Note
+	var progression []progressionChord
+	var steps []voiceLeadingStep
 	batchHasErrors := false
 
 	for scanner.Scan() {
 		lineNum++
-		line := strings.TrimSpace(scanner.Text())
+		result := a.AnalyzeLine(lineNum, scanner.Text())
 
-		if line == "" {
-			fmt.Fprintf(os.Stderr, "Error on line %d: No notes provided\n", lineNum)
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error on line %d: %v\n", lineNum, result.Err)
 			batchHasErrors = true
-			continue
+		} else {
+			if opts.keys || opts.nashville {
+				allNotes = append(allNotes, result.Notes...)
+			}
+			progression = append(progression, progressionChordFromLine(result))
+			steps = append(steps, voiceLeadingStep{position: lineNum, name: a.Notation().SliceToString(result.Notes), notes: result.Notes})
 		}
 
-		noteStrings := strings.Fields(line)
-		notes, err := parseAndValidateNotes(noteStrings)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error on line %d: %v\n", lineNum, err)
-			batchHasErrors = true
+		if table != nil {
+			table.WriteLine(result)
 			continue
 		}
-
-		if keysFlag {
-			allNotes = append(allNotes, notes...)
-		}
-
-		root := notes[0]
-		intervals := CalculateIntervals(root, notes)
-		matches := FindMatches(intervals)
-
-		var matchStrings []string
-		for _, m := range matches {
-			isSubset := len(notes) > len(m.Intervals)
-			matchStr := fmt.Sprintf("%s %s", root.Original, m.Name)
-			if isSubset {
-				matchStr += " (subset)"
-			}
-			matchStrings = append(matchStrings, matchStr)
+		if result.Err != nil {
+			continue
 		}
 
+		matchStrings := localizeAll(a.Notation(), result.MatchStrings())
 		if len(matchStrings) == 0 {
-			fmt.Printf("[%d] %s -> No match found\n", lineNum, line)
+			fmt.Printf("[%d] %s -> No match found\n", lineNum, result.Input)
 		} else {
-			fmt.Printf("[%d] %s -> %s\n", lineNum, line, strings.Join(matchStrings, ", "))
+			fmt.Printf("[%d] %s -> %s\n", lineNum, result.Input, strings.Join(matchStrings, ", "))
 		}
 	}
 
@@ -262,357 +381,118 @@ func runBatchMode(filename string) {
 		return
 	}
 
-	if keysFlag {
-		printKeyEstimation(allNotes)
-	}
-
-	if batchHasErrors {
-		exitCode = 2
-	}
-}
-
-// --- Output Formatting ---
-
-func printStandardOutput(root Note, notes []Note, intervals []int, matches []Match) {
-	fmt.Printf("Input Notes: %s\n", SliceToString(notes))
-	fmt.Printf("Root: %s\n", root.Original)
-	fmt.Printf("Intervals: %v\n", intervals)
-	fmt.Println("Matched Chords:")
-	if len(matches) == 0 {
-		fmt.Println(" - None")
-	} else {
-		for _, m := range matches {
-			isSubset := len(notes) > len(m.Intervals)
-			matchStr := fmt.Sprintf(" - %s %s", root.Original, m.Name)
-			if isSubset {
-				matchStr += " (subset)"
+	if opts.keys || opts.nashville {
+		estimate := a.EstimateKeys(allNotes)
+		keyed := progressionInKey(estimate, progression)
+		if opts.keys && table != nil {
+			table.WriteKeys(estimate)
+		} else if opts.keys {
+			printKeyEstimation(os.Stdout, a.Notation(), estimate)
+			positions := make([]int, len(steps))
+			for i, step := range steps {
+				positions[i] = step.position
 			}
-			fmt.Println(matchStr)
+			printCadences(os.Stdout, a.Notation(), keyed, positions)
+			printChromaticChords(os.Stdout, a.Notation(), keyed, positions)
 		}
-	}
-	fmt.Println()
-}
-
-func printVerboseOutput(root Note, notes []Note, intervals []int, matches []Match) {
-	fmt.Printf("Input Notes: %s\n", SliceToString(notes))
-	fmt.Printf("Root: %s\n", root.Original)
-	fmt.Printf("Input Intervals: %v\n", intervals)
-	fmt.Println("---")
-	fmt.Println("Checking Dictionary...")
-
-	intervalSet := make(map[int]struct{})
-	for _, i := range intervals {
-		intervalSet[i] = struct{}{}
-	}
-
-	allChords := GetDictionary()
-	for _, c := range allChords {
-		match, reason := c.Check(intervals, intervalSet)
-		if match {
-			fmt.Printf("✅ Match: %s %v\n", c.Name, c.Intervals)
-		} else {
-			fmt.Printf("❌ No Match: %s %v (%s)\n", c.Name, c.Intervals, reason)
-		}
-	}
-
-	fmt.Println("---")
-	fmt.Println("Matched Chords:")
-	if len(matches) == 0 {
-		fmt.Println(" - None")
-	} else {
-		for _, m := range matches {
-			isSubset := len(notes) > len(m.Intervals)
-			matchStr := fmt.Sprintf(" - %s %s", root.Original, m.Name)
-			if isSubset {
-				matchStr += " (subset)"
-			}
-			fmt.Println(matchStr)
+		if opts.nashville {
+			printNashvilleNumbers(os.Stdout, a.Notation(), keyed)
 		}
 	}
-	fmt.Println()
-}
-
-func printKeyEstimation(allNotes []Note) {
-	fmt.Println("---")
-	fmt.Println("Key Estimation Results")
-
-	uniqueNotes := Unique(allNotes)
-	sort.Slice(uniqueNotes, func(i, j int) bool {
-		return uniqueNotes[i].Value < uniqueNotes[j].Value
-	})
-	fmt.Printf("Aggregated Notes: %s\n\n", SliceToString(uniqueNotes))
-
-	keyMatches := Estimate(uniqueNotes)
-	if len(keyMatches) == 0 {
-		fmt.Println("Could not determine likely keys.")
-	} else {
-		fmt.Println("Likely Keys:")
-		for _, km := range keyMatches {
-			fmt.Printf(" %s (%d matches)\n", km.Name, km.MatchCount)
-		}
+	if opts.voiceLeading {
+		printVoiceLeading(os.Stdout, a.Notation(), steps)
 	}
-}
 
-// --- Utility Functions ---
-
-func parseAndValidateNotes(noteStrings []string) ([]Note, error) {
-	var notes []Note
-	for _, s := range noteStrings {
-		s = strings.TrimSpace(s)
-		if s == "" {
-			continue
-		}
-		n, err := ParseNote(s)
-		if err != nil {
-			return nil, fmt.Errorf("invalid note '%s' in input", s)
+	if table != nil {
+		if err := table.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+			exitCode = 1
+			return
 		}
-		notes = append(notes, n)
-	}
-
-	if len(notes) == 0 {
-		return nil, errors.New("no valid notes provided")
 	}
 
-	return Unique(notes), nil
-}
-
-// =====================================================================================
-// SECTION: Note Logic
-// =====================================================================================
-
-type Note struct {
-	Original string
-	Value    int
-}
-
-var noteMap = map[string]int{
-	"C": 0, "B#": 0, "C#": 1, "DB": 1, "D": 2, "D#": 3, "EB": 3, "E": 4, "FB": 4,
-	"F": 5, "E#": 5, "F#": 6, "GB": 6, "G": 7, "G#": 8, "AB": 8, "A": 9, "A#": 10, "BB": 10,
-	"B": 11, "CB": 11,
-}
-
-var valueToName = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
-
-func ParseNote(s string) (Note, error) {
-	if s == "" {
-		return Note{}, fmt.Errorf("cannot parse empty string")
+	if opts.export.enabled() {
+		opts.export.write(a, progression)
 	}
-	runes := []rune(s)
-	normalized := string(unicode.ToTitle(runes[0]))
-	if len(runes) > 1 {
-		normalized += strings.ToUpper(string(runes[1:]))
-	}
-	normalized = strings.Replace(normalized, "b", "B", 1)
 
-	value, ok := noteMap[normalized]
-	if !ok {
-		return Note{}, fmt.Errorf("unrecognized note")
+	if batchHasErrors && exitCode == 0 {
+		exitCode = 2
 	}
-	return Note{Original: s, Value: value}, nil
 }
 
-func Unique(notes []Note) []Note {
-	seen := make(map[int]struct{})
-	var uniqueNotes []Note
-	for _, n := range notes {
-		if _, ok := seen[n.Value]; !ok {
-			seen[n.Value] = struct{}{}
-			uniqueNotes = append(uniqueNotes, n)
-		}
-	}
-	return uniqueNotes
-}
+// --- Output Formatting ---
 
-func SliceToString(notes []Note) string {
-	var parts []string
-	for _, n := range notes {
-		if n.Original == "" {
-			parts = append(parts, valueToName[n.Value])
+// printIdentifications prints each identification in standard or verbose form.
+func printIdentifications(w io.Writer, a *theory.Analyzer, results []theory.Identification, verbose bool) {
+	for _, id := range results {
+		if verbose {
+			printVerboseOutput(w, a, id)
 		} else {
-			parts = append(parts, n.Original)
+			printStandardOutput(w, a.Notation(), id)
 		}
 	}
-	return strings.Join(parts, " ")
 }
 
-// =====================================================================================
-// SECTION: Chord Logic
-// =====================================================================================
-
-type Chord struct {
-	Name      string
-	Suffixes  []string // Suffixes used for parsing chord names, e.g., "m", "min"
-	Intervals []int
+func printStandardOutput(w io.Writer, n theory.Notation, id theory.Identification) {
+	fmt.Fprintf(w, "Input Notes: %s\n", n.SliceToString(id.Notes))
+	fmt.Fprintf(w, "Root: %s\n", n.NoteName(id.Root))
+	fmt.Fprintf(w, "Intervals: %v\n", id.Intervals)
+	printMatchedChords(w, n, id)
 }
 
-type Match struct {
-	Name      string
-	Intervals []int
-}
-
-var chordDictionary = []Chord{
-	{Name: "Major 7th", Suffixes: []string{"maj7", "M7"}, Intervals: []int{0, 4, 7, 11}},
-	{Name: "Minor-Major 7th", Suffixes: []string{"m(maj7)"}, Intervals: []int{0, 3, 7, 11}},
-	{Name: "Minor 7th", Suffixes: []string{"m7", "min7"}, Intervals: []int{0, 3, 7, 10}},
-	{Name: "Dominant 7th", Suffixes: []string{"7", "dom7"}, Intervals: []int{0, 4, 7, 10}},
-	{Name: "Major Triad", Suffixes: []string{"", "M"}, Intervals: []int{0, 4, 7}},
-	{Name: "Minor Triad", Suffixes: []string{"m", "min"}, Intervals: []int{0, 3, 7}},
-	{Name: "Diminished Triad", Suffixes: []string{"dim"}, Intervals: []int{0, 3, 6}},
-	{Name: "Augmented Triad", Suffixes: []string{"aug", "+"}, Intervals: []int{0, 4, 8}},
-	{Name: "Sus2", Suffixes: []string{"sus2"}, Intervals: []int{0, 2, 7}},
-	{Name: "Sus4", Suffixes: []string{"sus4"}, Intervals: []int{0, 5, 7}},
-}
-
-func GetDictionary() []Chord {
-	return chordDictionary
-}
-
-// ParseChordName breaks a string like "F#m7" into a root note and a Chord definition.
-func ParseChordName(name string) (Note, Chord, error) {
-	// First, try to parse the longest possible note name (e.g., "C#", "Db").
-	var rootNote Note
-	var quality string
-	var err error
-
-	if len(name) > 1 {
-		// Check for two-character note names like "C#" or "Db"
-		if rootNote, err = ParseNote(name[:2]); err == nil {
-			quality = name[2:]
-		} else if rootNote, err = ParseNote(name[:1]); err == nil {
-			quality = name[1:]
+func printVerboseOutput(w io.Writer, a *theory.Analyzer, id theory.Identification) {
+	fmt.Fprintf(w, "Input Notes: %s\n", a.Notation().SliceToString(id.Notes))
+	fmt.Fprintf(w, "Root: %s\n", a.Notation().NoteName(id.Root))
+	fmt.Fprintf(w, "Input Intervals: %v\n", id.Intervals)
+	fmt.Fprintln(w, "---")
+	fmt.Fprintln(w, "Checking Dictionary...")
+
+	for _, check := range a.Explain(id.Intervals) {
+		c := check.Chord
+		if check.Matched {
+			fmt.Fprintf(w, "✅ Match: %s %v\n", c.Name, c.Intervals)
 		} else {
-			return Note{}, Chord{}, fmt.Errorf("invalid root note in chord name")
-		}
-	} else {
-		if rootNote, err = ParseNote(name); err == nil {
-			quality = ""
-		} else {
-			return Note{}, Chord{}, fmt.Errorf("invalid root note in chord name")
-		}
-	}
-
-	// Now find the chord definition that matches the quality suffix.
-	for _, chordDef := range chordDictionary {
-		for _, suffix := range chordDef.Suffixes {
-			if quality == suffix {
-				return rootNote, chordDef, nil
-			}
+			fmt.Fprintf(w, "❌ No Match: %s %v (%s)\n", c.Name, c.Intervals, check.Reason)
 		}
 	}
 
-	return Note{}, Chord{}, fmt.Errorf("unknown chord quality: '%s'", quality)
-}
-
-func GenerateNotes(root Note, intervals []int) []Note {
-	notes := make([]Note, len(intervals))
-	for i, interval := range intervals {
-		noteValue := (root.Value + interval) % 12
-		// We don't have the "original" spelling, so we create a canonical one.
-		notes[i] = Note{Original: valueToName[noteValue], Value: noteValue}
-	}
-	return notes
+	fmt.Fprintln(w, "---")
+	printMatchedChords(w, a.Notation(), id)
 }
 
-func CalculateIntervals(root Note, notes []Note) []int {
-	var intervals []int
-	for _, n := range notes {
-		interval := n.Value - root.Value
-		if interval < 0 {
-			interval += 12
-		}
-		intervals = append(intervals, interval)
-	}
-	sort.Ints(intervals)
-	return intervals
-}
-
-func (c Chord) Check(inputIntervals []int, inputSet map[int]struct{}) (bool, string) {
-	if len(inputIntervals) < len(c.Intervals) {
-		return false, fmt.Sprintf("requires %d intervals, input has %d", len(c.Intervals), len(inputIntervals))
-	}
-	for _, requiredInterval := range c.Intervals {
-		if _, ok := inputSet[requiredInterval]; !ok {
-			return false, fmt.Sprintf("missing interval %d", requiredInterval)
-		}
-	}
-	return true, ""
-}
-
-func FindMatches(intervals []int) []Match {
-	var matches []Match
-	intervalSet := make(map[int]struct{})
-	for _, i := range intervals {
-		intervalSet[i] = struct{}{}
-	}
-
-	for _, chordDef := range chordDictionary {
-		if ok, _ := chordDef.Check(intervals, intervalSet); ok {
-			matches = append(matches, Match{Name: chordDef.Name, Intervals: chordDef.Intervals})
+func printMatchedChords(w io.Writer, n theory.Notation, id theory.Identification) {
+	fmt.Fprintln(w, "Matched Chords:")
+	matchStrings := localizeAll(n, id.MatchStrings())
+	if len(matchStrings) == 0 {
+		fmt.Fprintln(w, " - None")
+	} else {
+		for _, matchStr := range matchStrings {
+			fmt.Fprintf(w, " - %s\n", matchStr)
 		}
 	}
-	return matches
+	fmt.Fprintln(w)
 }
 
-// =====================================================================================
-// SECTION: Key Logic
-// =====================================================================================
-
-type Key struct {
-	Name  string
-	Notes map[int]struct{}
-}
-
-type KeyMatch struct {
-	Name       string
-	MatchCount int
-}
-
-var keySignatures = []Key{}
-
-func init() {
-	noteNames := []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
-	flatNames := []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
-	majorPattern := []int{0, 2, 4, 5, 7, 9, 11}
-	minorPattern := []int{0, 2, 3, 5, 7, 8, 10}
+func printKeyEstimation(w io.Writer, n theory.Notation, estimate theory.KeyEstimate) {
+	fmt.Fprintln(w, "---")
+	fmt.Fprintln(w, "Key Estimation Results")
+	fmt.Fprintf(w, "Aggregated Notes: %s\n\n", n.SliceToString(estimate.Notes))
 
-	for i := 0; i < 12; i++ {
-		majorNotes := make(map[int]struct{})
-		for _, interval := range majorPattern {
-			majorNotes[(i+interval)%12] = struct{}{}
-		}
-		keySignatures = append(keySignatures, Key{Name: flatNames[i] + " Major", Notes: majorNotes})
-
-		minorNotes := make(map[int]struct{})
-		for _, interval := range minorPattern {
-			minorNotes[(i+interval)%12] = struct{}{}
+	if len(estimate.Keys) == 0 {
+		fmt.Fprintln(w, "Could not determine likely keys.")
+	} else {
+		fmt.Fprintln(w, "Likely Keys:")
+		for _, km := range estimate.Keys {
+			fmt.Fprintf(w, " %s (%d matches)\n", n.Localize(km.Name), km.MatchCount)
 		}
-		keySignatures = append(keySignatures, Key{Name: noteNames[i] + " Minor", Notes: minorNotes})
 	}
 }
 
-func Estimate(notes []Note) []KeyMatch {
-	if len(notes) == 0 {
-		return nil
-	}
-	var matches []KeyMatch
-	for _, keySig := range keySignatures {
-		count := 0
-		uniqueNotes := Unique(notes) // Ensure we only count each pitch class once
-		for _, n := range uniqueNotes {
-			if _, ok := keySig.Notes[n.Value]; ok {
-				count++
-			}
-		}
-		if count > 0 {
-			matches = append(matches, KeyMatch{Name: keySig.Name, MatchCount: count})
-		}
+// localizeAll translates the note names at the start of each string.
+func localizeAll(n theory.Notation, texts []string) []string {
+	localized := make([]string, len(texts))
+	for i, text := range texts {
+		localized[i] = n.Localize(text)
 	}
-	sort.Slice(matches, func(i, j int) bool {
-		if matches[i].MatchCount != matches[j].MatchCount {
-			return matches[i].MatchCount > matches[j].MatchCount
-		}
-		return matches[i].Name < matches[j].Name
-	})
-	return matches
+	return localized
 }
-
@@ -10,8 +10,6 @@ import (
 	"flag"
 	"io"
 	"os"
-	"reflect"
-	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -89,6 +87,272 @@ func TestCLI(t *testing.T) {
 			stdoutContains:   true,
 			expectedStdout:   "Matched Chords:\n - C Major Triad",
 		},
+		{
+			name:             "Chord From Frequencies",
+			args:             []string{"cordelia", "--hz", "329.6", "261.6", "392.0", "300"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   " 300 Hz -> D4 (+37.0 cents, out of tune: between D4 and D#4)",
+		},
+		{
+			name:             "Frequencies With Keys",
+			args:             []string{"cordelia", "--hz", "--keys", "440"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --hz cannot be combined with --keys, --batch or --notes.",
+		},
+		{
+			name:             "Spell With Tuning",
+			args:             []string{"cordelia", "spell", "--tuning", "just,12tet", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Tuning: 12-TET on C, A4 = 440 Hz\n C4     261.63 Hz\n E4     329.63 Hz\n G4     392.00 Hz\n C4-E4      400.0 cents, +13.7 from 5/4, 10.38 beats/s",
+		},
+		{
+			name:             "Unknown Tuning",
+			args:             []string{"cordelia", "identify", "--tuning", "werckmeister", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: unknown tuning 'werckmeister' (expected 12tet, just, pythagorean, meantone or a .scl file).",
+		},
+		{
+			name:             "German Notation",
+			args:             []string{"cordelia", "--notation", "german", "H", "Dis", "Fis"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Input Notes: H Dis Fis\nRoot: H\nIntervals: [0 4 7]\nMatched Chords:\n - H Major Triad",
+		},
+		{
+			name:             "Unknown Notation",
+			args:             []string{"cordelia", "spell", "--notation", "klingon", "C"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: Unknown notation 'klingon' (expected english, german, dutch or solfege).",
+		},
+		{
+			name:             "Nashville Chart",
+			args:             []string{"cordelia", "nashville", "--key", "G", "1", "4", "5m", "1/3"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Key: G Major\n[1] 1 -> G (G Major Triad): G B D\n[2] 4 -> C (C Major Triad): C E G\n[3] 5m -> Dm (D Minor Triad): D F A\n[4] 1/3 -> G/B (G Major Triad): B G D",
+		},
+		{
+			name:             "Keys As Nashville Numbers",
+			args:             []string{"cordelia", "--keys", "--nashville", "C", "Am7/G", "F", "G7"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Nashville Numbers (C Major): 1 6m7/5 4 5(7)",
+		},
+		{
+			name:             "Chord Scales In Estimated Key",
+			args:             []string{"cordelia", "chordscales", "Dm7", "G7", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Key: C Major (estimated)\nDm7 (D Minor 7th):\n D Dorian: D E F G A B C; diatonic",
+		},
+		{
+			name:             "Substitutes Suggest Each Chord Once",
+			args:             []string{"cordelia", "substitutes", "--key", "C", "Cmaj7"},
+			expectedExitCode: 0,
+			expectedStdout:   "Key: C Major\n[1] Cmaj7 (C Major 7th):\n Relative Minor: Am7 (A C E G); diatonic\n Diatonic Third: Em7 (E G B D); diatonic",
+		},
+		{
+			name:             "Tuning Kbm Without Scala File",
+			args:             []string{"cordelia", "identify", "--tuning", "just", "--kbm", "white.kbm", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --kbm requires a .scl file in --tuning.",
+		},
+		{
+			name:             "Tuning Kbm With Upper-Case Scala File",
+			args:             []string{"cordelia", "identify", "--tuning", "missing.SCL", "--kbm", "white.kbm", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: File not found: missing.SCL.",
+		},
+		{
+			name:             "Borrowed Chords In German Notation",
+			args:             []string{"cordelia", "keys", "--notation", "german", "C", "As", "B", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Chromatic Chords (C Major):\n[2] As: bVI, borrowed from C Minor\n[3] B: bVII, borrowed from C Minor",
+		},
+		{
+			name:             "Nashville Numbers In The Cadence Key",
+			args:             []string{"cordelia", "keys", "--nashville", "Dm", "G7", "C", "Am"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Cadences (C Major):\n[2-3] Perfect Authentic: G7 -> C (V7 -> I)\n\nNashville Numbers (C Major): 2m 5(7) 1 6m",
+		},
+		{
+			name:             "Nashville Numbers With A Numeric Suffix",
+			args:             []string{"cordelia", "keys", "--nashville", "E", "B7", "E"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Nashville Numbers (E Major): 1 5(7) 1",
+		},
+		{
+			name:             "Nashville Numbers For A Two-Chord Progression",
+			args:             []string{"cordelia", "keys", "--nashville", "D", "G"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Cadences (G Major):\n[1-2] Perfect Authentic: D -> G (V -> I)\n\nNashville Numbers (G Major): 5 1",
+		},
+		{
+			name:             "Export Tempo Out Of Range",
+			args:             []string{"cordelia", "keys", "--midi-out", "out.mid", "--tempo", "1", "C", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --tempo must be between 20 and 400.",
+		},
+		{
+			name:             "Export Beats Per Chord Out Of Range",
+			args:             []string{"cordelia", "keys", "--wav-out", "out.wav", "--beats-per-chord", "1e9", "C", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --beats-per-chord must be greater than zero and at most 64.",
+		},
+		{
+			name:             "Chord Scales From Notes",
+			args:             []string{"cordelia", "chordscales", "--notes", "C,E,F#,G,Bb"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "C7#11 (C Dominant 7th Sharp 11):\n C Lydian Dominant: C D E F# G A Bb; for 7#11",
+		},
+		{
+			name:             "Chord Scales For Tension Chords",
+			args:             []string{"cordelia", "chordscales", "--key", "F", "C7#11", "G7alt"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "C7#11 (C Dominant 7th Sharp 11):\n C Lydian Dominant: C D E F# G A Bb; for 7#11; 1 note outside the key",
+		},
+		{
+			name:             "Chord Scales For Altered Chord",
+			args:             []string{"cordelia", "chordscales", "G7alt"},
+			expectedExitCode: 0,
+			expectedStdout:   "G7alt (G Altered Dominant):\n G Altered: G Ab Bb Cb Db Eb F; for 7alt",
+		},
+		{
+			name:             "Scales From Notes",
+			args:             []string{"cordelia", "scales", "--limit", "2", "A", "C", "D", "E", "G"},
+			expectedExitCode: 0,
+			expectedStdout:   "Input Notes: A C D E G\nScales:\n A Minor Pentatonic: A C D E G (exact)\n C Major Pentatonic: C D E G A (exact)\n ... 49 more (--limit 0 shows all)",
+		},
+		{
+			name:             "Substitutes For Dominant",
+			args:             []string{"cordelia", "substitutes", "G7"},
+			expectedExitCode: 0,
+			expectedStdout:   "[1] G7 (G Dominant 7th):\n Tritone Substitution: Db7 (Db F Ab Cb)\n ii-V Expansion: Dm7 G7 (D F A C | G B D F)",
+		},
+		{
+			name:             "Predict Without Model",
+			args:             []string{"cordelia", "predict", "--model", "missing-model.json", "C", "F"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: File not found: missing-model.json",
+		},
+		{
+			name:             "Keys With Cadences",
+			args:             []string{"cordelia", "keys", "C", "Dm", "G7", "Am", "F", "G", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Cadences (C Major):\n[3-4] Deceptive: G7 -> Am (V7 -> vi)\n[6-7] Perfect Authentic: G -> C (V -> I)",
+		},
+		{
+			name:             "Cadence Key For Progression Ending On vi",
+			args:             []string{"cordelia", "--keys", "Dm", "G7", "C", "Am"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Cadences (C Major):\n[2-3] Perfect Authentic: G7 -> C (V7 -> I)",
+		},
+		{
+			name:             "Keys With Chromatic Chords",
+			args:             []string{"cordelia", "--keys", "C", "E7", "Am", "D7", "G7", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Chromatic Chords (C Major):\n[2] E7: V7/vi, secondary dominant\n[4] D7: V7/V, secondary dominant",
+		},
+		{
+			name:             "Borrowed Chords In Major",
+			args:             []string{"cordelia", "--keys", "C", "Ab", "Bb", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Chromatic Chords (C Major):\n[2] Ab: bVI, borrowed from C Minor\n[3] Bb: bVII, borrowed from C Minor",
+		},
+		{
+			name:             "Voice Leading Between Chords",
+			args:             []string{"cordelia", "keys", "--voice-leading", "C", "F"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Voice Leading:\n[1 -> 2] C -> F\n Common Tones: C\n Motion: C4 -> C4 (0), E4 -> F4 (+1), G4 -> A4 (+2)\n Distance: 3 semitones\n Voicing: C4 F4 A4",
+		},
+		{
+			name:             "Voice Leading Without Keys Or Batch",
+			args:             []string{"cordelia", "--voice-leading", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --voice-leading requires --keys or --batch.",
+		},
+		{
+			name:             "CSV Format Without Batch",
+			args:             []string{"cordelia", "--format", "csv", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --format csv requires --batch.",
+		},
+		{
+			name:             "Export Without Keys Or Batch",
+			args:             []string{"cordelia", "--musicxml-out", "chords.musicxml", "C", "E", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: --musicxml-out requires --keys or --batch.",
+		},
+		{
+			name:             "Export Unknown Voicing",
+			args:             []string{"cordelia", "keys", "--midi-out", "chords.mid", "--voicing", "spread", "C", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: unknown voicing 'spread' (expected close, open or drop2).",
+		},
+		{
+			name:             "Export Unknown Arpeggio",
+			args:             []string{"cordelia", "--keys", "--wav-out", "chords.wav", "--arpeggio", "sideways", "C", "G"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: unknown arpeggio 'sideways' (expected off, up, down or updown).",
+		},
+		{
+			name:             "Identify Subcommand",
+			args:             []string{"cordelia", "identify", "--inversions", "E", "G", "C"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "Root: C\nIntervals: [0 4 7]\nMatched Chords:\n - C Major Triad",
+		},
+		{
+			name:             "Keys Subcommand From Notes",
+			args:             []string{"cordelia", "keys", "--notes", "C,D,E,F,G,A,B"},
+			expectedExitCode: 0,
+			stdoutContains:   true,
+			expectedStdout:   "C Major (7 matches)",
+		},
+		{
+			name:             "Spell Subcommand",
+			args:             []string{"cordelia", "spell", "Am7", "G7"},
+			expectedExitCode: 0,
+			expectedStdout:   "Am7: A C E G\nG7: G B D F",
+		},
+		{
+			name:             "Parse Subcommand Unknown Quality",
+			args:             []string{"cordelia", "parse", "Cmaj9"},
+			expectedExitCode: 1,
+			expectedStderr:   "Error: Could not parse chord name 'Cmaj9': unknown chord quality: 'maj9'",
+		},
+		{
+			name:             "Transpose Subcommand",
+			args:             []string{"cordelia", "transpose", "--by", "2", "Bb", "Am7", "F#"},
+			expectedExitCode: 0,
+			expectedStdout:   "C Bm7 G#",
+		},
+		{
+			name:             "Transpose Slash Chord Bass With The Root",
+			args:             []string{"cordelia", "transpose", "--by", "1", "Bb/D", "Db/F"},
+			expectedExitCode: 0,
+			expectedStdout:   "B/D# D/F#",
+		},
+		{
+			name:             "Help For Subcommand",
+			args:             []string{"cordelia", "help", "spell"},
+			expectedExitCode: 0,
+			stderrContains:   true,
+			expectedStderr:   "Usage of cordelia spell:",
+		},
 	}
 
 	for _, tt := range tests {
@@ -101,7 +365,7 @@ func TestCLI(t *testing.T) {
 			exit = capture.Exit
 			os.Args = tt.args
 			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
-			notesFlag, inversionsFlag, batchFlag, keysFlag, verboseFlag, helpFlag = "", false, "", false, false, false
+			notesFlag, inversionsFlag, batchFlag, keysFlag, verboseFlag, formatFlag, helpFlag = "", false, "", false, false, formatText, false
 			exitCode = 0
 
 			main()
@@ -137,69 +401,3 @@ func TestCLI(t *testing.T) {
 		})
 	}
 }
-
-// =====================================================================================
-// SECTION: Unit Tests
-// =====================================================================================
-
-func TestParseChordName(t *testing.T) {
-	t.Parallel()
-	tests := []struct {
-		input         string
-		expectedRoot  string
-		expectedChord string
-		expectError   bool
-	}{
-		{"C", "C", "Major Triad", false},
-		{"Am", "A", "Minor Triad", false},
-		{"F#m7", "F#", "Minor 7th", false},
-		{"Bb7", "Bb", "Dominant 7th", false},
-		{"Gaug", "G", "Augmented Triad", false},
-		{"H", "", "", true},
-		{"Cmaj9", "", "", true}, // maj9 is not in our dictionary
-	}
-
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.input, func(t *testing.T) {
-			t.Parallel()
-			root, chord, err := ParseChordName(tt.input)
-			if tt.expectError {
-				if err == nil {
-					t.Errorf("Expected an error, but got none")
-				}
-				return
-			}
-			if err != nil {
-				t.Fatalf("Got unexpected error: %v", err)
-			}
-			if root.Original != tt.expectedRoot {
-				t.Errorf("Expected root %s, got %s", tt.expectedRoot, root.Original)
-			}
-			if chord.Name != tt.expectedChord {
-				t.Errorf("Expected chord name %s, got %s", tt.expectedChord, chord.Name)
-			}
-		})
-	}
-}
-
-func TestGenerateNotes(t *testing.T) {
-	t.Parallel()
-	root := Note{Original: "A", Value: 9}
-	intervals := []int{0, 3, 7} // Minor Triad
-	expectedNotes := []Note{
-		{Original: "A", Value: 9},
-		{Original: "C", Value: 0},
-		{Original: "E", Value: 4},
-	}
-
-	got := GenerateNotes(root, intervals)
-	// Sort for comparison
-	sort.Slice(got, func(i, j int) bool { return got[i].Value < got[j].Value })
-	sort.Slice(expectedNotes, func(i, j int) bool { return expectedNotes[i].Value < expectedNotes[j].Value })
-
-	if !reflect.DeepEqual(got, expectedNotes) {
-		t.Errorf("GenerateNotes() got = %v, want %v", got, expectedNotes)
-	}
-}
-
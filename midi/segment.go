@@ -0,0 +1,180 @@
+package midi
+
+import "sort"
+
+// SegmentMode selects how the timeline is cut into chord segments.
+type SegmentMode int
+
+const (
+	// SegmentByOnset starts a new segment whenever a note begins.
+	SegmentByOnset SegmentMode = iota
+	// SegmentByBeat cuts the timeline into equal slices of one or more beats.
+	SegmentByBeat
+)
+
+// SegmentOptions configures Segments.
+type SegmentOptions struct {
+	Mode SegmentMode
+	// Beats is the length of each segment in quarter notes for SegmentByBeat.
+	// Zero means one beat.
+	Beats float64
+	// Channels restricts analysis to these zero-based channels. Empty means all.
+	Channels []int
+	// IncludeDrums keeps notes on the percussion channel, which are skipped by default.
+	IncludeDrums bool
+}
+
+// Segment is a span of time and the MIDI keys sounding during it, lowest first.
+type Segment struct {
+	Start int64
+	End   int64
+	Keys  []int
+}
+
+// Segments groups the file's notes into chord segments. Consecutive segments
+// with the same keys are merged.
+func (f *File) Segments(opts SegmentOptions) []Segment {
+	notes := f.filterNotes(opts)
+	if len(notes) == 0 {
+		return nil
+	}
+
+	var segments []Segment
+	switch opts.Mode {
+	case SegmentByBeat:
+		segments = segmentByBeat(notes, f.beatTicks(opts.Beats))
+	default:
+		segments = segmentByOnset(notes)
+	}
+	return mergeSegments(segments)
+}
+
+func (f *File) filterNotes(opts SegmentOptions) []Note {
+	allowed := make(map[int]bool)
+	for _, ch := range opts.Channels {
+		allowed[ch] = true
+	}
+	var notes []Note
+	for _, n := range f.Notes {
+		if n.Channel == DrumChannel && !opts.IncludeDrums {
+			continue
+		}
+		if len(allowed) > 0 && !allowed[n.Channel] {
+			continue
+		}
+		if n.End <= n.Start {
+			continue
+		}
+		notes = append(notes, n)
+	}
+	return notes
+}
+
+func (f *File) beatTicks(beats float64) int64 {
+	if beats <= 0 {
+		beats = 1
+	}
+	ticks := int64(beats * float64(f.Division))
+	if ticks < 1 {
+		ticks = 1
+	}
+	return ticks
+}
+
+func segmentByOnset(notes []Note) []Segment {
+	var onsets []int64
+	seen := make(map[int64]bool)
+	for _, n := range notes {
+		if !seen[n.Start] {
+			seen[n.Start] = true
+			onsets = append(onsets, n.Start)
+		}
+	}
+	sort.Slice(onsets, func(i, j int) bool { return onsets[i] < onsets[j] })
+
+	var segments []Segment
+	for i, start := range onsets {
+		end := int64(-1)
+		if i+1 < len(onsets) {
+			end = onsets[i+1]
+		}
+		keys, lastEnd := soundingKeys(notes, start, end)
+		if len(keys) == 0 {
+			continue
+		}
+		// A segment ends at the next onset, or earlier if everything has stopped.
+		if end < 0 || lastEnd < end {
+			end = lastEnd
+		}
+		segments = append(segments, Segment{Start: start, End: end, Keys: keys})
+	}
+	return segments
+}
+
+func segmentByBeat(notes []Note, beatTicks int64) []Segment {
+	last := int64(0)
+	for _, n := range notes {
+		if n.End > last {
+			last = n.End
+		}
+	}
+
+	var segments []Segment
+	for start := int64(0); start < last; start += beatTicks {
+		keys, _ := soundingKeys(notes, start, start+beatTicks)
+		if len(keys) == 0 {
+			continue
+		}
+		segments = append(segments, Segment{Start: start, End: start + beatTicks, Keys: keys})
+	}
+	return segments
+}
+
+// soundingKeys returns the sorted unique keys of notes overlapping [start, end),
+// where end < 0 means open-ended, and the latest end among those notes.
+func soundingKeys(notes []Note, start, end int64) ([]int, int64) {
+	seen := make(map[int]bool)
+	var keys []int
+	lastEnd := start
+	for _, n := range notes {
+		if n.End <= start || (end >= 0 && n.Start >= end) {
+			continue
+		}
+		if !seen[n.Key] {
+			seen[n.Key] = true
+			keys = append(keys, n.Key)
+		}
+		if n.End > lastEnd {
+			lastEnd = n.End
+		}
+	}
+	sort.Ints(keys)
+	return keys, lastEnd
+}
+
+func mergeSegments(segments []Segment) []Segment {
+	var merged []Segment
+	for _, s := range segments {
+		if len(merged) > 0 {
+			prev := &merged[len(merged)-1]
+			if prev.End == s.Start && equalKeys(prev.Keys, s.Keys) {
+				prev.End = s.End
+				continue
+			}
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func equalKeys(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
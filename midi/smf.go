@@ -0,0 +1,336 @@
+// Package midi reads and writes Standard MIDI Files (SMF) and groups their
+// notes into chord segments for analysis.
+package midi
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DrumChannel is the zero-based channel reserved for percussion (channel 10).
+const DrumChannel = 9
+
+// Note is a sounding note, with start and end measured in ticks.
+type Note struct {
+	Start    int64
+	End      int64
+	Key      int // MIDI note number, 60 = middle C
+	Channel  int // zero-based
+	Velocity int
+}
+
+// TempoChange sets the tempo from Tick onwards.
+type TempoChange struct {
+	Tick             int64
+	MicrosPerQuarter int
+}
+
+// File is the content of a Standard MIDI File relevant to harmonic analysis.
+type File struct {
+	Format int
+	// Division is the number of ticks per quarter note, or for SMPTE timing,
+	// the number of ticks per second.
+	Division int
+	SMPTE    bool
+	Tempos   []TempoChange
+	Notes    []Note
+}
+
+// defaultMicrosPerQuarter is 120 BPM, the SMF default before any tempo event.
+const defaultMicrosPerQuarter = 500000
+
+// ReadFile parses an SMF of format 0 or 1.
+func ReadFile(r io.Reader) (*File, error) {
+	br := bufio.NewReader(r)
+	id, data, err := readChunk(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if id != "MThd" || len(data) < 6 {
+		return nil, errors.New("not a Standard MIDI File")
+	}
+
+	f := &File{Format: int(binary.BigEndian.Uint16(data[0:2]))}
+	trackCount := int(binary.BigEndian.Uint16(data[2:4]))
+	division := binary.BigEndian.Uint16(data[4:6])
+	if f.Format > 1 {
+		return nil, fmt.Errorf("unsupported SMF format %d", f.Format)
+	}
+	if division&0x8000 != 0 {
+		fps := -int(int8(division >> 8))
+		f.SMPTE = true
+		f.Division = fps * int(division&0xFF)
+	} else {
+		f.Division = int(division)
+	}
+	if f.Division <= 0 {
+		return nil, errors.New("invalid time division")
+	}
+
+	var events []event
+	for track := 0; track < trackCount; {
+		id, data, err := readChunk(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading track %d: %w", track+1, err)
+		}
+		if id != "MTrk" {
+			continue // Unknown chunks are skipped, as the SMF spec requires.
+		}
+		trackEvents, err := parseTrack(data)
+		if err != nil {
+			return nil, fmt.Errorf("track %d: %w", track+1, err)
+		}
+		events = append(events, trackEvents...)
+		track++
+	}
+
+	f.Tempos, f.Notes = collectNotes(events)
+	return f, nil
+}
+
+// Seconds converts an absolute tick to seconds using the tempo map.
+func (f *File) Seconds(tick int64) float64 {
+	if f.SMPTE {
+		return float64(tick) / float64(f.Division)
+	}
+	seconds := 0.0
+	lastTick := int64(0)
+	micros := defaultMicrosPerQuarter
+	for _, t := range f.Tempos {
+		if t.Tick >= tick {
+			break
+		}
+		seconds += float64(t.Tick-lastTick) * float64(micros) / 1e6 / float64(f.Division)
+		lastTick, micros = t.Tick, t.MicrosPerQuarter
+	}
+	return seconds + float64(tick-lastTick)*float64(micros)/1e6/float64(f.Division)
+}
+
+func readChunk(r io.Reader) (string, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", nil, fmt.Errorf("truncated %s chunk", header[:4])
+	}
+	return string(header[:4]), data, nil
+}
+
+// --- Track Parsing ---
+
+type eventKind int
+
+// The order of these kinds breaks ties between events on the same tick, except
+// that note-ons rank with note-offs (see collectNotes).
+const (
+	evNoteOff eventKind = iota
+	evSustain
+	evTempo
+	evNoteOn
+	evEnd
+)
+
+type event struct {
+	tick    int64
+	kind    eventKind
+	channel int
+	key     int
+	value   int // velocity, pedal value or tempo
+}
+
+func parseTrack(data []byte) ([]event, error) {
+	var events []event
+	pos := 0
+	tick := int64(0)
+	runningStatus := byte(0)
+
+	for pos < len(data) {
+		delta, n, err := readVarLen(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+		tick += int64(delta)
+		if pos >= len(data) {
+			return nil, errors.New("truncated event")
+		}
+
+		status := data[pos]
+		if status < 0x80 {
+			if runningStatus == 0 {
+				return nil, errors.New("data byte without running status")
+			}
+			status = runningStatus
+		} else {
+			pos++
+		}
+
+		switch {
+		case status == 0xFF:
+			if pos >= len(data) {
+				return nil, errors.New("truncated meta event")
+			}
+			metaType := data[pos]
+			length, n, err := readVarLen(data[pos+1:])
+			if err != nil {
+				return nil, err
+			}
+			start := pos + 1 + n
+			end := start + int(length)
+			if end > len(data) {
+				return nil, errors.New("truncated meta event")
+			}
+			switch metaType {
+			case 0x51:
+				if length == 3 {
+					micros := int(data[start])<<16 | int(data[start+1])<<8 | int(data[start+2])
+					events = append(events, event{tick: tick, kind: evTempo, value: micros})
+				}
+			case 0x2F:
+				events = append(events, event{tick: tick, kind: evEnd})
+			}
+			pos = end
+			runningStatus = 0
+		case status == 0xF0 || status == 0xF7:
+			length, n, err := readVarLen(data[pos:])
+			if err != nil {
+				return nil, err
+			}
+			pos += n + int(length)
+			runningStatus = 0
+		case status >= 0x80 && status < 0xF0:
+			runningStatus = status
+			size := 2
+			if status&0xF0 == 0xC0 || status&0xF0 == 0xD0 {
+				size = 1
+			}
+			if pos+size > len(data) {
+				return nil, errors.New("truncated channel event")
+			}
+			channel := int(status & 0x0F)
+			d1, d2 := int(data[pos]), 0
+			if size == 2 {
+				d2 = int(data[pos+1])
+			}
+			pos += size
+
+			switch status & 0xF0 {
+			case 0x90:
+				if d2 > 0 {
+					events = append(events, event{tick: tick, kind: evNoteOn, channel: channel, key: d1, value: d2})
+				} else {
+					events = append(events, event{tick: tick, kind: evNoteOff, channel: channel, key: d1})
+				}
+			case 0x80:
+				events = append(events, event{tick: tick, kind: evNoteOff, channel: channel, key: d1})
+			case 0xB0:
+				if d1 == 64 {
+					events = append(events, event{tick: tick, kind: evSustain, channel: channel, value: d2})
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unsupported status byte 0x%X", status)
+		}
+	}
+	return events, nil
+}
+
+func readVarLen(data []byte) (uint32, int, error) {
+	var value uint32
+	for i := 0; i < 4 && i < len(data); i++ {
+		value = value<<7 | uint32(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, errors.New("invalid variable-length quantity")
+}
+
+// collectNotes pairs note-on and note-off events across all tracks, extending
+// notes released while the sustain pedal is down until the pedal is lifted.
+// Note-ons and note-offs at the same tick keep their file order, and a
+// note-off ends the oldest sounding note of its key, so a re-struck note
+// releases the older one while a zero-length note ends where it starts.
+func collectNotes(events []event) ([]TempoChange, []Note) {
+	rank := func(k eventKind) eventKind {
+		if k == evNoteOn {
+			return evNoteOff
+		}
+		return k
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].tick != events[j].tick {
+			return events[i].tick < events[j].tick
+		}
+		return rank(events[i].kind) < rank(events[j].kind)
+	})
+
+	type voice struct{ channel, key int }
+	active := make(map[voice][]int) // indexes into notes, oldest first
+	sustained := make(map[int][]int)
+	pedalDown := make(map[int]bool)
+	var notes []Note
+	var tempos []TempoChange
+	lastTick := int64(0)
+
+	release := func(idx int, tick int64) {
+		notes[idx].End = tick
+	}
+
+	for _, ev := range events {
+		lastTick = ev.tick
+		switch ev.kind {
+		case evTempo:
+			tempos = append(tempos, TempoChange{Tick: ev.tick, MicrosPerQuarter: ev.value})
+		case evNoteOn:
+			// A re-struck note cuts off any sustained copy of itself.
+			for i, idx := range sustained[ev.channel] {
+				if notes[idx].Key == ev.key {
+					release(idx, ev.tick)
+					sustained[ev.channel] = append(sustained[ev.channel][:i], sustained[ev.channel][i+1:]...)
+					break
+				}
+			}
+			v := voice{ev.channel, ev.key}
+			active[v] = append(active[v], len(notes))
+			notes = append(notes, Note{Start: ev.tick, End: -1, Key: ev.key, Channel: ev.channel, Velocity: ev.value})
+		case evNoteOff:
+			v := voice{ev.channel, ev.key}
+			if len(active[v]) == 0 {
+				continue
+			}
+			idx := active[v][0]
+			active[v] = active[v][1:]
+			if pedalDown[ev.channel] {
+				sustained[ev.channel] = append(sustained[ev.channel], idx)
+			} else {
+				release(idx, ev.tick)
+			}
+		case evSustain:
+			down := ev.value >= 64
+			if pedalDown[ev.channel] && !down {
+				for _, idx := range sustained[ev.channel] {
+					release(idx, ev.tick)
+				}
+				sustained[ev.channel] = nil
+			}
+			pedalDown[ev.channel] = down
+		}
+	}
+
+	// Notes still sounding at the end of the file stop at the last event.
+	for i := range notes {
+		if notes[i].End < 0 {
+			notes[i].End = lastTick
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Start < notes[j].Start })
+	return tempos, notes
+}
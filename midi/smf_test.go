@@ -0,0 +1,169 @@
+package midi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildSMF assembles a format 1 file with a division of 480 ticks per quarter.
+func buildSMF(tracks ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("MThd")
+	binary.Write(&buf, binary.BigEndian, uint32(6))
+	binary.Write(&buf, binary.BigEndian, []uint16{1, uint16(len(tracks)), 480})
+	for _, t := range tracks {
+		buf.WriteString("MTrk")
+		binary.Write(&buf, binary.BigEndian, uint32(len(t)))
+		buf.Write(t)
+	}
+	return buf.Bytes()
+}
+
+// testSMF holds a C major triad for one beat at 60 BPM, then an F major triad
+// released early but held by the sustain pedal, plus a drum hit.
+var testSMF = buildSMF(
+	[]byte{
+		0x00, 0xFF, 0x51, 0x03, 0x0F, 0x42, 0x40, // 60 BPM
+		0x00, 0xFF, 0x2F, 0x00,
+	},
+	[]byte{
+		0x00, 0x90, 0x3C, 0x64, // C4 on
+		0x00, 0x40, 0x64, // E4 on (running status)
+		0x00, 0x43, 0x64, // G4 on
+		0x83, 0x60, 0x80, 0x3C, 0x00, // +480: C4 off
+		0x00, 0x40, 0x00, // E4 off
+		0x00, 0x43, 0x00, // G4 off
+		0x00, 0xB0, 0x40, 0x7F, // sustain down
+		0x00, 0x90, 0x41, 0x64, // F4 on
+		0x00, 0x45, 0x64, // A4 on
+		0x00, 0x48, 0x64, // C5 on
+		0x00, 0x99, 0x24, 0x64, // kick drum on
+		0x81, 0x70, 0x80, 0x41, 0x00, // +240: F4 off (sustained)
+		0x00, 0x45, 0x00, // A4 off
+		0x00, 0x48, 0x00, // C5 off
+		0x00, 0x89, 0x24, 0x00, // kick drum off
+		0x81, 0x70, 0xB0, 0x40, 0x00, // +240: sustain up
+		0x00, 0xFF, 0x2F, 0x00,
+	},
+)
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+	f, err := ReadFile(bytes.NewReader(testSMF))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	if f.Format != 1 || f.Division != 480 {
+		t.Errorf("Expected format 1 with division 480, got %d and %d", f.Format, f.Division)
+	}
+	if len(f.Notes) != 7 {
+		t.Fatalf("Expected 7 notes, got %d: %+v", len(f.Notes), f.Notes)
+	}
+	for _, n := range f.Notes {
+		switch {
+		case n.Channel == DrumChannel:
+			if n.End != 720 {
+				t.Errorf("Expected drum note to end at 720, got %d", n.End)
+			}
+		case n.Start == 480 && n.End != 960:
+			t.Errorf("Expected sustained note %d to end at 960, got %d", n.Key, n.End)
+		case n.Start == 0 && n.End != 480:
+			t.Errorf("Expected note %d to end at 480, got %d", n.Key, n.End)
+		}
+	}
+	if got := f.Seconds(480); got != 1.0 {
+		t.Errorf("Expected tick 480 at 60 BPM to be 1s, got %v", got)
+	}
+}
+
+func TestReadFileSameTickNotes(t *testing.T) {
+	t.Parallel()
+	data := buildSMF([]byte{
+		0x00, 0x90, 0x3C, 0x64, // C4 on
+		0x00, 0x80, 0x3C, 0x00, // C4 off at the same tick: zero length
+		0x00, 0x90, 0x40, 0x64, // E4 on
+		0x83, 0x60, 0x90, 0x40, 0x64, // +480: E4 struck again
+		0x00, 0x80, 0x40, 0x00, // E4 off, releasing the older E4
+		0x83, 0x60, 0x80, 0x40, 0x00, // +480: E4 off
+		0x00, 0xFF, 0x2F, 0x00,
+	})
+	f, err := ReadFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	want := []Note{
+		{Start: 0, End: 0, Key: 60, Velocity: 100},
+		{Start: 0, End: 480, Key: 64, Velocity: 100},
+		{Start: 480, End: 960, Key: 64, Velocity: 100},
+	}
+	if !reflect.DeepEqual(f.Notes, want) {
+		t.Errorf("Notes = %+v, want %+v", f.Notes, want)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	t.Parallel()
+	f, err := ReadFile(bytes.NewReader(testSMF))
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		opts     SegmentOptions
+		expected []Segment
+	}{
+		{
+			name: "By Onset",
+			opts: SegmentOptions{Mode: SegmentByOnset},
+			expected: []Segment{
+				{Start: 0, End: 480, Keys: []int{60, 64, 67}},
+				{Start: 480, End: 960, Keys: []int{65, 69, 72}},
+			},
+		},
+		{
+			name: "By Half Beat Merges Repeats",
+			opts: SegmentOptions{Mode: SegmentByBeat, Beats: 0.5},
+			expected: []Segment{
+				{Start: 0, End: 480, Keys: []int{60, 64, 67}},
+				{Start: 480, End: 960, Keys: []int{65, 69, 72}},
+			},
+		},
+		{
+			name: "With Drums",
+			opts: SegmentOptions{Mode: SegmentByOnset, IncludeDrums: true},
+			expected: []Segment{
+				{Start: 0, End: 480, Keys: []int{60, 64, 67}},
+				{Start: 480, End: 960, Keys: []int{36, 65, 69, 72}},
+			},
+		},
+		{
+			name:     "Channel Filter",
+			opts:     SegmentOptions{Channels: []int{DrumChannel}, IncludeDrums: true},
+			expected: []Segment{{Start: 480, End: 720, Keys: []int{36}}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := f.Segments(tt.opts); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Segments() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReadFileRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+	if _, err := ReadFile(bytes.NewReader([]byte("RIFF0000WAVE"))); err == nil {
+		t.Errorf("Expected an error for non-MIDI input")
+	}
+	truncated := testSMF[:len(testSMF)-5]
+	if _, err := ReadFile(bytes.NewReader(truncated)); err == nil {
+		t.Errorf("Expected an error for a truncated track")
+	}
+}
@@ -0,0 +1,221 @@
+package midi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Voicing selects how a chord's pitch classes are spread across octaves.
+type Voicing int
+
+const (
+	// VoicingClose stacks every note as closely as possible above the root.
+	VoicingClose Voicing = iota
+	// VoicingOpen puts the root an octave below a close voicing of the rest.
+	VoicingOpen
+	// VoicingDrop2 lowers the second-highest note of a close voicing by an octave.
+	VoicingDrop2
+)
+
+// ParseVoicing converts "close", "open" or "drop2" into a Voicing.
+func ParseVoicing(s string) (Voicing, error) {
+	switch s {
+	case "close":
+		return VoicingClose, nil
+	case "open":
+		return VoicingOpen, nil
+	case "drop2":
+		return VoicingDrop2, nil
+	}
+	return 0, fmt.Errorf("unknown voicing '%s' (expected close, open or drop2)", s)
+}
+
+// Voice turns pitch classes, root first, into MIDI keys with the root in the
+// given octave (octave 4 puts C on key 60).
+func Voice(pitchClasses []int, octave int, v Voicing) []int {
+	if len(pitchClasses) == 0 {
+		return nil
+	}
+	keys := make([]int, len(pitchClasses))
+	keys[0] = 12*(octave+1) + pitchClasses[0]
+	for i := 1; i < len(pitchClasses); i++ {
+		key := keys[i-1] - keys[i-1]%12 + pitchClasses[i]
+		for key <= keys[i-1] {
+			key += 12
+		}
+		keys[i] = key
+	}
+
+	switch v {
+	case VoicingOpen:
+		if len(keys) > 1 {
+			keys[0] -= 12
+		}
+	case VoicingDrop2:
+		if len(keys) > 2 {
+			keys[len(keys)-2] -= 12
+		}
+	}
+	sort.Ints(keys)
+	for i := range keys {
+		for keys[i] < 0 {
+			keys[i] += 12
+		}
+		for keys[i] > 127 {
+			keys[i] -= 12
+		}
+	}
+	return keys
+}
+
+// SymbolEvent selects the meta-event used to label each chord.
+type SymbolEvent int
+
+const (
+	// SymbolMarker writes chord symbols as marker events (FF 06).
+	SymbolMarker SymbolEvent = iota
+	// SymbolLyric writes chord symbols as lyric events (FF 05).
+	SymbolLyric
+	// SymbolNone omits chord symbols.
+	SymbolNone
+)
+
+// ParseSymbolEvent converts "marker", "lyric" or "none" into a SymbolEvent.
+func ParseSymbolEvent(s string) (SymbolEvent, error) {
+	switch s {
+	case "marker":
+		return SymbolMarker, nil
+	case "lyric":
+		return SymbolLyric, nil
+	case "none":
+		return SymbolNone, nil
+	}
+	return 0, fmt.Errorf("unknown symbol event '%s' (expected marker, lyric or none)", s)
+}
+
+// ChordEvent is one chord of a progression to be written.
+type ChordEvent struct {
+	Symbol string
+	Keys   []int
+}
+
+// WriteOptions configures WriteProgression. Zero values select the defaults
+// noted on each field.
+type WriteOptions struct {
+	BPM           float64 // default 120
+	BeatsPerChord float64 // default 4
+	Division      int     // ticks per quarter note, default 480
+	Channel       int     // zero-based, default 0
+	Velocity      int     // default 80
+	Symbols       SymbolEvent
+}
+
+func (o WriteOptions) withDefaults() WriteOptions {
+	if o.BPM <= 0 {
+		o.BPM = 120
+	}
+	if o.BeatsPerChord <= 0 {
+		o.BeatsPerChord = 4
+	}
+	if o.Division <= 0 {
+		o.Division = 480
+	}
+	if o.Velocity <= 0 || o.Velocity > 127 {
+		o.Velocity = 80
+	}
+	return o
+}
+
+// WriteProgression writes the chords one after another as a format 0 SMF.
+func WriteProgression(w io.Writer, chords []ChordEvent, opts WriteOptions) error {
+	opts = opts.withDefaults()
+	if opts.Channel < 0 || opts.Channel > 15 {
+		return fmt.Errorf("invalid MIDI channel %d", opts.Channel+1)
+	}
+	if opts.Division > 0x7FFF {
+		return errors.New("division too large")
+	}
+
+	// The tempo meta event holds microseconds per quarter note in 3 bytes.
+	if micros := 60e6 / opts.BPM; !(micros >= 1 && micros <= 0xFFFFFF) {
+		return fmt.Errorf("tempo %g BPM out of range", opts.BPM)
+	}
+	micros := int(60e6/opts.BPM + 0.5)
+
+	var track trackWriter
+	track.meta(0, 0x51, []byte{byte(micros >> 16), byte(micros >> 8), byte(micros)})
+	track.meta(0, 0x58, []byte{4, 2, 24, 8}) // 4/4
+
+	chordTicks := uint32(opts.BeatsPerChord*float64(opts.Division) + 0.5)
+	noteOn := byte(0x90 | opts.Channel)
+	noteOff := byte(0x80 | opts.Channel)
+	for _, c := range chords {
+		switch opts.Symbols {
+		case SymbolMarker:
+			track.meta(0, 0x06, []byte(c.Symbol))
+		case SymbolLyric:
+			track.meta(0, 0x05, []byte(c.Symbol))
+		}
+		for _, k := range c.Keys {
+			track.event(0, noteOn, byte(k), byte(opts.Velocity))
+		}
+		delta := chordTicks
+		for _, k := range c.Keys {
+			track.event(delta, noteOff, byte(k), 0)
+			delta = 0
+		}
+		if len(c.Keys) == 0 {
+			track.pending += chordTicks
+		}
+	}
+	track.meta(0, 0x2F, nil)
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("MThd")
+	binary.Write(bw, binary.BigEndian, uint32(6))
+	binary.Write(bw, binary.BigEndian, []uint16{0, 1, uint16(opts.Division)})
+	bw.WriteString("MTrk")
+	binary.Write(bw, binary.BigEndian, uint32(track.buf.Len()))
+	bw.Write(track.buf.Bytes())
+	return bw.Flush()
+}
+
+// trackWriter accumulates the events of one track. Rests are carried in
+// pending and added to the next event's delta time.
+type trackWriter struct {
+	buf     bytes.Buffer
+	pending uint32
+}
+
+func (t *trackWriter) delta(d uint32) {
+	writeVarLen(&t.buf, t.pending+d)
+	t.pending = 0
+}
+
+func (t *trackWriter) event(delta uint32, status, d1, d2 byte) {
+	t.delta(delta)
+	t.buf.Write([]byte{status, d1, d2})
+}
+
+func (t *trackWriter) meta(delta uint32, metaType byte, data []byte) {
+	t.delta(delta)
+	t.buf.Write([]byte{0xFF, metaType})
+	writeVarLen(&t.buf, uint32(len(data)))
+	t.buf.Write(data)
+}
+
+func writeVarLen(buf *bytes.Buffer, value uint32) {
+	var tmp [4]byte
+	i := len(tmp) - 1
+	tmp[i] = byte(value & 0x7F)
+	for value >>= 7; value > 0; value >>= 7 {
+		i--
+		tmp[i] = byte(value&0x7F) | 0x80
+	}
+	buf.Write(tmp[i:])
+}
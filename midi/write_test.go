@@ -0,0 +1,88 @@
+package midi
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVoice(t *testing.T) {
+	t.Parallel()
+	am7 := []int{9, 0, 4, 7} // A C E G
+	tests := []struct {
+		name     string
+		voicing  Voicing
+		octave   int
+		expected []int
+	}{
+		{"Close", VoicingClose, 4, []int{69, 72, 76, 79}},
+		{"Open", VoicingOpen, 4, []int{57, 72, 76, 79}},
+		{"Drop 2", VoicingDrop2, 4, []int{64, 69, 72, 79}},
+		{"Low Octave", VoicingClose, 2, []int{45, 48, 52, 55}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Voice(am7, tt.octave, tt.voicing); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Voice() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteProgressionRoundTrip(t *testing.T) {
+	t.Parallel()
+	chords := []ChordEvent{
+		{Symbol: "C", Keys: []int{60, 64, 67}},
+		{Symbol: "F", Keys: []int{65, 69, 72}},
+		{Symbol: "G7", Keys: []int{67, 71, 74, 77}},
+	}
+	var buf bytes.Buffer
+	if err := WriteProgression(&buf, chords, WriteOptions{BPM: 60, BeatsPerChord: 2}); err != nil {
+		t.Fatalf("WriteProgression() returned error: %v", err)
+	}
+
+	f, err := ReadFile(&buf)
+	if err != nil {
+		t.Fatalf("ReadFile() returned error: %v", err)
+	}
+	if f.Format != 0 || f.Division != 480 {
+		t.Errorf("Expected format 0 with division 480, got %d and %d", f.Format, f.Division)
+	}
+	if got := f.Seconds(960); got != 2.0 {
+		t.Errorf("Expected two beats at 60 BPM to be 2s, got %v", got)
+	}
+
+	expected := []Segment{
+		{Start: 0, End: 960, Keys: []int{60, 64, 67}},
+		{Start: 960, End: 1920, Keys: []int{65, 69, 72}},
+		{Start: 1920, End: 2880, Keys: []int{67, 71, 74, 77}},
+	}
+	if got := f.Segments(SegmentOptions{}); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Segments() = %+v, want %+v", got, expected)
+	}
+}
+
+func TestWriteProgressionRejectsInvalidChannel(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	if err := WriteProgression(&buf, nil, WriteOptions{Channel: 16}); err == nil {
+		t.Errorf("Expected an error for channel 17")
+	}
+}
+
+func TestWriteProgressionRejectsTempoOutOfRange(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	for _, bpm := range []float64{3, math.Inf(1), math.NaN()} {
+		if err := WriteProgression(&buf, nil, WriteOptions{BPM: bpm}); err == nil {
+			t.Errorf("Expected an error for %g BPM", bpm)
+		}
+	}
+	if err := WriteProgression(&buf, nil, WriteOptions{BPM: 4}); err != nil {
+		t.Errorf("WriteProgression at 4 BPM returned error: %v", err)
+	}
+}
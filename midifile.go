@@ -0,0 +1,157 @@
+// midifile.go
+// This file contains the "midi" subcommand, which identifies the chords in a
+// Standard MIDI File, and the --midi-out export.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cordelia/midi"
+	"cordelia/theory"
+)
+
+func runMidiCommand(args []string) {
+	fs := newCommandFlagSet("midi", "cordelia midi [flags] <file.mid>")
+	segment := fs.String("segment", "onset", "How to cut the timeline into chords: onset or beat.")
+	beats := fs.Float64("beats", 1, "Segment length in beats when --segment=beat.")
+	channels := fs.String("channels", "", "Comma-separated MIDI channels (1-16) to analyse. Default: all.")
+	drums := fs.Bool("drums", false, "Include the percussion channel (10).")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one MIDI file.")
+		exitCode = 1
+		return
+	}
+
+	opts := midi.SegmentOptions{Beats: *beats, IncludeDrums: *drums}
+	switch *segment {
+	case "onset":
+		opts.Mode = midi.SegmentByOnset
+	case "beat":
+		opts.Mode = midi.SegmentByBeat
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown segment mode '%s' (expected onset or beat).\n", *segment)
+		exitCode = 1
+		return
+	}
+	if *beats <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: --beats must be greater than zero.")
+		exitCode = 1
+		return
+	}
+	chans, err := parseChannelList(*channels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+	opts.Channels = chans
+
+	filename := fs.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	smf, err := midi.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not read MIDI file %s: %v\n", filename, err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{})
+	fmt.Printf("Processing %s...\n", filename)
+	segments := smf.Segments(opts)
+	if len(segments) == 0 {
+		fmt.Println("No notes found.")
+		return
+	}
+
+	var allNotes []theory.Note
+	for i, seg := range segments {
+		notes := keysToNotes(seg.Keys)
+		allNotes = append(allNotes, notes...)
+		id := a.IdentifyRoot(notes[0], notes)
+
+		span := fmt.Sprintf("%s-%s", formatTimestamp(smf.Seconds(seg.Start)), formatTimestamp(smf.Seconds(seg.End)))
+		matchStrings := id.MatchStrings()
+		if len(matchStrings) == 0 {
+			fmt.Printf("[%d] %s %s -> No match found\n", i+1, span, theory.SliceToString(notes))
+		} else {
+			fmt.Printf("[%d] %s %s -> %s\n", i+1, span, theory.SliceToString(notes), strings.Join(matchStrings, ", "))
+		}
+	}
+
+	printKeyEstimation(os.Stdout, a.Notation(), a.EstimateKeys(allNotes))
+}
+
+// keysToNotes converts MIDI keys, lowest first, into unique pitch classes so
+// that the bass note becomes the root.
+func keysToNotes(keys []int) []theory.Note {
+	notes := make([]theory.Note, len(keys))
+	for i, k := range keys {
+		pc := k % 12
+		notes[i] = theory.Note{Original: theory.NoteName(pc, false), Value: pc}
+	}
+	return theory.Unique(notes)
+}
+
+// parseChannelList parses 1-based channel numbers into zero-based channels.
+func parseChannelList(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var channels []int
+	for _, part := range strings.Split(s, ",") {
+		ch, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || ch < 1 || ch > 16 {
+			return nil, fmt.Errorf("invalid MIDI channel '%s' (expected 1-16)", part)
+		}
+		channels = append(channels, ch-1)
+	}
+	return channels, nil
+}
+
+// formatTimestamp renders seconds as m:ss.mmm.
+func formatTimestamp(seconds float64) string {
+	millis := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%d:%02d.%03d", millis/60000, millis/1000%60, millis%1000)
+}
+
+// --- MIDI Export ---
+
+// writeMidiExport voices the chords and writes them to the --midi-out file.
+func writeMidiExport(m *exportFlags, chords []progressionChord) {
+	symbols, _ := midi.ParseSymbolEvent(*m.symbols)
+
+	events := make([]midi.ChordEvent, len(chords))
+	for i, c := range chords {
+		events[i] = midi.ChordEvent{Symbol: c.Symbol, Keys: m.voice(c)}
+	}
+
+	file, err := os.Create(*m.midiOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", *m.midiOut, err)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	opts := midi.WriteOptions{BPM: *m.tempo, BeatsPerChord: *m.beats, Symbols: symbols}
+	if err := midi.WriteProgression(file, events, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write %s: %v\n", *m.midiOut, err)
+		exitCode = 1
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d chords to %s\n", len(events), *m.midiOut)
+}
@@ -0,0 +1,349 @@
+// Package musicxml reads and writes MusicXML scores (partwise, uncompressed
+// or .mxl) as the pitched notes and chord symbols relevant to harmonic analysis.
+package musicxml
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pitch is a written pitch. Octave 4 is the octave of middle C.
+type Pitch struct {
+	Step   string // C, D, E, F, G, A or B
+	Alter  int    // semitones, e.g. -1 for flat
+	Octave int
+}
+
+var stepValues = map[string]int{"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11}
+
+// Key returns the MIDI note number of the pitch, 60 = middle C.
+func (p Pitch) Key() int {
+	return 12*(p.Octave+1) + stepValues[p.Step] + p.Alter
+}
+
+// Name spells the pitch class, e.g. "F#" or "Bb".
+func (p Pitch) Name() string {
+	switch {
+	case p.Alter > 0:
+		return p.Step + strings.Repeat("#", p.Alter)
+	case p.Alter < 0:
+		return p.Step + strings.Repeat("b", -p.Alter)
+	}
+	return p.Step
+}
+
+// Note is a sounding note. Times are in quarter notes from the start of the score.
+type Note struct {
+	Part     string
+	Measure  int // index into Score.Measures
+	Start    float64
+	Duration float64
+	Pitch    Pitch
+}
+
+// Harmony is a chord symbol written in the score. Line and Column are the
+// 1-based position of its <harmony> tag in the score document; Column counts
+// characters.
+type Harmony struct {
+	Part    string
+	Measure int // index into Score.Measures
+	Start   float64
+	Root    Pitch
+	Kind    string // MusicXML kind value, e.g. "minor-seventh"
+	Text    string // the kind's display text, if any
+	Bass    *Pitch
+	Line    int
+	Column  int
+}
+
+// Measure is the position of one measure, taken from the first part.
+type Measure struct {
+	Number string
+	Start  float64
+	Length float64
+	// BeatLength is the length of one beat of the time signature in quarter notes.
+	BeatLength float64
+}
+
+// Beat returns the 1-based beat within the measure at which t falls.
+func (m Measure) Beat(t float64) float64 {
+	return (t-m.Start)/m.BeatLength + 1
+}
+
+// Score is the content of a MusicXML file relevant to harmonic analysis.
+type Score struct {
+	Measures  []Measure
+	Notes     []Note
+	Harmonies []Harmony
+}
+
+// --- Reading ---
+
+type xmlScore struct {
+	XMLName xml.Name
+	Parts   []xmlPart `xml:"part"`
+}
+
+type xmlPart struct {
+	ID       string       `xml:"id,attr"`
+	Measures []xmlMeasure `xml:"measure"`
+}
+
+type xmlMeasure struct {
+	Number   string       `xml:"number,attr"`
+	Elements []xmlElement `xml:",any"`
+}
+
+// xmlElement holds the fields of every measure child we care about; which of
+// them are set depends on XMLName.
+type xmlElement struct {
+	XMLName   xml.Name
+	Divisions float64   `xml:"divisions"`
+	Times     []xmlTime `xml:"time"`
+	Duration  float64   `xml:"duration"`
+	Chord     *struct{} `xml:"chord"`
+	Rest      *struct{} `xml:"rest"`
+	Grace     *struct{} `xml:"grace"`
+	Cue       *struct{} `xml:"cue"`
+	Pitch     *xmlPitch `xml:"pitch"`
+	Root      *xmlRoot  `xml:"root"`
+	Kind      xmlKind   `xml:"kind"`
+	Bass      *xmlBass  `xml:"bass"`
+	Offset    float64   `xml:"offset"`
+	end       int64     // input offset just after the start tag
+}
+
+// UnmarshalXML decodes an element, recording where its start tag ends.
+func (e *xmlElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type plain xmlElement
+	e.end = d.InputOffset()
+	return d.DecodeElement((*plain)(e), &start)
+}
+
+type xmlTime struct {
+	Beats    string `xml:"beats"`
+	BeatType int    `xml:"beat-type"`
+}
+
+type xmlPitch struct {
+	Step   string  `xml:"step"`
+	Alter  float64 `xml:"alter"`
+	Octave int     `xml:"octave"`
+}
+
+type xmlRoot struct {
+	Step  string  `xml:"root-step"`
+	Alter float64 `xml:"root-alter"`
+}
+
+type xmlBass struct {
+	Step  string  `xml:"bass-step"`
+	Alter float64 `xml:"bass-alter"`
+}
+
+type xmlKind struct {
+	Value string `xml:",chardata"`
+	Text  string `xml:"text,attr"`
+}
+
+// ReadScore parses a score-partwise document, or a compressed .mxl archive
+// containing one.
+func ReadScore(r io.Reader) (*Score, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		if data, err = readCompressed(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc xmlScore
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid MusicXML: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "score-partwise":
+	case "score-timewise":
+		return nil, errors.New("score-timewise documents are not supported")
+	default:
+		return nil, errors.New("not a MusicXML score")
+	}
+
+	s := &Score{}
+	for i, part := range doc.Parts {
+		if err := s.readPart(part, i == 0, data); err != nil {
+			return nil, fmt.Errorf("part %s: %w", part.ID, err)
+		}
+	}
+	sort.SliceStable(s.Notes, func(i, j int) bool { return s.Notes[i].Start < s.Notes[j].Start })
+	sort.SliceStable(s.Harmonies, func(i, j int) bool { return s.Harmonies[i].Start < s.Harmonies[j].Start })
+	return s, nil
+}
+
+// readPart walks one part's measures, converting durations to quarter notes.
+// The first part also defines the measure positions. data is the score
+// document, used to locate harmonies.
+func (s *Score) readPart(part xmlPart, first bool, data []byte) error {
+	divisions := 1.0
+	beatLength := 1.0
+	cursor := 0.0
+	for mi, m := range part.Measures {
+		if !first && mi >= len(s.Measures) {
+			return fmt.Errorf("measure %s is not in the first part", m.Number)
+		}
+		measureStart := cursor
+		measureEnd := cursor
+		lastStart := cursor
+
+		for _, el := range m.Elements {
+			switch el.XMLName.Local {
+			case "attributes":
+				if el.Divisions > 0 {
+					divisions = el.Divisions
+				}
+				if len(el.Times) > 0 && el.Times[0].BeatType > 0 {
+					beatLength = 4 / float64(el.Times[0].BeatType)
+				}
+			case "note":
+				if el.Grace != nil || el.Cue != nil {
+					continue
+				}
+				start := cursor
+				if el.Chord != nil {
+					start = lastStart
+				}
+				duration := el.Duration / divisions
+				if el.Pitch != nil && el.Rest == nil {
+					if _, ok := stepValues[el.Pitch.Step]; !ok {
+						return fmt.Errorf("measure %s: invalid step '%s'", m.Number, el.Pitch.Step)
+					}
+					s.Notes = append(s.Notes, Note{
+						Part:     part.ID,
+						Measure:  mi,
+						Start:    start,
+						Duration: duration,
+						Pitch:    Pitch{Step: el.Pitch.Step, Alter: roundAlter(el.Pitch.Alter), Octave: el.Pitch.Octave},
+					})
+				}
+				if el.Chord == nil {
+					lastStart = cursor
+					cursor += duration
+				}
+			case "backup":
+				cursor -= el.Duration / divisions
+			case "forward":
+				cursor += el.Duration / divisions
+			case "harmony":
+				if el.Root == nil {
+					continue // function-only harmony, e.g. a Roman numeral
+				}
+				if _, ok := stepValues[el.Root.Step]; !ok {
+					return fmt.Errorf("measure %s: invalid root step '%s'", m.Number, el.Root.Step)
+				}
+				h := Harmony{
+					Part:    part.ID,
+					Measure: mi,
+					Start:   cursor + el.Offset/divisions,
+					Root:    Pitch{Step: el.Root.Step, Alter: roundAlter(el.Root.Alter)},
+					Kind:    strings.TrimSpace(el.Kind.Value),
+					Text:    el.Kind.Text,
+				}
+				h.Line, h.Column = position(data, el.end)
+				if el.Bass != nil {
+					if _, ok := stepValues[el.Bass.Step]; !ok {
+						return fmt.Errorf("measure %s: invalid bass step '%s'", m.Number, el.Bass.Step)
+					}
+					h.Bass = &Pitch{Step: el.Bass.Step, Alter: roundAlter(el.Bass.Alter)}
+				}
+				s.Harmonies = append(s.Harmonies, h)
+			}
+			if cursor < measureStart {
+				return fmt.Errorf("measure %s: backup before the start of the measure", m.Number)
+			}
+			if cursor > measureEnd {
+				measureEnd = cursor
+			}
+		}
+
+		if first {
+			s.Measures = append(s.Measures, Measure{
+				Number:     m.Number,
+				Start:      measureStart,
+				Length:     measureEnd - measureStart,
+				BeatLength: beatLength,
+			})
+		}
+		cursor = measureEnd
+	}
+	return nil
+}
+
+// position returns the 1-based line and column of the tag that ends just
+// before offset.
+func position(data []byte, offset int64) (line, column int) {
+	start := bytes.LastIndexByte(data[:offset], '<')
+	lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+	return bytes.Count(data[:start], []byte("\n")) + 1, utf8.RuneCount(data[lineStart:start]) + 1
+}
+
+// roundAlter rounds microtonal alterations to the nearest semitone.
+func roundAlter(alter float64) int {
+	return int(math.Round(alter))
+}
+
+// readCompressed extracts the root score from an .mxl archive.
+func readCompressed(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid .mxl archive: %w", err)
+	}
+
+	rootPath := ""
+	for _, f := range zr.File {
+		if f.Name != "META-INF/container.xml" {
+			continue
+		}
+		var container struct {
+			Rootfiles []struct {
+				Path string `xml:"full-path,attr"`
+			} `xml:"rootfiles>rootfile"`
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if err := xml.Unmarshal(content, &container); err == nil && len(container.Rootfiles) > 0 {
+			rootPath = container.Rootfiles[0].Path
+		}
+	}
+
+	for _, f := range zr.File {
+		if rootPath == "" && !strings.HasPrefix(f.Name, "META-INF/") && path.Ext(f.Name) == ".xml" {
+			rootPath = f.Name
+		}
+		if f.Name == rootPath {
+			return readZipFile(f)
+		}
+	}
+	return nil, errors.New("no score found in .mxl archive")
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,163 @@
+package musicxml
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testScore has two parts in 4/4 with two divisions per quarter. Measure 1
+// holds C then Am7/G symbols over a C triad and an A minor triad; measure 2
+// holds Bb over two voices joined with <backup>.
+const testScore = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">
+<score-partwise version="4.0">
+  <part-list>
+    <score-part id="P1"><part-name>Piano</part-name></score-part>
+    <score-part id="P2"><part-name>Bass</part-name></score-part>
+  </part-list>
+  <part id="P1">
+    <measure number="1">
+      <attributes><divisions>2</divisions><time><beats>4</beats><beat-type>4</beat-type></time></attributes>
+      <harmony><root><root-step>C</root-step></root><kind>major</kind></harmony>
+      <note><pitch><step>C</step><octave>4</octave></pitch><duration>4</duration></note>
+      <note><chord/><pitch><step>E</step><octave>4</octave></pitch><duration>4</duration></note>
+      <note><chord/><pitch><step>G</step><octave>4</octave></pitch><duration>4</duration></note>
+      <harmony><root><root-step>A</root-step></root><kind text="m7">minor-seventh</kind><bass><bass-step>G</bass-step></bass></harmony>
+      <note><pitch><step>A</step><octave>3</octave></pitch><duration>4</duration></note>
+      <note><chord/><pitch><step>C</step><octave>4</octave></pitch><duration>4</duration></note>
+      <note><chord/><pitch><step>E</step><octave>4</octave></pitch><duration>4</duration></note>
+    </measure>
+    <measure number="2">
+      <harmony><root><root-step>B</root-step><root-alter>-1</root-alter></root><kind>major</kind></harmony>
+      <note><grace/><pitch><step>C</step><octave>5</octave></pitch></note>
+      <note><pitch><step>D</step><octave>4</octave></pitch><duration>8</duration></note>
+      <backup><duration>8</duration></backup>
+      <note><rest/><duration>4</duration></note>
+      <note><pitch><step>F</step><octave>4</octave></pitch><duration>4</duration></note>
+    </measure>
+  </part>
+  <part id="P2">
+    <measure number="1">
+      <attributes><divisions>1</divisions></attributes>
+      <note><rest/><duration>4</duration></note>
+    </measure>
+    <measure number="2">
+      <note><pitch><step>B</step><alter>-1</alter><octave>2</octave></pitch><duration>4</duration></note>
+    </measure>
+  </part>
+</score-partwise>`
+
+func TestReadScore(t *testing.T) {
+	t.Parallel()
+	s, err := ReadScore(strings.NewReader(testScore))
+	if err != nil {
+		t.Fatalf("ReadScore() returned error: %v", err)
+	}
+
+	expectedMeasures := []Measure{
+		{Number: "1", Start: 0, Length: 4, BeatLength: 1},
+		{Number: "2", Start: 4, Length: 4, BeatLength: 1},
+	}
+	if !reflect.DeepEqual(s.Measures, expectedMeasures) {
+		t.Errorf("Measures = %+v, want %+v", s.Measures, expectedMeasures)
+	}
+	if len(s.Notes) != 9 {
+		t.Errorf("Expected 9 notes without the grace note, got %d: %+v", len(s.Notes), s.Notes)
+	}
+
+	if len(s.Harmonies) != 3 {
+		t.Fatalf("Expected 3 harmonies, got %d: %+v", len(s.Harmonies), s.Harmonies)
+	}
+	am7 := s.Harmonies[1]
+	if am7.Start != 2 || am7.Root.Name() != "A" || am7.Kind != "minor-seventh" || am7.Text != "m7" {
+		t.Errorf("Unexpected second harmony: %+v", am7)
+	}
+	if am7.Bass == nil || am7.Bass.Name() != "G" {
+		t.Errorf("Expected bass G on the second harmony, got %+v", am7.Bass)
+	}
+	if am7.Line != 15 || am7.Column != 7 {
+		t.Errorf("Expected the second harmony at line 15, column 7, got %d:%d", am7.Line, am7.Column)
+	}
+	if bb := s.Harmonies[2]; bb.Measure != 1 || bb.Root.Name() != "Bb" {
+		t.Errorf("Expected Bb in the second measure, got %+v", bb)
+	}
+}
+
+func TestScoreSegments(t *testing.T) {
+	t.Parallel()
+	s, err := ReadScore(strings.NewReader(testScore))
+	if err != nil {
+		t.Fatalf("ReadScore() returned error: %v", err)
+	}
+
+	keys := func(segments []Segment) [][]int {
+		var out [][]int
+		for _, seg := range segments {
+			var k []int
+			for _, p := range seg.Pitches {
+				k = append(k, p.Key())
+			}
+			out = append(out, k)
+		}
+		return out
+	}
+
+	byMeasure := [][]int{{57, 60, 64, 67}, {46, 62, 65}}
+	if got := keys(s.Segments(SegmentByMeasure)); !reflect.DeepEqual(got, byMeasure) {
+		t.Errorf("Segments(SegmentByMeasure) = %v, want %v", got, byMeasure)
+	}
+
+	byBeat := [][]int{
+		{60, 64, 67}, {60, 64, 67}, {57, 60, 64}, {57, 60, 64},
+		{46, 62}, {46, 62}, {46, 62, 65}, {46, 62, 65},
+	}
+	if got := keys(s.Segments(SegmentByBeat)); !reflect.DeepEqual(got, byBeat) {
+		t.Errorf("Segments(SegmentByBeat) = %v, want %v", got, byBeat)
+	}
+}
+
+func TestReadScoreCompressed(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="score/tune.xml"/></rootfiles></container>`,
+		"score/tune.xml":         testScore,
+	}
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := ReadScore(&buf)
+	if err != nil {
+		t.Fatalf("ReadScore() returned error: %v", err)
+	}
+	if len(s.Measures) != 2 || len(s.Harmonies) != 3 {
+		t.Errorf("Expected 2 measures and 3 harmonies, got %d and %d", len(s.Measures), len(s.Harmonies))
+	}
+}
+
+func TestReadScoreRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+	inputs := map[string]string{
+		"Not XML":        "MThd",
+		"Other Document": "<html></html>",
+		"Timewise":       "<score-timewise></score-timewise>",
+		"Invalid Step":   `<score-partwise><part id="P1"><measure number="1"><note><pitch><step>H</step><octave>4</octave></pitch><duration>1</duration></note></measure></part></score-partwise>`,
+	}
+	for name, input := range inputs {
+		if _, err := ReadScore(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package musicxml
+
+import "sort"
+
+// SegmentMode selects how measures are cut into chord segments.
+type SegmentMode int
+
+const (
+	// SegmentByMeasure gathers every note sounding in a measure.
+	SegmentByMeasure SegmentMode = iota
+	// SegmentByBeat gathers the notes sounding during each beat of the time signature.
+	SegmentByBeat
+)
+
+// Segment is a span of a measure and the pitches sounding during it, lowest
+// first. Enharmonic duplicates keep the first spelling found.
+type Segment struct {
+	Measure int // index into Score.Measures
+	Start   float64
+	End     float64
+	Pitches []Pitch
+}
+
+// Segments groups the score's notes by measure or by beat. Spans without
+// notes are left out.
+func (s *Score) Segments(mode SegmentMode) []Segment {
+	var segments []Segment
+	for mi, m := range s.Measures {
+		end := m.Start + m.Length
+		if mode != SegmentByBeat || m.BeatLength <= 0 {
+			if pitches := s.soundingPitches(m.Start, end); len(pitches) > 0 {
+				segments = append(segments, Segment{Measure: mi, Start: m.Start, End: end, Pitches: pitches})
+			}
+			continue
+		}
+		for start := m.Start; start < end; start += m.BeatLength {
+			beatEnd := start + m.BeatLength
+			if beatEnd > end {
+				beatEnd = end
+			}
+			if pitches := s.soundingPitches(start, beatEnd); len(pitches) > 0 {
+				segments = append(segments, Segment{Measure: mi, Start: start, End: beatEnd, Pitches: pitches})
+			}
+		}
+	}
+	return segments
+}
+
+// soundingPitches returns the pitches of notes overlapping [start, end),
+// sorted and unique by key.
+func (s *Score) soundingPitches(start, end float64) []Pitch {
+	seen := make(map[int]bool)
+	var pitches []Pitch
+	for _, n := range s.Notes {
+		if n.Start >= end || n.Start+n.Duration <= start {
+			continue
+		}
+		if k := n.Pitch.Key(); !seen[k] {
+			seen[k] = true
+			pitches = append(pitches, n.Pitch)
+		}
+	}
+	sort.Slice(pitches, func(i, j int) bool { return pitches[i].Key() < pitches[j].Key() })
+	return pitches
+}
@@ -0,0 +1,180 @@
+package musicxml
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+// ChordSymbol is one measure of an exported score: a chord symbol over the
+// notes of the chord staff.
+type ChordSymbol struct {
+	Root Pitch // the octave is ignored
+	// Kind is the MusicXML kind value, e.g. "minor-seventh". An empty Kind
+	// leaves out the <harmony> element.
+	Kind string
+	Text string // display text for the kind, e.g. "m7"
+	Bass *Pitch
+	// Pitches are written as a whole-note chord; none writes a measure rest.
+	Pitches []Pitch
+}
+
+// WriteOptions configures WriteScore.
+type WriteOptions struct {
+	PartName string // default "Chords"
+}
+
+// PitchForKey spells a MIDI key with the given step and alteration, choosing
+// the octave so that Key returns key.
+func PitchForKey(key int, step string, alter int) Pitch {
+	natural := key - stepValues[step] - alter
+	if natural < 0 {
+		natural -= 11 // round towards negative infinity
+	}
+	return Pitch{Step: step, Alter: alter, Octave: natural/12 - 1}
+}
+
+type outScore struct {
+	XMLName  xml.Name    `xml:"score-partwise"`
+	Version  string      `xml:"version,attr"`
+	PartList outPartList `xml:"part-list"`
+	Part     outPart     `xml:"part"`
+}
+
+type outPartList struct {
+	ScorePart struct {
+		ID   string `xml:"id,attr"`
+		Name string `xml:"part-name"`
+	} `xml:"score-part"`
+}
+
+type outPart struct {
+	ID       string       `xml:"id,attr"`
+	Measures []outMeasure `xml:"measure"`
+}
+
+type outMeasure struct {
+	Number     int            `xml:"number,attr"`
+	Attributes *outAttributes `xml:"attributes,omitempty"`
+	Harmony    *outHarmony    `xml:"harmony,omitempty"`
+	Notes      []outNote      `xml:"note"`
+}
+
+type outAttributes struct {
+	Divisions int `xml:"divisions"`
+	Time      struct {
+		Beats    int `xml:"beats"`
+		BeatType int `xml:"beat-type"`
+	} `xml:"time"`
+	Clef struct {
+		Sign string `xml:"sign"`
+		Line int    `xml:"line"`
+	} `xml:"clef"`
+}
+
+type outHarmony struct {
+	Root struct {
+		Step  string `xml:"root-step"`
+		Alter int    `xml:"root-alter,omitempty"`
+	} `xml:"root"`
+	Kind struct {
+		Value string `xml:",chardata"`
+		Text  string `xml:"text,attr"`
+	} `xml:"kind"`
+	Bass *outBass `xml:"bass,omitempty"`
+}
+
+type outBass struct {
+	Step  string `xml:"bass-step"`
+	Alter int    `xml:"bass-alter,omitempty"`
+}
+
+type outNote struct {
+	Chord    *struct{} `xml:"chord,omitempty"`
+	Rest     *outRest  `xml:"rest,omitempty"`
+	Pitch    *outPitch `xml:"pitch,omitempty"`
+	Duration int       `xml:"duration"`
+	Type     string    `xml:"type,omitempty"`
+}
+
+type outRest struct {
+	Measure string `xml:"measure,attr"`
+}
+
+type outPitch struct {
+	Step   string `xml:"step"`
+	Alter  int    `xml:"alter,omitempty"`
+	Octave int    `xml:"octave"`
+}
+
+const doctype = `<!DOCTYPE score-partwise PUBLIC "-//Recordare//DTD MusicXML 4.0 Partwise//EN" "http://www.musicxml.org/dtds/partwise.dtd">` + "\n"
+
+// WriteScore writes the chords as a single-part score-partwise document in
+// 4/4, one chord per measure. The clef is chosen from the average pitch.
+func WriteScore(w io.Writer, chords []ChordSymbol, opts WriteOptions) error {
+	if opts.PartName == "" {
+		opts.PartName = "Chords"
+	}
+
+	doc := outScore{Version: "4.0"}
+	doc.PartList.ScorePart.ID = "P1"
+	doc.PartList.ScorePart.Name = opts.PartName
+	doc.Part.ID = "P1"
+
+	attrs := &outAttributes{Divisions: 1}
+	attrs.Time.Beats, attrs.Time.BeatType = 4, 4
+	attrs.Clef.Sign, attrs.Clef.Line = clefFor(chords)
+
+	for i, c := range chords {
+		m := outMeasure{Number: i + 1}
+		if i == 0 {
+			m.Attributes = attrs
+		}
+		if c.Kind != "" {
+			h := &outHarmony{}
+			h.Root.Step, h.Root.Alter = c.Root.Step, c.Root.Alter
+			h.Kind.Value, h.Kind.Text = c.Kind, c.Text
+			if c.Bass != nil {
+				h.Bass = &outBass{Step: c.Bass.Step, Alter: c.Bass.Alter}
+			}
+			m.Harmony = h
+		}
+		if len(c.Pitches) == 0 {
+			m.Notes = append(m.Notes, outNote{Rest: &outRest{Measure: "yes"}, Duration: 4})
+		}
+		for j, p := range c.Pitches {
+			n := outNote{Duration: 4, Type: "whole"}
+			if j > 0 {
+				n.Chord = &struct{}{}
+			}
+			n.Pitch = &outPitch{Step: p.Step, Alter: p.Alter, Octave: p.Octave}
+			m.Notes = append(m.Notes, n)
+		}
+		doc.Part.Measures = append(doc.Part.Measures, m)
+	}
+
+	if _, err := io.WriteString(w, xml.Header+doctype); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// clefFor picks the bass clef when the chords sit mostly below middle C.
+func clefFor(chords []ChordSymbol) (string, int) {
+	total, count := 0, 0
+	for _, c := range chords {
+		for _, p := range c.Pitches {
+			total += p.Key()
+			count++
+		}
+	}
+	if count > 0 && total/count < 60 {
+		return "F", 4
+	}
+	return "G", 2
+}
@@ -0,0 +1,88 @@
+package musicxml
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPitchForKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		key      int
+		step     string
+		alter    int
+		expected Pitch
+	}{
+		{60, "C", 0, Pitch{Step: "C", Octave: 4}},
+		{70, "B", -1, Pitch{Step: "B", Alter: -1, Octave: 4}},
+		{59, "C", -1, Pitch{Step: "C", Alter: -1, Octave: 4}},
+		{60, "B", 1, Pitch{Step: "B", Alter: 1, Octave: 3}},
+		{0, "C", 0, Pitch{Step: "C", Octave: -1}},
+	}
+	for _, tt := range tests {
+		got := PitchForKey(tt.key, tt.step, tt.alter)
+		if got != tt.expected {
+			t.Errorf("PitchForKey(%d, %s, %d) = %+v, want %+v", tt.key, tt.step, tt.alter, got, tt.expected)
+		}
+		if got.Key() != tt.key {
+			t.Errorf("PitchForKey(%d, %s, %d).Key() = %d", tt.key, tt.step, tt.alter, got.Key())
+		}
+	}
+}
+
+func TestWriteScoreRoundTrip(t *testing.T) {
+	t.Parallel()
+	bass := Pitch{Step: "G"}
+	chords := []ChordSymbol{
+		{
+			Root:    Pitch{Step: "B", Alter: -1},
+			Kind:    "major-seventh",
+			Text:    "maj7",
+			Pitches: []Pitch{{"B", -1, 3}, {"D", 0, 4}, {"F", 0, 4}, {"A", 0, 4}},
+		},
+		{
+			Root:    Pitch{Step: "A"},
+			Kind:    "minor-seventh",
+			Text:    "m7",
+			Bass:    &bass,
+			Pitches: []Pitch{{"G", 0, 3}, {"A", 0, 3}, {"C", 0, 4}, {"E", 0, 4}},
+		},
+		{Pitches: []Pitch{{"C", 0, 4}, {"D", 0, 4}}},
+		{},
+	}
+	var buf bytes.Buffer
+	if err := WriteScore(&buf, chords, WriteOptions{}); err != nil {
+		t.Fatalf("WriteScore() returned error: %v", err)
+	}
+
+	s, err := ReadScore(&buf)
+	if err != nil {
+		t.Fatalf("ReadScore() returned error: %v", err)
+	}
+	if len(s.Measures) != 4 || s.Measures[3].Start != 12 {
+		t.Errorf("Expected four whole-note measures, got %+v", s.Measures)
+	}
+	if len(s.Harmonies) != 2 {
+		t.Fatalf("Expected 2 harmonies, got %d: %+v", len(s.Harmonies), s.Harmonies)
+	}
+	expected := Harmony{Part: "P1", Measure: 1, Start: 4, Root: Pitch{Step: "A"}, Kind: "minor-seventh", Text: "m7", Bass: &bass}
+	got := s.Harmonies[1]
+	got.Line, got.Column = 0, 0 // depend on the writer's layout
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Second harmony = %+v, want %+v", got, expected)
+	}
+
+	var keys [][]int
+	for _, seg := range s.Segments(SegmentByMeasure) {
+		var k []int
+		for _, p := range seg.Pitches {
+			k = append(k, p.Key())
+		}
+		keys = append(keys, k)
+	}
+	expectedKeys := [][]int{{58, 62, 65, 69}, {55, 57, 60, 64}, {60, 62}}
+	if !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Segments() keys = %v, want %v", keys, expectedKeys)
+	}
+}
@@ -0,0 +1,282 @@
+// musicxmlfile.go
+// This file contains the "musicxml" subcommand, which reads chord symbols and
+// notes from a MusicXML score and reports them by measure, and the
+// --musicxml-out export.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"cordelia/musicxml"
+	"cordelia/theory"
+)
+
+// harmonyKindSuffixes maps MusicXML <kind> values to the dictionary suffixes
+// of the same chords, read with theory.ChordScaleDictionary. Kinds in neither
+// this map nor simplifiedHarmonyKinds are unsupported.
+var harmonyKindSuffixes = map[string]string{
+	"major":             "",
+	"minor":             "m",
+	"augmented":         "aug",
+	"diminished":        "dim",
+	"dominant":          "7",
+	"major-seventh":     "maj7",
+	"minor-seventh":     "m7",
+	"major-minor":       "m(maj7)",
+	"suspended-second":  "sus2",
+	"suspended-fourth":  "sus4",
+	"half-diminished":   "m7b5",
+	"augmented-seventh": "7#5",
+}
+
+// simplifiedHarmonyKinds maps extended MusicXML kinds to the 7th chord they
+// are reduced to. Such chords are printed with their original kind.
+var simplifiedHarmonyKinds = map[string]string{
+	"dominant-ninth": "7",
+	"dominant-11th":  "7",
+	"dominant-13th":  "7",
+	"major-ninth":    "maj7",
+	"major-11th":     "maj7",
+	"major-13th":     "maj7",
+	"minor-ninth":    "m7",
+	"minor-11th":     "m7",
+	"minor-13th":     "m7",
+}
+
+// chordKinds maps dictionary chord names to MusicXML <kind> values for export.
+var chordKinds = map[string]string{
+	"Major Triad":          "major",
+	"Minor Triad":          "minor",
+	"Augmented Triad":      "augmented",
+	"Diminished Triad":     "diminished",
+	"Dominant 7th":         "dominant",
+	"Major 7th":            "major-seventh",
+	"Minor 7th":            "minor-seventh",
+	"Minor-Major 7th":      "major-minor",
+	"Sus2":                 "suspended-second",
+	"Sus4":                 "suspended-fourth",
+	"Half-Diminished 7th":  "half-diminished",
+	"Dominant 7th Sharp 5": "augmented-seventh",
+}
+
+func runMusicXMLCommand(args []string) {
+	fs := newCommandFlagSet("musicxml", "cordelia musicxml [flags] <file.musicxml|file.mxl>")
+	segment := fs.String("segment", "measure", "How to group simultaneous notes: measure or beat.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: Expected exactly one MusicXML file.")
+		exitCode = 1
+		return
+	}
+
+	var mode musicxml.SegmentMode
+	switch *segment {
+	case "measure":
+		mode = musicxml.SegmentByMeasure
+	case "beat":
+		mode = musicxml.SegmentByBeat
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown segment mode '%s' (expected measure or beat).\n", *segment)
+		exitCode = 1
+		return
+	}
+
+	filename := fs.Arg(0)
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	score, err := musicxml.ReadScore(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not read MusicXML file %s: %v\n", filename, err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{})
+	symbols := theory.NewAnalyzer(theory.Options{Dictionary: theory.ChordScaleDictionary()})
+	fmt.Printf("Processing %s...\n", filename)
+
+	// Chord symbols are the better evidence for the key, so notes are only
+	// used when the score has none.
+	var harmonyNotes, scoreNotes []theory.Note
+	hasErrors := false
+	if len(score.Harmonies) > 0 {
+		fmt.Println("Chord Symbols:")
+		for _, h := range score.Harmonies {
+			label := measureLabel(score.Measures[h.Measure], h.Start, true)
+			chord, err := harmonyChord(symbols, h)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s:%d:%d: %s: %v\n", filename, h.Line, h.Column, chord.Symbol, err)
+				hasErrors = true
+				continue
+			}
+			harmonyNotes = append(harmonyNotes, chord.Notes...)
+			fmt.Printf("[%s] %s -> %s\n", label, harmonySymbol(h, chord), theory.SliceToString(chord.Notes))
+		}
+	}
+
+	segments := score.Segments(mode)
+	if len(segments) > 0 {
+		fmt.Println("Notes:")
+	}
+	for _, seg := range segments {
+		notes := pitchesToNotes(seg.Pitches)
+		scoreNotes = append(scoreNotes, notes...)
+		id := a.IdentifyRoot(notes[0], notes)
+
+		label := measureLabel(score.Measures[seg.Measure], seg.Start, mode == musicxml.SegmentByBeat)
+		matchStrings := id.MatchStrings()
+		if len(matchStrings) == 0 {
+			fmt.Printf("[%s] %s -> No match found\n", label, theory.SliceToString(notes))
+		} else {
+			fmt.Printf("[%s] %s -> %s\n", label, theory.SliceToString(notes), strings.Join(matchStrings, ", "))
+		}
+	}
+
+	switch {
+	case len(harmonyNotes) > 0:
+		printKeyEstimation(os.Stdout, a.Notation(), a.EstimateKeys(harmonyNotes))
+	case len(scoreNotes) > 0:
+		printKeyEstimation(os.Stdout, a.Notation(), a.EstimateKeys(scoreNotes))
+	default:
+		fmt.Println("No chord symbols or notes found.")
+	}
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+// harmonyChord spells a <harmony> element with the analyzer's dictionary,
+// reducing extended kinds to 7th chords. The returned Symbol is set even when
+// the kind is unsupported.
+func harmonyChord(a *theory.Analyzer, h musicxml.Harmony) (progressionChord, error) {
+	root := pitchToNote(h.Root)
+	suffix, ok := harmonyKindSuffixes[h.Kind]
+	if !ok {
+		suffix, ok = simplifiedHarmonyKinds[h.Kind]
+	}
+	if !ok {
+		return progressionChord{Symbol: root.Original + h.Text, Root: root}, fmt.Errorf("unsupported chord kind '%s'", h.Kind)
+	}
+	chord := progressionChord{Symbol: root.Original + suffix, Root: root, Suffix: suffix}
+
+	_, chordDef, err := a.ParseChordName(theory.NoteName(root.Value, false) + suffix)
+	if err != nil {
+		return chord, err
+	}
+	chord.Quality = chordDef.Name
+	chord.Notes = theory.GenerateNotes(root, chordDef.Intervals)
+	chord.Parsed = theory.ChordSymbol{Root: root, Suffix: suffix, Chord: chordDef}
+	if h.Bass != nil {
+		bass := pitchToNote(*h.Bass)
+		chord.Symbol += "/" + bass.Original
+		chord.Bass = &bass
+		chord.Parsed.Bass = &bass
+		chord.Notes = theory.Unique(append([]theory.Note{bass}, chord.Notes...))
+	}
+	return chord, nil
+}
+
+// harmonySymbol writes a harmony's chord symbol, followed by the original
+// kind and its display text when the chord was simplified, as in
+// `C7 (dominant-ninth "9")`.
+func harmonySymbol(h musicxml.Harmony, chord progressionChord) string {
+	if _, ok := simplifiedHarmonyKinds[h.Kind]; !ok {
+		return chord.Symbol
+	}
+	kind := h.Kind
+	if h.Text != "" {
+		kind += fmt.Sprintf(" %q", h.Text)
+	}
+	return fmt.Sprintf("%s (%s)", chord.Symbol, kind)
+}
+
+// measureLabel renders a position as "m12", or "m12 beat 3" when beats are shown.
+func measureLabel(m musicxml.Measure, t float64, showBeat bool) string {
+	label := "m" + m.Number
+	if showBeat {
+		label += " beat " + strconv.FormatFloat(m.Beat(t), 'g', 4, 64)
+	}
+	return label
+}
+
+func pitchToNote(p musicxml.Pitch) theory.Note {
+	return theory.Note{Original: p.Name(), Value: (p.Key()%12 + 12) % 12}
+}
+
+// pitchesToNotes converts pitches, lowest first, into unique pitch classes so
+// that the bass note becomes the root.
+func pitchesToNotes(pitches []musicxml.Pitch) []theory.Note {
+	notes := make([]theory.Note, len(pitches))
+	for i, p := range pitches {
+		notes[i] = pitchToNote(p)
+	}
+	return theory.Unique(notes)
+}
+
+// noteToPitch spells a MIDI key with the letter and accidentals of a note name.
+func noteToPitch(key int, n theory.Note) musicxml.Pitch {
+	step, alter := spellingOf(n)
+	return musicxml.PitchForKey(key, step, alter)
+}
+
+// --- MusicXML Export ---
+
+// chordSymbol converts a progression chord into a measure of the exported
+// score. Chords outside the MusicXML kinds are written with kind "other" and
+// their suffix as text; unmatched chords get no symbol.
+func (e *exportFlags) chordSymbol(c progressionChord, flats bool) musicxml.ChordSymbol {
+	var sym musicxml.ChordSymbol
+	if c.Quality != "" {
+		sym.Root = noteToPitch(c.Root.Value, c.Root)
+		sym.Kind = chordKinds[c.Quality]
+		if sym.Kind == "" {
+			sym.Kind = "other"
+		}
+		sym.Text = c.Suffix
+		if c.Bass != nil {
+			bass := noteToPitch(c.Bass.Value, *c.Bass)
+			sym.Bass = &bass
+		}
+	}
+
+	for _, key := range e.voice(c) {
+		sym.Pitches = append(sym.Pitches, noteToPitch(key, c.spelling(key, flats)))
+	}
+	return sym
+}
+
+// writeMusicXMLExport writes the chords to the --musicxml-out file.
+func writeMusicXMLExport(e *exportFlags, chords []progressionChord) {
+	flats := flatSpellings(chords)
+	symbols := make([]musicxml.ChordSymbol, len(chords))
+	for i, c := range chords {
+		symbols[i] = e.chordSymbol(c, flats[i])
+	}
+
+	file, err := os.Create(*e.musicXMLOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", *e.musicXMLOut, err)
+		exitCode = 1
+		return
+	}
+	defer file.Close()
+
+	if err := musicxml.WriteScore(file, symbols, musicxml.WriteOptions{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not write %s: %v\n", *e.musicXMLOut, err)
+		exitCode = 1
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d chords to %s\n", len(symbols), *e.musicXMLOut)
+}
@@ -0,0 +1,53 @@
+// musicxmlfile_test.go
+// This file contains the tests for reading MusicXML chord symbols.
+
+package main
+
+import (
+	"testing"
+
+	"cordelia/musicxml"
+	"cordelia/theory"
+)
+
+func TestHarmonyChord(t *testing.T) {
+	t.Parallel()
+	a := theory.NewAnalyzer(theory.Options{Dictionary: theory.ChordScaleDictionary()})
+	tests := []struct {
+		root     string
+		kind     string
+		text     string
+		expected string
+		notes    string
+		err      string
+	}{
+		{"C", "major", "", "C", "C E G", ""},
+		{"B", "half-diminished", "ø7", "Bm7b5", "B D F A", ""},
+		{"C", "augmented-seventh", "+7", "C7#5", "C E G# A#", ""},
+		{"C", "dominant-ninth", "9", `C7 (dominant-ninth "9")`, "C E G A#", ""},
+		{"D", "minor-11th", "", "Dm7 (minor-11th)", "D F A C", ""},
+		{"B", "diminished-seventh", "dim7", "", "", "unsupported chord kind 'diminished-seventh'"},
+		{"C", "major-sixth", "6", "", "", "unsupported chord kind 'major-sixth'"},
+		{"A", "minor-sixth", "m6", "", "", "unsupported chord kind 'minor-sixth'"},
+	}
+	for _, tt := range tests {
+		h := musicxml.Harmony{Root: musicxml.Pitch{Step: tt.root}, Kind: tt.kind, Text: tt.text}
+		chord, err := harmonyChord(a, h)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("harmonyChord(%s %s) error = %v, want %s", tt.root, tt.kind, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("harmonyChord(%s %s) returned error: %v", tt.root, tt.kind, err)
+			continue
+		}
+		if got := harmonySymbol(h, chord); got != tt.expected {
+			t.Errorf("harmonySymbol(%s %s) = %s, want %s", tt.root, tt.kind, got, tt.expected)
+		}
+		if got := theory.SliceToString(chord.Notes); got != tt.notes {
+			t.Errorf("harmonyChord(%s %s) notes = %s, want %s", tt.root, tt.kind, got, tt.notes)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// nashville.go
+// This file contains the "nashville" subcommand, which reads a chart of
+// Nashville numbers in a given key, and the --nashville output of keys and
+// batch, which writes a progression as numbers in its estimated key.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cordelia/theory"
+)
+
+func runNashvilleCommand(args []string) {
+	fs := newCommandFlagSet("nashville", "cordelia nashville --key <key> <number1> <number2> ...")
+	key := fs.String("key", "", "Key of the chart, as a chord name (\"G\", \"Em\") or a key name (\"G Major\").")
+	export := addExportFlags(fs)
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if export.enabled() {
+		if err := export.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			return
+		}
+	}
+	if *key == "" {
+		fmt.Fprintln(os.Stderr, "Error: --key is required.")
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No Nashville numbers provided.")
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	keyName, err := keyFromFlag(a, *key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Invalid key '%s': %v\n", *key, err)
+		exitCode = 1
+		return
+	}
+	tonic, minor, _ := theory.ParseKeyName(keyName)
+
+	fmt.Printf("Key: %s\n", notation.Localize(keyName))
+	var chords []progressionChord
+	var notes []theory.Note
+	for i, number := range fs.Args() {
+		symbol, err := a.ParseNashville(number, tonic, minor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+			return
+		}
+		c := progressionChordFromSymbol(notation.Localize(symbol.String()), symbol)
+		chords = append(chords, c)
+		notes = append(notes, c.Notes...)
+		fmt.Printf("[%d] %s -> %s (%s %s): %s\n", i+1, number, c.Symbol,
+			notation.NoteName(symbol.Root), symbol.Chord.Name, notation.SliceToString(c.Notes))
+	}
+
+	estimate := a.EstimateKeys(notes)
+	printKeyEstimation(os.Stdout, notation, estimate)
+	printKeyComparison(os.Stdout, notation, "Chart Key", keyName, estimate, tonic)
+
+	if export.enabled() {
+		export.write(a, chords)
+	}
+}
+
+// keyFromFlag reads a key written as a chord name ("G", "Em") or as a key
+// name ("G Major", "E minor") into a key name as used by the key estimate.
+func keyFromFlag(a *theory.Analyzer, value string) (string, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return keyFromChordName(a, value)
+	}
+	tonic, err := a.Notation().ParseNote(fields[0])
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(fields[1]) {
+	case "major":
+		return theory.NoteName(tonic.Value, true) + " Major", nil
+	case "minor":
+		return theory.NoteName(tonic.Value, false) + " Minor", nil
+	}
+	return "", fmt.Errorf("expected Major or Minor, got '%s'", fields[1])
+}
+
+// printNashvilleNumbers writes the chords as Nashville numbers in the key of
+// the progression, the one its cadences are given in. Chords whose notes
+// matched nothing are written as "?".
+func printNashvilleNumbers(w io.Writer, n theory.Notation, p keyedProgression) {
+	if len(p.symbols) == 0 {
+		return
+	}
+	if p.key == "" {
+		fmt.Fprintln(w, "\nNashville Numbers: no key found")
+		return
+	}
+	numbers := make([]string, len(p.symbols))
+	for i, symbol := range p.symbols {
+		if len(symbol.Chord.Intervals) == 0 {
+			numbers[i] = "?"
+			continue
+		}
+		numbers[i] = theory.ToNashville(symbol, p.tonic, p.minor)
+	}
+	fmt.Fprintf(w, "\nNashville Numbers (%s): %s\n", n.Localize(p.key), strings.Join(numbers, " "))
+}
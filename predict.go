@@ -0,0 +1,336 @@
+// predict.go
+// This file contains the "train" and "predict" subcommands, which build an
+// n-gram model of chord progressions as Roman numerals and use it to suggest
+// the next chord of a progression.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"cordelia/theory"
+)
+
+// defaultModelFile is where train writes and predict reads the model.
+const defaultModelFile = "cordelia-model.json"
+
+func runTrainCommand(args []string) {
+	fs := newCommandFlagSet("train",
+		"cordelia train [--order N] [--model file] <chords.txt> ...",
+		"cordelia train --batch [--order N] [--model file] <batch.txt> ...")
+	batch := fs.Bool("batch", false, "Read batch files of notes, one chord per line, instead of chord names.")
+	order := fs.Int("order", 3, "Count transitions after up to N-1 preceding chords.")
+	modelFile := fs.String("model", defaultModelFile, "File to write the model to.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No training files provided.")
+		exitCode = 1
+		return
+	}
+	if *order < 1 {
+		fmt.Fprintln(os.Stderr, "Error: --order must be at least 1.")
+		exitCode = 1
+		return
+	}
+	model, err := theory.NewChordModel(*order)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	chords, hasErrors := 0, false
+	for _, filename := range fs.Args() {
+		file, err := os.Open(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", filename)
+			exitCode = 1
+			return
+		}
+		var progressions [][]theory.ChordSymbol
+		if *batch {
+			progressions, err = readBatchProgressions(a, file, filename)
+		} else {
+			progressions, err = readChordProgressions(a, file, filename)
+		}
+		file.Close()
+		if err != nil {
+			hasErrors = true
+		}
+		for _, p := range progressions {
+			roman, err := progressionNumerals(a, p, "")
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error in %s: %v\n", filename, err)
+				hasErrors = true
+				continue
+			}
+			model.Train(roman.numerals, roman.minor)
+			chords += len(p)
+		}
+	}
+
+	file, err := os.Create(*modelFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not create %s: %v\n", *modelFile, err)
+		exitCode = 1
+		return
+	}
+	err = model.Write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *modelFile, err)
+		exitCode = 1
+		return
+	}
+	fmt.Printf("Trained on %s (%s) from %s.\n", plural(model.Progressions, "progression"), plural(chords, "chord"), plural(fs.NArg(), "file"))
+	fmt.Printf("Model written to %s (order %d).\n", *modelFile, model.Order)
+	if hasErrors {
+		exitCode = 2
+	}
+}
+
+func runPredictCommand(args []string) {
+	fs := newCommandFlagSet("predict", "cordelia predict [--model file] [--key <key>] [--limit N] <chord1> <chord2> ...")
+	modelFile := fs.String("model", defaultModelFile, "Model written by train.")
+	key := fs.String("key", "", "Key of the progression, as a chord name (\"G\", \"Em\") or a key name (\"G Major\"). Default: estimated.")
+	limit := fs.Int("limit", 5, "Show at most this many chords; 0 shows all.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if *limit < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --limit cannot be negative.")
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No chord names provided.")
+		exitCode = 1
+		return
+	}
+
+	file, err := os.Open(*modelFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File not found: %s\n", *modelFile)
+		exitCode = 1
+		return
+	}
+	model, err := theory.ReadChordModel(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	var symbols []theory.ChordSymbol
+	for _, name := range fs.Args() {
+		symbol, err := a.ParseChordSymbol(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+			exitCode = 1
+			return
+		}
+		symbols = append(symbols, symbol)
+	}
+	roman, err := progressionNumerals(a, symbols, *key)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	printProgressionKey(os.Stdout, notation, roman.key, roman.estimated)
+	fmt.Printf("Numerals: %s\n", strings.Join(roman.numerals, " "))
+
+	predictions, context := model.Predict(roman.numerals, roman.minor)
+	printPredictions(os.Stdout, a, roman, predictions, context, *limit)
+}
+
+// romanProgression is a progression written as Roman numerals in its key.
+type romanProgression struct {
+	key       string
+	estimated bool
+	tonic     theory.Note
+	minor     bool
+	numerals  []string
+}
+
+// progressionNumerals writes chords as Roman numerals in the key given by a
+// --key flag value or, without one, the key estimated as by progressionKey.
+// A single chord without a key is taken as the tonic of its own key, minor
+// when it has a minor third and no major third, and reported as estimated.
+func progressionNumerals(a *theory.Analyzer, symbols []theory.ChordSymbol, value string) (romanProgression, error) {
+	var p romanProgression
+	var err error
+	p.key, p.estimated, err = progressionKey(a, value, symbols)
+	if err != nil {
+		return p, err
+	}
+	if p.key != "" {
+		if p.tonic, p.minor, err = theory.ParseKeyName(p.key); err != nil {
+			return p, err
+		}
+	} else {
+		intervals := symbols[0].Chord.Intervals
+		p.tonic = symbols[0].Root
+		p.minor = slices.Contains(intervals, 3) && !slices.Contains(intervals, 4)
+		p.key, p.estimated = theory.NoteName(p.tonic.Value, theory.IsFlat(p.tonic))+" Major", true
+		if p.minor {
+			p.key = theory.NoteName(p.tonic.Value, theory.IsFlat(p.tonic)) + " Minor"
+		}
+	}
+	for _, s := range symbols {
+		p.numerals = append(p.numerals, theory.RomanNumeral(s, p.tonic, p.minor))
+	}
+	return p, nil
+}
+
+// readChordProgressions reads a file of chord names with one progression per
+// line, skipping blank lines. Lines with chords that do not parse are
+// reported and skipped, and the error returned is the last of them.
+func readChordProgressions(a *theory.Analyzer, r io.Reader, filename string) ([][]theory.ChordSymbol, error) {
+	var progressions [][]theory.ChordSymbol
+	var lastErr error
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+lines:
+	for scanner.Scan() {
+		lineNum++
+		var progression []theory.ChordSymbol
+		for _, name := range strings.Fields(scanner.Text()) {
+			symbol, err := a.ParseChordSymbol(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error on %s line %d: Could not parse chord name '%s': %v\n", filename, lineNum, name, err)
+				lastErr = err
+				continue lines
+			}
+			progression = append(progression, symbol)
+		}
+		if len(progression) > 0 {
+			progressions = append(progressions, progression)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", filename, err)
+		lastErr = err
+	}
+	return progressions, lastErr
+}
+
+// readBatchProgressions reads a batch file of notes with one chord per line,
+// named by its best match. Blank lines and lines without a match separate
+// progressions; lines with errors are reported and also separate them.
+func readBatchProgressions(a *theory.Analyzer, r io.Reader, filename string) ([][]theory.ChordSymbol, error) {
+	var progressions [][]theory.ChordSymbol
+	var progression []theory.ChordSymbol
+	var lastErr error
+	end := func() {
+		if len(progression) > 0 {
+			progressions = append(progressions, progression)
+		}
+		progression = nil
+	}
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			end()
+			continue
+		}
+		result := a.AnalyzeLine(lineNum, scanner.Text())
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error on %s line %d: %v\n", filename, lineNum, result.Err)
+			lastErr = result.Err
+			end()
+			continue
+		}
+		if len(result.Matches) == 0 {
+			end()
+			continue
+		}
+		symbol, err := a.ParseChordSymbol(result.Symbol(result.Matches[0]))
+		if err != nil {
+			end()
+			continue
+		}
+		progression = append(progression, symbol)
+	}
+	end()
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", filename, err)
+		lastErr = err
+	}
+	return progressions, lastErr
+}
+
+// printPredictions writes the context the model used and up to limit
+// predicted chords, spelled in the progression's key, with their
+// probabilities.
+func printPredictions(w io.Writer, a *theory.Analyzer, p romanProgression, predictions []theory.ChordPrediction, context []string, limit int) {
+	n := a.Notation()
+	if len(predictions) == 0 {
+		mode := "major"
+		if p.minor {
+			mode = "minor"
+		}
+		fmt.Fprintf(w, "No predictions: the model has no progressions in %s keys.\n", mode)
+		return
+	}
+	total := 0
+	for _, pr := range predictions {
+		total += pr.Count
+	}
+	if len(context) == 0 {
+		fmt.Fprintf(w, "Context: none (%s)\n", plural(total, "chord"))
+	} else {
+		fmt.Fprintf(w, "Context: %s (%s)\n", strings.Join(context, " "), plural(total, "continuation"))
+	}
+	fmt.Fprintln(w, "Predictions:")
+	shown := predictions
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, pr := range shown {
+		chord := "?"
+		if symbol, err := a.ParseRomanNumeral(pr.Numeral, p.tonic, p.minor); err == nil {
+			chord = n.Localize(symbol.String())
+		}
+		fmt.Fprintf(w, " %s (%s): %.1f%%\n", pr.Numeral, chord, 100*pr.Probability)
+	}
+	if len(shown) < len(predictions) {
+		fmt.Fprintf(w, " ... %d more (--limit 0 shows all)\n", len(predictions)-len(shown))
+	}
+}
+
+// plural writes a count with a noun, adding "s" unless the count is 1.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,201 @@
+// repl.go
+// This file contains the interactive shell used by "cordelia repl".
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"cordelia/theory"
+)
+
+const replPrompt = "cordelia> "
+
+const replHelp = `Enter notes (e.g. "C E G Bb") to identify a chord, or a single chord name
+(e.g. "F#m7") to spell it. Every entry is added to the running key estimate.
+
+Commands:
+  :keys               Show the key estimate for everything entered so far.
+  :inversions on|off  Treat each note as a potential root.
+  :verbose on|off     Show detailed matching logic.
+  :reset              Forget all entered notes.
+  :history            List previous entries; recall one with !<n> or !!.
+  :help               Show this message.
+  :quit               Leave the shell.`
+
+// runRepl parses the repl subcommand's flags and starts the shell on stdin.
+func runRepl(args []string) {
+	fs := newCommandFlagSet("repl", "cordelia repl [flags]")
+	inversions := fs.Bool("inversions", false, "Start with inversion detection enabled.")
+	verbose := fs.Bool("verbose", false, "Start with verbose matching output enabled.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+
+	r := newRepl(os.Stdin, os.Stdout)
+	r.analyzer = r.analyzer.WithInversions(*inversions)
+	r.verbose = *verbose
+	fmt.Fprintln(os.Stdout, "Cordelia interactive shell. Type :help for commands, :quit to exit.")
+	if err := r.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		exitCode = 1
+	}
+}
+
+// repl holds the settings and accumulated notes of an interactive session.
+type repl struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	analyzer *theory.Analyzer
+	verbose  bool
+	notes    []theory.Note
+	history  []string
+}
+
+func newRepl(in io.Reader, out io.Writer) *repl {
+	return &repl{in: bufio.NewScanner(in), out: out, analyzer: theory.NewAnalyzer(theory.Options{})}
+}
+
+// Run reads and evaluates lines until :quit or end of input.
+func (r *repl) Run() error {
+	for {
+		fmt.Fprint(r.out, replPrompt)
+		if !r.in.Scan() {
+			fmt.Fprintln(r.out)
+			return r.in.Err()
+		}
+		if !r.Eval(r.in.Text()) {
+			return nil
+		}
+	}
+}
+
+// Eval handles one line of input. It returns false when the session should end.
+func (r *repl) Eval(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+
+	if strings.HasPrefix(line, "!") {
+		recalled, err := r.recall(line)
+		if err != nil {
+			fmt.Fprintf(r.out, "Error: %v\n", err)
+			return true
+		}
+		fmt.Fprintln(r.out, recalled)
+		line = recalled
+	}
+	if strings.HasPrefix(line, ":") {
+		return r.command(line)
+	}
+
+	r.history = append(r.history, line)
+	fields := strings.Fields(strings.ReplaceAll(line, ",", " "))
+	if len(fields) == 1 {
+		r.spellChord(fields[0])
+	} else {
+		r.identify(fields)
+	}
+	return true
+}
+
+// recall resolves "!!" and "!<n>" to an entry from the history.
+func (r *repl) recall(line string) (string, error) {
+	if len(r.history) == 0 {
+		return "", errors.New("history is empty")
+	}
+	if line == "!!" {
+		return r.history[len(r.history)-1], nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(r.history) {
+		return "", fmt.Errorf("no history entry '%s'", line[1:])
+	}
+	return r.history[n-1], nil
+}
+
+func (r *repl) command(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case ":quit", ":exit", ":q":
+		return false
+	case ":help":
+		fmt.Fprintln(r.out, replHelp)
+	case ":keys":
+		if len(r.notes) == 0 {
+			fmt.Fprintln(r.out, "No notes entered yet.")
+		} else {
+			printKeyEstimation(r.out, r.analyzer.Notation(), r.analyzer.EstimateKeys(r.notes))
+		}
+	case ":reset":
+		r.notes = nil
+		fmt.Fprintln(r.out, "Cleared all entered notes.")
+	case ":history":
+		for i, entry := range r.history {
+			fmt.Fprintf(r.out, "%4d  %s\n", i+1, entry)
+		}
+	case ":inversions":
+		inversions := r.analyzer.Inversions()
+		r.setToggle(fields, &inversions)
+		r.analyzer = r.analyzer.WithInversions(inversions)
+	case ":verbose":
+		r.setToggle(fields, &r.verbose)
+	default:
+		fmt.Fprintf(r.out, "Error: Unknown command '%s'. Type :help for commands.\n", fields[0])
+	}
+	return true
+}
+
+// setToggle sets a boolean setting from an "on"/"off" argument, or reports it.
+func (r *repl) setToggle(fields []string, setting *bool) {
+	name := strings.TrimPrefix(fields[0], ":")
+	if len(fields) == 1 {
+		fmt.Fprintf(r.out, "%s is %s\n", name, onOff(*setting))
+		return
+	}
+	switch fields[1] {
+	case "on":
+		*setting = true
+	case "off":
+		*setting = false
+	default:
+		fmt.Fprintf(r.out, "Error: Expected 'on' or 'off', got '%s'.\n", fields[1])
+		return
+	}
+	fmt.Fprintf(r.out, "%s %s\n", name, onOff(*setting))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func (r *repl) identify(noteStrings []string) {
+	results, err := r.analyzer.IdentifyStrings(noteStrings)
+	if err != nil {
+		fmt.Fprintf(r.out, "Error: %v\n", err)
+		return
+	}
+	r.notes = append(r.notes, results[0].Notes...)
+	printIdentifications(r.out, r.analyzer, results, r.verbose)
+}
+
+func (r *repl) spellChord(name string) {
+	root, chordDef, err := r.analyzer.ParseChordName(name)
+	if err != nil {
+		fmt.Fprintf(r.out, "Error: Could not parse chord name '%s': %v\n", name, err)
+		return
+	}
+	r.notes = append(r.notes, theory.GenerateNotes(root, chordDef.Intervals)...)
+	printParsedChord(r.out, r.analyzer.Notation(), name, root, chordDef)
+}
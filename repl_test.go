@@ -0,0 +1,82 @@
+// repl_test.go
+// This file contains the tests for the interactive shell.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "Identify Notes",
+			input:    "C E G\n",
+			expected: []string{"Matched Chords:\n - C Major Triad"},
+		},
+		{
+			name:     "Spell Chord Name",
+			input:    "Am7\n",
+			expected: []string{"Quality: Minor 7th", "Notes: A C E G"},
+		},
+		{
+			name:     "Running Key Estimate",
+			input:    "C\nG\nAm\n:keys\n",
+			expected: []string{"C Major (6 matches)"},
+		},
+		{
+			name:     "Reset Clears Notes",
+			input:    "C E G\n:reset\n:keys\n",
+			expected: []string{"No notes entered yet."},
+		},
+		{
+			name:     "Inversions Toggle",
+			input:    ":inversions on\nE G C\n",
+			expected: []string{"inversions on", "Root: C\nIntervals: [0 4 7]"},
+		},
+		{
+			name:     "History Recall",
+			input:    "D F# A\n!1\n",
+			expected: []string{"D F# A\nInput Notes: D F# A"},
+		},
+		{
+			name:   "Quit Stops Evaluation",
+			input:  ":quit\nC E G\n",
+			absent: []string{"Matched Chords:"},
+		},
+		{
+			name:     "Errors Keep Session Alive",
+			input:    "C X\n:bogus\nC E G\n",
+			expected: []string{"Error: invalid note 'X' in input", "Error: Unknown command ':bogus'", "C Major Triad"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var out bytes.Buffer
+			if err := newRepl(strings.NewReader(tt.input), &out).Run(); err != nil {
+				t.Fatalf("Run() returned error: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected output to contain %q, got %q", want, out.String())
+				}
+			}
+			for _, unwanted := range tt.absent {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("Expected output not to contain %q, got %q", unwanted, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// scales.go
+// This file contains the "scales" subcommand, which ranks the scales on every
+// root that contain a collection of notes.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"cordelia/theory"
+)
+
+func runScalesCommand(args []string) {
+	fs := newCommandFlagSet("scales", "cordelia scales [--limit N] <note1> <note2> ...")
+	limit := fs.Int("limit", 10, "Show at most this many scales; 0 shows all.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if *limit < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --limit cannot be negative.")
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No notes provided.")
+		exitCode = 1
+		return
+	}
+
+	notes, err := notation.ParseNotes(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+	printScaleMatches(os.Stdout, notation, notes, theory.IdentifyScales(notes), *limit)
+}
+
+// printScaleMatches writes the input notes and up to limit scale matches,
+// marking each as exact or as a partial match with the notes it adds.
+func printScaleMatches(w io.Writer, n theory.Notation, notes []theory.Note, matches []theory.ScaleMatch, limit int) {
+	fmt.Fprintf(w, "Input Notes: %s\n", n.SliceToString(notes))
+	fmt.Fprintln(w, "Scales:")
+	shown := matches
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, m := range shown {
+		line := fmt.Sprintf(" %s %s: %s", n.NoteName(m.Root), m.Scale.Name, n.SliceToString(m.Notes))
+		if m.Exact {
+			line += " (exact)"
+		} else {
+			line += fmt.Sprintf(" (partial, adds %s)", n.SliceToString(m.Extra))
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(shown) < len(matches) {
+		fmt.Fprintf(w, " ... %d more (--limit 0 shows all)\n", len(matches)-len(shown))
+	}
+}
@@ -0,0 +1,344 @@
+// server.go
+// This file contains the HTTP JSON API used by "cordelia serve".
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"cordelia/theory"
+)
+
+// maxRequestBody caps the size of any request body accepted by the server.
+const maxRequestBody = 1 << 20
+
+// runServe parses the serve subcommand's flags and starts the HTTP server.
+func runServe(args []string) {
+	fs := newCommandFlagSet("serve", "cordelia serve [--addr :8080]")
+	addr := fs.String("addr", ":8080", "Address to listen on.")
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServerMux(theory.NewAnalyzer(theory.Options{})),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+	}
+}
+
+// apiServer serves the JSON endpoints from a shared Analyzer.
+type apiServer struct {
+	analyzer *theory.Analyzer
+}
+
+// newServerMux registers the API endpoints.
+func newServerMux(a *theory.Analyzer) *http.ServeMux {
+	s := &apiServer{analyzer: a}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identify", postOnly(s.handleIdentify))
+	mux.HandleFunc("/parse", s.handleParse)
+	mux.HandleFunc("/keys", postOnly(s.handleKeys))
+	mux.HandleFunc("/batch", postOnly(s.handleBatch))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("no endpoint at %s", r.URL.Path))
+	})
+	return mux
+}
+
+// --- Request & Response Types ---
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+type identifyRequest struct {
+	Notes      []string `json:"notes"`
+	Inversions bool     `json:"inversions"`
+}
+
+type matchJSON struct {
+	Name      string `json:"name"`
+	Chord     string `json:"chord"`
+	Intervals []int  `json:"intervals"`
+	Subset    bool   `json:"subset"`
+}
+
+type rootResultJSON struct {
+	Root      string      `json:"root"`
+	Intervals []int       `json:"intervals"`
+	Matches   []matchJSON `json:"matches"`
+}
+
+type identifyResponse struct {
+	Notes   []string         `json:"notes"`
+	Results []rootResultJSON `json:"results"`
+}
+
+type parseResponse struct {
+	Chord     string   `json:"chord"`
+	Root      string   `json:"root"`
+	Quality   string   `json:"quality"`
+	Intervals []int    `json:"intervals"`
+	Notes     []string `json:"notes"`
+}
+
+type keysRequest struct {
+	Chords []string `json:"chords"`
+	Notes  []string `json:"notes"`
+}
+
+type keyMatchJSON struct {
+	Name    string `json:"name"`
+	Matches int    `json:"matches"`
+}
+
+type keysResponse struct {
+	AggregatedNotes []string       `json:"aggregated_notes"`
+	Keys            []keyMatchJSON `json:"keys"`
+}
+
+type batchLineJSON struct {
+	Line      int         `json:"line"`
+	Input     string      `json:"input"`
+	Root      string      `json:"root,omitempty"`
+	Intervals []int       `json:"intervals,omitempty"`
+	Matches   []matchJSON `json:"matches,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Lines []batchLineJSON `json:"lines"`
+	Keys  *keysResponse   `json:"keys,omitempty"`
+}
+
+// --- Handlers ---
+
+func (s *apiServer) handleIdentify(w http.ResponseWriter, r *http.Request) {
+	var req identifyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	results, err := s.analyzer.WithInversions(req.Inversions).IdentifyStrings(req.Notes)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_notes", err.Error())
+		return
+	}
+
+	resp := identifyResponse{Notes: noteNames(results[0].Notes), Results: []rootResultJSON{}}
+	for _, id := range results {
+		resp.Results = append(resp.Results, rootResultJSON{
+			Root:      id.Root.Original,
+			Intervals: id.Intervals,
+			Matches:   matchesToJSON(id),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *apiServer) handleParse(w http.ResponseWriter, r *http.Request) {
+	var name string
+	switch r.Method {
+	case http.MethodGet:
+		name = r.URL.Query().Get("chord")
+	case http.MethodPost:
+		var req struct {
+			Chord string `json:"chord"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		name = req.Chord
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing_chord", "no chord name provided")
+		return
+	}
+
+	root, chordDef, err := s.analyzer.ParseChordName(name)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "invalid_chord", fmt.Sprintf("could not parse chord name '%s': %v", name, err))
+		return
+	}
+	writeJSON(w, http.StatusOK, parseResponse{
+		Chord:     name,
+		Root:      root.Original,
+		Quality:   chordDef.Name,
+		Intervals: chordDef.Intervals,
+		Notes:     noteNames(theory.GenerateNotes(root, chordDef.Intervals)),
+	})
+}
+
+func (s *apiServer) handleKeys(w http.ResponseWriter, r *http.Request) {
+	var req keysRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Chords) > 0 && len(req.Notes) > 0 {
+		writeError(w, http.StatusBadRequest, "ambiguous_input", "provide either chords or notes, not both")
+		return
+	}
+
+	var allNotes []theory.Note
+	switch {
+	case len(req.Chords) > 0:
+		notes, err := s.analyzer.ChordNotes(req.Chords)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_chord", err.Error())
+			return
+		}
+		allNotes = notes
+	case len(req.Notes) > 0:
+		notes, err := theory.ParseNotes(req.Notes)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_notes", err.Error())
+			return
+		}
+		allNotes = notes
+	default:
+		writeError(w, http.StatusBadRequest, "missing_input", "no chords or notes provided")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, estimateToJSON(s.analyzer.EstimateKeys(allNotes)))
+}
+
+// handleBatch analyses a plain-text body in the same format as a --batch file.
+// Key estimation is included when the "keys" query parameter is "true".
+func (s *apiServer) handleBatch(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, maxRequestBody)
+	result, err := s.analyzer.AnalyzeBatch(body)
+	if err != nil {
+		writeBodyError(w, "invalid_body", err)
+		return
+	}
+	if len(result.Lines) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_input", "request body is empty")
+		return
+	}
+
+	resp := batchResponse{Lines: []batchLineJSON{}}
+	for _, l := range result.Lines {
+		line := batchLineJSON{Line: l.LineNum, Input: l.Input}
+		if l.Err != nil {
+			line.Error = l.Err.Error()
+		} else {
+			line.Root = l.Root.Original
+			line.Intervals = l.Intervals
+			line.Matches = matchesToJSON(l.Identification)
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+
+	if r.URL.Query().Get("keys") == "true" {
+		keys := estimateToJSON(result.Keys)
+		resp.Keys = &keys
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// --- Helpers ---
+
+// postOnly rejects requests that do not use the POST method.
+func postOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+}
+
+// decodeJSON reads the request body into v, writing an error response on failure.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, "invalid_json", "request body is empty")
+		} else {
+			writeBodyError(w, "invalid_json", err)
+		}
+		return false
+	}
+	return true
+}
+
+// writeBodyError writes the error response for a request body that could not
+// be read: 413 when it is larger than maxRequestBody, otherwise 400 with code.
+func writeBodyError(w http.ResponseWriter, code string, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "body_too_large", fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, code, err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, errorResponse{Error: apiError{Code: code, Message: message}})
+}
+
+func noteNames(notes []theory.Note) []string {
+	names := make([]string, len(notes))
+	for i, n := range notes {
+		names[i] = n.Original
+	}
+	return names
+}
+
+func matchesToJSON(id theory.Identification) []matchJSON {
+	out := []matchJSON{}
+	for _, m := range id.Matches {
+		out = append(out, matchJSON{
+			Name:      m.Name,
+			Chord:     fmt.Sprintf("%s %s", id.Root.Original, m.Name),
+			Intervals: m.Intervals,
+			Subset:    id.IsSubset(m),
+		})
+	}
+	return out
+}
+
+func estimateToJSON(estimate theory.KeyEstimate) keysResponse {
+	resp := keysResponse{AggregatedNotes: noteNames(estimate.Notes), Keys: []keyMatchJSON{}}
+	for _, km := range estimate.Keys {
+		resp.Keys = append(resp.Keys, keyMatchJSON{Name: km.Name, Matches: km.MatchCount})
+	}
+	return resp
+}
@@ -0,0 +1,136 @@
+// server_test.go
+// This file contains the tests for the HTTP JSON API.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cordelia/theory"
+)
+
+func TestServerEndpoints(t *testing.T) {
+	t.Parallel()
+	mux := newServerMux(theory.NewAnalyzer(theory.Options{}))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Identify",
+			method:         http.MethodPost,
+			path:           "/identify",
+			body:           `{"notes": ["C", "E", "G"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"chord":"C Major Triad"`,
+		},
+		{
+			name:           "Identify Invalid Note",
+			method:         http.MethodPost,
+			path:           "/identify",
+			body:           `{"notes": ["C", "X"]}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":{"code":"invalid_notes","message":"invalid note 'X' in input"}}`,
+		},
+		{
+			name:           "Identify Wrong Method",
+			method:         http.MethodGet,
+			path:           "/identify",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `"code":"method_not_allowed"`,
+		},
+		{
+			name:           "Parse Query",
+			method:         http.MethodGet,
+			path:           "/parse?chord=Am7",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"chord":"Am7","root":"A","quality":"Minor 7th","intervals":[0,3,7,10],"notes":["A","C","E","G"]}`,
+		},
+		{
+			name:           "Parse Unknown Quality",
+			method:         http.MethodPost,
+			path:           "/parse",
+			body:           `{"chord": "Cmaj9"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `"code":"invalid_chord"`,
+		},
+		{
+			name:           "Keys From Chords",
+			method:         http.MethodPost,
+			path:           "/keys",
+			body:           `{"chords": ["C", "G", "Am"]}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"name":"C Major","matches":6}`,
+		},
+		{
+			name:           "Keys Malformed JSON",
+			method:         http.MethodPost,
+			path:           "/keys",
+			body:           `{"chords": [`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"code":"invalid_json"`,
+		},
+		{
+			name:           "Batch With Keys",
+			method:         http.MethodPost,
+			path:           "/batch?keys=true",
+			contentType:    "text/plain",
+			body:           "C G E\nX\nG D B\n",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"line":2,"input":"X","error":"invalid note 'X' in input"}`,
+		},
+		{
+			name:           "Batch Body Too Large",
+			method:         http.MethodPost,
+			path:           "/batch",
+			contentType:    "text/plain",
+			body:           strings.Repeat("C E G\n", maxRequestBody/6+1),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `"code":"body_too_large"`,
+		},
+		{
+			name:           "Identify Body Too Large",
+			method:         http.MethodPost,
+			path:           "/identify",
+			body:           `{"notes": ["C", "E", "G"], "padding": "` + strings.Repeat("x", maxRequestBody) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   `"code":"body_too_large"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType == "" {
+				tt.contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			resp := rec.Result()
+
+			var raw json.RawMessage
+			if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+				t.Fatalf("Response is not valid JSON: %v", err)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tt.expectedStatus, resp.StatusCode, raw)
+			}
+			if !strings.Contains(string(raw), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, raw)
+			}
+		})
+	}
+}
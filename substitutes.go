@@ -0,0 +1,100 @@
+// substitutes.go
+// This file contains the "substitutes" subcommand, which suggests chord
+// substitutions for reharmonizing a progression.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"cordelia/theory"
+)
+
+func runSubstitutesCommand(args []string) {
+	fs := newCommandFlagSet("substitutes", "cordelia substitutes [--key <key>] <chord1> <chord2> ...")
+	key := fs.String("key", "", "Key to judge substitutions in, as a chord name (\"G\", \"Em\") or a key name (\"G Major\"). Default: estimated from two or more chords.")
+	notationName := addNotationFlag(fs)
+	if !parseCommandFlags(fs, args) {
+		return
+	}
+	notation, err := notationFromFlag(*notationName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No chord names provided.")
+		exitCode = 1
+		return
+	}
+
+	a := theory.NewAnalyzer(theory.Options{Notation: notation})
+	var symbols []theory.ChordSymbol
+	for _, name := range fs.Args() {
+		symbol, err := a.ParseChordSymbol(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not parse chord name '%s': %v\n", name, err)
+			exitCode = 1
+			return
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	keyName, estimated, err := progressionKey(a, *key, symbols)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		exitCode = 1
+		return
+	}
+	printProgressionKey(os.Stdout, notation, keyName, estimated)
+
+	substitutions, err := a.Substitutions(symbols, keyName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exitCode = 1
+		return
+	}
+	for i, subs := range substitutions {
+		fmt.Printf("[%d] %s (%s %s):\n", i+1, fs.Arg(i), notation.NoteName(symbols[i].Root), symbols[i].Chord.Name)
+		printSubstitutions(os.Stdout, notation, subs, keyName != "")
+	}
+}
+
+// printSubstitutions writes one line per substitution with its chords, their
+// notes and, when judged in a key, how well they fit it.
+func printSubstitutions(w io.Writer, n theory.Notation, subs []theory.Substitution, hasKey bool) {
+	if len(subs) == 0 {
+		fmt.Fprintln(w, " No substitutions found.")
+		return
+	}
+	for _, s := range subs {
+		names := make([]string, len(s.Chords))
+		for i, c := range s.Chords {
+			names[i] = n.Localize(c.String())
+		}
+		notes := make([]string, len(s.Chords))
+		for i, chordNotes := range s.Notes {
+			notes[i] = n.SliceToString(chordNotes)
+		}
+		line := fmt.Sprintf(" %s: %s (%s)", s.Kind, strings.Join(names, " "), strings.Join(notes, " | "))
+		if hasKey {
+			line += "; " + keyFit(s.Outside)
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// keyFit describes how many notes of a suggestion are outside the key.
+func keyFit(outside int) string {
+	switch outside {
+	case 0:
+		return "diatonic"
+	case 1:
+		return "1 note outside the key"
+	}
+	return fmt.Sprintf("%d notes outside the key", outside)
+}
@@ -0,0 +1,404 @@
+package theory
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Options configures an Analyzer. Zero values select the built-in defaults.
+type Options struct {
+	// Dictionary is the list of chords to match against, in priority order.
+	Dictionary []Chord
+	// Keys is the set of keys considered by key estimation.
+	Keys []Key
+	// Inversions treats every input note as a potential root when identifying.
+	Inversions bool
+	// Notation is the naming system for parsing note and chord names.
+	// Parsed notes always carry English spellings; callers translate output
+	// with the Notation's methods.
+	Notation Notation
+}
+
+// Analyzer identifies chords and estimates keys. Its configuration is copied
+// at construction and never modified, so an Analyzer is safe for concurrent use.
+type Analyzer struct {
+	dictionary []Chord
+	keys       []Key
+	inversions bool
+	notation   Notation
+}
+
+// NewAnalyzer creates an Analyzer from the given options.
+func NewAnalyzer(opts Options) *Analyzer {
+	a := &Analyzer{inversions: opts.Inversions, notation: opts.Notation}
+	if opts.Dictionary != nil {
+		a.dictionary = copyDictionary(opts.Dictionary)
+	} else {
+		a.dictionary = chordDictionary
+	}
+	if opts.Keys != nil {
+		a.keys = copyKeys(opts.Keys)
+	} else {
+		a.keys = keySignatures
+	}
+	return a
+}
+
+// WithInversions returns an Analyzer sharing this one's dictionary and keys
+// with inversion detection set as given.
+func (a *Analyzer) WithInversions(inversions bool) *Analyzer {
+	clone := *a
+	clone.inversions = inversions
+	return &clone
+}
+
+// Inversions reports whether inversion detection is enabled.
+func (a *Analyzer) Inversions() bool {
+	return a.inversions
+}
+
+// Notation returns the naming system used for parsing.
+func (a *Analyzer) Notation() Notation {
+	return a.notation
+}
+
+// Dictionary returns a copy of the chords this Analyzer matches against.
+func (a *Analyzer) Dictionary() []Chord {
+	return copyDictionary(a.dictionary)
+}
+
+// --- Identification ---
+
+// Identification is the result of matching a set of notes against the
+// dictionary with one of the notes taken as root.
+type Identification struct {
+	Root      Note
+	Notes     []Note
+	Intervals []int
+	Matches   []Match
+}
+
+// IsSubset reports whether the input has more notes than the given match requires.
+func (id Identification) IsSubset(m Match) bool {
+	return len(id.Notes) > len(m.Intervals)
+}
+
+// Symbol writes a match as a chord symbol on this root, e.g. "Am7".
+func (id Identification) Symbol(m Match) string {
+	return id.Root.Original + m.Suffix
+}
+
+// MatchStrings formats each match as "<root> <name>", marking subset matches.
+func (id Identification) MatchStrings() []string {
+	var matchStrings []string
+	for _, m := range id.Matches {
+		matchStr := fmt.Sprintf("%s %s", id.Root.Original, m.Name)
+		if id.IsSubset(m) {
+			matchStr += " (subset)"
+		}
+		matchStrings = append(matchStrings, matchStr)
+	}
+	return matchStrings
+}
+
+// CheckResult records the outcome of checking one dictionary chord.
+type CheckResult struct {
+	Chord   Chord
+	Matched bool
+	Reason  string
+}
+
+// FindMatches returns every dictionary chord contained in the intervals.
+func (a *Analyzer) FindMatches(intervals []int) []Match {
+	return findMatches(a.dictionary, intervals)
+}
+
+// Explain checks the intervals against every dictionary chord, including the
+// reason for each failed check.
+func (a *Analyzer) Explain(intervals []int) []CheckResult {
+	intervalSet := intervalSetOf(intervals)
+	results := make([]CheckResult, len(a.dictionary))
+	for i, c := range a.dictionary {
+		matched, reason := c.Check(intervals, intervalSet)
+		results[i] = CheckResult{Chord: c, Matched: matched, Reason: reason}
+	}
+	return results
+}
+
+// IdentifyRoot matches the notes against the dictionary using the given root.
+func (a *Analyzer) IdentifyRoot(root Note, notes []Note) Identification {
+	intervals := CalculateIntervals(root, notes)
+	return Identification{
+		Root:      root,
+		Notes:     notes,
+		Intervals: intervals,
+		Matches:   a.FindMatches(intervals),
+	}
+}
+
+// Identify matches the notes using the first note as root, or every note in
+// turn when inversion detection is enabled.
+func (a *Analyzer) Identify(notes []Note) []Identification {
+	if len(notes) == 0 {
+		return nil
+	}
+	rootsToTest := []Note{notes[0]}
+	if a.inversions {
+		rootsToTest = notes
+	}
+
+	results := make([]Identification, 0, len(rootsToTest))
+	for _, root := range rootsToTest {
+		results = append(results, a.IdentifyRoot(root, notes))
+	}
+	return results
+}
+
+// IdentifyStrings parses note names and identifies them.
+func (a *Analyzer) IdentifyStrings(noteStrings []string) ([]Identification, error) {
+	notes, err := a.notation.ParseNotes(noteStrings)
+	if err != nil {
+		return nil, err
+	}
+	return a.Identify(notes), nil
+}
+
+// --- Chord Names ---
+
+// ParseChordName breaks a string like "F#m7" into a root note and a Chord
+// definition from this Analyzer's dictionary.
+func (a *Analyzer) ParseChordName(name string) (Note, Chord, error) {
+	return parseChordName(a.dictionary, a.notation, name)
+}
+
+// SplitSlashChord separates a slash chord such as "Am7/G" into the chord name
+// and its bass note, parsed in this Analyzer's notation.
+func (a *Analyzer) SplitSlashChord(name string) (string, *Note, error) {
+	return splitSlashChord(a.notation, name)
+}
+
+// ChordSymbol is a parsed chord name such as "Am7/G".
+type ChordSymbol struct {
+	Root   Note
+	Suffix string // the quality suffix as written, e.g. "min7"
+	Chord  Chord  // the dictionary chord the suffix names
+	Bass   *Note  // nil unless the name is a slash chord
+}
+
+// String writes the symbol with English note names.
+func (c ChordSymbol) String() string {
+	s := c.Root.Original + c.Suffix
+	if c.Bass != nil {
+		s += "/" + c.Bass.Original
+	}
+	return s
+}
+
+// ParseChordSymbol parses a chord name, with an optional slash bass, in this
+// Analyzer's notation.
+func (a *Analyzer) ParseChordSymbol(name string) (ChordSymbol, error) {
+	chordName, bass, err := a.SplitSlashChord(name)
+	if err != nil {
+		return ChordSymbol{}, err
+	}
+	root, suffix, chordDef, err := splitKnownChordName(a.dictionary, a.notation, chordName)
+	if err != nil {
+		return ChordSymbol{}, err
+	}
+	return ChordSymbol{Root: root, Suffix: suffix, Chord: chordDef, Bass: bass}, nil
+}
+
+// TransposeChordName moves the root of a chord name by the given number of
+// semitones, keeping the quality suffix exactly as written. The bass note of
+// a slash chord such as "C/G" is transposed along with the root. Names are
+// read and written in this Analyzer's notation.
+func (a *Analyzer) TransposeChordName(name string, semitones int, spelling Spelling) (string, error) {
+	chordName, bass, err := a.SplitSlashChord(name)
+	if err != nil {
+		return "", err
+	}
+	root, quality, chordDef, err := splitKnownChordName(a.dictionary, a.notation, chordName)
+	if err != nil {
+		return "", err
+	}
+	newRoot := Transpose(root, semitones, spelling)
+	transposed := a.notation.Name(newRoot.Original) + quality
+	if bass != nil {
+		// With automatic spelling, the bass takes the root's accidental, or
+		// the signature of the root's key when the root is natural, so that
+		// Bb/D up a semitone is B/D#, not B/Eb.
+		bassSpelling := spelling
+		if spelling == SpellingAuto {
+			set := intervalSetOf(chordDef.Intervals)
+			_, minorThird := set[3]
+			_, majorThird := set[4]
+			switch {
+			case IsFlat(newRoot):
+				bassSpelling = SpellingFlats
+			case strings.Contains(newRoot.Original, "#"):
+				bassSpelling = SpellingSharps
+			default:
+				bassSpelling = KeySpelling(newRoot.Value, minorThird && !majorThird)
+			}
+			if bassSpelling == SpellingAuto && IsFlat(root) {
+				bassSpelling = SpellingFlats
+			}
+		}
+		transposed += "/" + a.notation.Name(Transpose(*bass, semitones, bassSpelling).Original)
+	}
+	return transposed, nil
+}
+
+// ChordNameError reports a chord name that could not be parsed.
+type ChordNameError struct {
+	Name string
+	Err  error
+}
+
+func (e *ChordNameError) Error() string {
+	return fmt.Sprintf("could not parse chord name '%s': %v", e.Name, e.Err)
+}
+
+func (e *ChordNameError) Unwrap() error {
+	return e.Err
+}
+
+// ChordNotes parses each chord name and returns all of their generated notes,
+// including the bass of slash chords such as "C/Bb". The error is a
+// *ChordNameError for the first name that fails to parse.
+func (a *Analyzer) ChordNotes(names []string) ([]Note, error) {
+	var allNotes []Note
+	for _, name := range names {
+		symbol, err := a.ParseChordSymbol(name)
+		if err != nil {
+			return nil, &ChordNameError{Name: name, Err: err}
+		}
+		if symbol.Bass != nil {
+			allNotes = append(allNotes, *symbol.Bass)
+		}
+		allNotes = append(allNotes, GenerateNotes(symbol.Root, symbol.Chord.Intervals)...)
+	}
+	return allNotes, nil
+}
+
+// --- Key Estimation ---
+
+// KeyEstimate holds the aggregated pitch classes of an input and the keys
+// that contain them, best first.
+type KeyEstimate struct {
+	Notes []Note
+	Keys  []KeyMatch
+}
+
+// Best returns the key with the most matches. Ties go to the key whose tonic
+// comes earliest in tonics, such as the first and last roots of a progression,
+// and otherwise to the first key in ranking order. It reports false when no
+// key matched.
+func (e KeyEstimate) Best(tonics ...Note) (KeyMatch, bool) {
+	if len(e.Keys) == 0 {
+		return KeyMatch{}, false
+	}
+	top := e.Keys[0].MatchCount
+	for _, t := range tonics {
+		for _, k := range e.Keys {
+			if k.MatchCount != top {
+				break
+			}
+			if tonic, _, err := ParseKeyName(k.Name); err == nil && tonic.Value == t.Value {
+				return k, true
+			}
+		}
+	}
+	return e.Keys[0], true
+}
+
+// EstimateKeys aggregates the notes into sorted unique pitch classes and ranks
+// the configured keys against them.
+func (a *Analyzer) EstimateKeys(notes []Note) KeyEstimate {
+	uniqueNotes := Unique(notes)
+	sort.Slice(uniqueNotes, func(i, j int) bool {
+		return uniqueNotes[i].Value < uniqueNotes[j].Value
+	})
+	return KeyEstimate{Notes: uniqueNotes, Keys: estimate(a.keys, uniqueNotes)}
+}
+
+// EstimateKeysFromChordNames estimates keys from the notes of the named chords.
+func (a *Analyzer) EstimateKeysFromChordNames(names []string) (KeyEstimate, error) {
+	notes, err := a.ChordNotes(names)
+	if err != nil {
+		return KeyEstimate{}, err
+	}
+	return a.EstimateKeys(notes), nil
+}
+
+// --- Batch Analysis ---
+
+// LineResult holds the analysis of a single line of batch input. When Err is
+// set, only LineNum and Input are meaningful.
+type LineResult struct {
+	LineNum int
+	Input   string
+	Identification
+	Err error
+}
+
+// BatchResult holds every analysed line and the key estimate over all notes
+// from lines without errors.
+type BatchResult struct {
+	Lines []LineResult
+	Keys  KeyEstimate
+}
+
+// HasErrors reports whether any line failed to parse.
+func (b BatchResult) HasErrors() bool {
+	for _, l := range b.Lines {
+		if l.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeLine parses and identifies the whitespace-separated notes on one
+// batch line, always using the first note as root.
+func (a *Analyzer) AnalyzeLine(lineNum int, raw string) LineResult {
+	result := LineResult{LineNum: lineNum, Input: strings.TrimSpace(raw)}
+	if result.Input == "" {
+		result.Err = errors.New("No notes provided")
+		return result
+	}
+
+	notes, err := a.notation.ParseNotes(strings.Fields(result.Input))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Identification = a.IdentifyRoot(notes[0], notes)
+	return result
+}
+
+// AnalyzeBatch analyses every line of r and estimates keys over all of them.
+func (a *Analyzer) AnalyzeBatch(r io.Reader) (BatchResult, error) {
+	var result BatchResult
+	var allNotes []Note
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := a.AnalyzeLine(lineNum, scanner.Text())
+		if line.Err == nil {
+			allNotes = append(allNotes, line.Notes...)
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return BatchResult{}, err
+	}
+	result.Keys = a.EstimateKeys(allNotes)
+	return result, nil
+}
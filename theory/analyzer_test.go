@@ -0,0 +1,176 @@
+package theory
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzerIdentify(t *testing.T) {
+	t.Parallel()
+	notes, err := ParseNotes([]string{"E", "G", "C"})
+	if err != nil {
+		t.Fatalf("ParseNotes() returned error: %v", err)
+	}
+
+	results := NewAnalyzer(Options{}).Identify(notes)
+	if len(results) != 1 || results[0].Root.Original != "E" {
+		t.Fatalf("Expected a single result rooted on E, got %+v", results)
+	}
+
+	results = NewAnalyzer(Options{Inversions: true}).Identify(notes)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results with inversions, got %d", len(results))
+	}
+	got := results[2].MatchStrings()
+	if !reflect.DeepEqual(got, []string{"C Major Triad"}) {
+		t.Errorf("Expected C root to match [C Major Triad], got %v", got)
+	}
+}
+
+func TestAnalyzerCustomOptions(t *testing.T) {
+	t.Parallel()
+	dict := []Chord{{Name: "Power Chord", Suffixes: []string{"5"}, Intervals: []int{0, 7}}}
+	keys := []Key{{Name: "C Pentatonic", Notes: map[int]struct{}{0: {}, 2: {}, 4: {}, 7: {}, 9: {}}}}
+	a := NewAnalyzer(Options{Dictionary: dict, Keys: keys})
+
+	// Mutating the caller's options must not affect the Analyzer.
+	dict[0].Name = "Changed"
+	keys[0].Notes[1] = struct{}{}
+
+	root, chord, err := a.ParseChordName("G5")
+	if err != nil {
+		t.Fatalf("ParseChordName() returned error: %v", err)
+	}
+	if root.Value != 7 || chord.Name != "Power Chord" {
+		t.Errorf("Expected G Power Chord, got %s %s", root.Original, chord.Name)
+	}
+	if _, _, err := a.ParseChordName("Am"); err == nil {
+		t.Errorf("Expected Am to be unknown to a custom dictionary")
+	}
+
+	estimate := a.EstimateKeys(GenerateNotes(root, chord.Intervals))
+	if !reflect.DeepEqual(estimate.Keys, []KeyMatch{{Name: "C Pentatonic", MatchCount: 2}}) {
+		t.Errorf("Unexpected key estimate: %+v", estimate.Keys)
+	}
+	if _, err := NewAnalyzer(Options{Keys: keys}).EstimateKeysFromChordNames([]string{"C#"}); err != nil {
+		t.Fatalf("EstimateKeysFromChordNames() returned error: %v", err)
+	}
+}
+
+func TestAnalyzerChordNameError(t *testing.T) {
+	t.Parallel()
+	_, err := NewAnalyzer(Options{}).EstimateKeysFromChordNames([]string{"C", "Bm#9"})
+	var nameErr *ChordNameError
+	if !errors.As(err, &nameErr) {
+		t.Fatalf("Expected a *ChordNameError, got %v", err)
+	}
+	if nameErr.Name != "Bm#9" {
+		t.Errorf("Expected failing name Bm#9, got %s", nameErr.Name)
+	}
+}
+
+func TestTransposeChordName(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tests := []struct {
+		name      string
+		semitones int
+		expected  string
+	}{
+		{"Am7", 2, "Bm7"},
+		{"C/G", 2, "D/A"},
+		{"Bbmaj7/D", 1, "Bmaj7/D#"},
+		{"Bb/D", 1, "B/D#"},
+		{"A/C#", 1, "A#/D"},
+		{"Ebm/Gb", 2, "Fm/Ab"},
+		{"C#/E#", -1, "C/E"},
+		{"Db/F", -1, "C/E"},
+		{"Bb/F", 2, "C/G"},
+	}
+	for _, tt := range tests {
+		got, err := a.TransposeChordName(tt.name, tt.semitones, SpellingAuto)
+		if err != nil || got != tt.expected {
+			t.Errorf("TransposeChordName(%s, %d) = %q, %v; want %q", tt.name, tt.semitones, got, err, tt.expected)
+		}
+	}
+	if _, err := a.TransposeChordName("C/H", 2, SpellingAuto); err == nil {
+		t.Errorf("Expected an error for an invalid bass note")
+	}
+}
+
+func TestKeyEstimateBest(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	estimate, err := a.EstimateKeysFromChordNames([]string{"C", "G", "Am", "F"})
+	if err != nil {
+		t.Fatalf("EstimateKeysFromChordNames() returned error: %v", err)
+	}
+
+	// A Minor and C Major tie; alphabetical ranking puts A Minor first.
+	if best, _ := estimate.Best(); best.Name != "A Minor" {
+		t.Errorf("Expected A Minor without tonic hints, got %s", best.Name)
+	}
+	c, _ := ParseNote("C")
+	if best, _ := estimate.Best(c); best.Name != "C Major" {
+		t.Errorf("Expected the C tonic hint to select C Major, got %s", best.Name)
+	}
+	if _, ok := (KeyEstimate{}).Best(c); ok {
+		t.Errorf("Expected no best key for an empty estimate")
+	}
+
+	tonic, minor, err := ParseKeyName("F# Minor")
+	if err != nil || tonic.Value != 6 || !minor {
+		t.Errorf("ParseKeyName(\"F# Minor\") = %+v, %v, %v", tonic, minor, err)
+	}
+	if _, _, err := ParseKeyName("C Pentatonic"); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+	if got := KeySpelling(2, true); got != SpellingFlats {
+		t.Errorf("Expected D minor to use flats, got %v", got)
+	}
+	if got := KeySpelling(4, false); got != SpellingSharps {
+		t.Errorf("Expected E major to use sharps, got %v", got)
+	}
+}
+
+func TestAnalyzeBatch(t *testing.T) {
+	t.Parallel()
+	result, err := NewAnalyzer(Options{}).AnalyzeBatch(strings.NewReader("C G E\n\nD A F#\nX\n"))
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() returned error: %v", err)
+	}
+	if len(result.Lines) != 4 || !result.HasErrors() {
+		t.Fatalf("Expected 4 lines with errors, got %+v", result.Lines)
+	}
+	if got := result.Lines[2].MatchStrings(); !reflect.DeepEqual(got, []string{"D Major Triad"}) {
+		t.Errorf("Expected line 3 to match [D Major Triad], got %v", got)
+	}
+	if result.Lines[3].Err == nil || result.Lines[3].Err.Error() != "invalid note 'X' in input" {
+		t.Errorf("Expected invalid note error on line 4, got %v", result.Lines[3].Err)
+	}
+	if got := SliceToString(result.Keys.Notes); got != "C D E F# G A" {
+		t.Errorf("Expected aggregated notes C D E F# G A, got %s", got)
+	}
+}
+
+func TestAnalyzerConcurrentUse(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(inversions bool) {
+			defer wg.Done()
+			if _, err := a.WithInversions(inversions).IdentifyStrings([]string{"C", "E", "G", "Bb"}); err != nil {
+				t.Errorf("IdentifyStrings() returned error: %v", err)
+			}
+			if _, err := a.EstimateKeysFromChordNames([]string{"C", "G", "Am", "F"}); err != nil {
+				t.Errorf("EstimateKeysFromChordNames() returned error: %v", err)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,113 @@
+package theory
+
+// Cadence is a cadence between two consecutive chords of a progression, given
+// by their indexes.
+type Cadence struct {
+	Kind string
+	From int
+	To   int
+}
+
+// Cadence kinds.
+const (
+	CadencePerfectAuthentic   = "Perfect Authentic"
+	CadenceImperfectAuthentic = "Imperfect Authentic"
+	CadencePlagal             = "Plagal"
+	CadenceHalf               = "Half"
+	CadenceDeceptive          = "Deceptive"
+	CadencePhrygianHalf       = "Phrygian Half"
+)
+
+// DetectCadences finds the cadences of a progression in a major or natural
+// minor key, looking at each pair of consecutive chords:
+//
+//   - Authentic: a major or dominant chord on the 5th degree, or a
+//     diminished triad on the leading tone, resolving to the tonic chord. It
+//     is perfect when V and I are both in root position, and imperfect when
+//     either is inverted or the leading-tone chord stands for V.
+//   - Plagal: a major or minor chord on the 4th degree resolving to the tonic.
+//   - Deceptive: V moving to the chord on the 6th degree.
+//   - Phrygian Half: in a minor key, iv with the 6th degree in the bass
+//     moving to V.
+//   - Half: another chord moving to V, when V does not go on to the tonic,
+//     the 6th degree or another dominant chord.
+//
+// Chords without intervals, such as unidentified lines, take part in no
+// cadence. Cadences are returned in order of position.
+func DetectCadences(chords []ChordSymbol, tonic Note, minor bool) []Cadence {
+	steps := majorSteps
+	if minor {
+		steps = minorSteps
+	}
+	degree := func(c ChordSymbol) int {
+		return ((c.Root.Value-tonic.Value)%12 + 12) % 12
+	}
+	triad := func(c ChordSymbol) (major, minor, diminished bool) {
+		set := intervalSetOf(c.Chord.Intervals)
+		_, third := set[4]
+		_, flatThird := set[3]
+		_, fifth := set[7]
+		_, flatFifth := set[6]
+		return third && !flatThird && fifth, flatThird && !third && fifth, flatThird && flatFifth && !fifth
+	}
+	rootPosition := func(c ChordSymbol) bool {
+		return c.Bass == nil || c.Bass.Value == c.Root.Value
+	}
+	isDominant := func(c ChordSymbol) bool {
+		major, _, _ := triad(c)
+		_, majorSeventh := intervalSetOf(c.Chord.Intervals)[11]
+		return degree(c) == 7 && major && !majorSeventh
+	}
+	isTonic := func(c ChordSymbol) bool {
+		major, minor, _ := triad(c)
+		return degree(c) == 0 && (major || minor)
+	}
+	isLeadingTone := func(c ChordSymbol) bool {
+		_, _, diminished := triad(c)
+		return degree(c) == 11 && diminished
+	}
+	isSubdominant := func(c ChordSymbol) bool {
+		major, minor, _ := triad(c)
+		return degree(c) == 5 && (major || minor)
+	}
+	isSubmediant := func(c ChordSymbol) bool {
+		major, minor, _ := triad(c)
+		return degree(c) == steps[5] && (major || minor)
+	}
+
+	var cadences []Cadence
+	for i := 0; i+1 < len(chords); i++ {
+		from, to := chords[i], chords[i+1]
+		if len(from.Chord.Intervals) == 0 || len(to.Chord.Intervals) == 0 {
+			continue
+		}
+		kind := ""
+		switch {
+		case isDominant(from) && isTonic(to):
+			kind = CadenceImperfectAuthentic
+			if rootPosition(from) && rootPosition(to) {
+				kind = CadencePerfectAuthentic
+			}
+		case isLeadingTone(from) && isTonic(to):
+			kind = CadenceImperfectAuthentic
+		case isSubdominant(from) && isTonic(to):
+			kind = CadencePlagal
+		case isDominant(from) && isSubmediant(to):
+			kind = CadenceDeceptive
+		case isDominant(to) && !isDominant(from):
+			if i+2 < len(chords) && len(chords[i+2].Chord.Intervals) > 0 {
+				if next := chords[i+2]; isTonic(next) || isSubmediant(next) || isDominant(next) {
+					continue
+				}
+			}
+			kind = CadenceHalf
+			if _, minorTriad, _ := triad(from); minor && isSubdominant(from) && minorTriad && from.Bass != nil && from.Bass.Value == (tonic.Value+steps[5])%12 {
+				kind = CadencePhrygianHalf
+			}
+		}
+		if kind != "" {
+			cadences = append(cadences, Cadence{Kind: kind, From: i, To: i + 1})
+		}
+	}
+	return cadences
+}
@@ -0,0 +1,55 @@
+package theory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDetectCadences(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tests := []struct {
+		chords string
+		key    string
+		want   string
+	}{
+		{"C F G C", "C Major", "Perfect Authentic 3-4"},
+		{"C/E F G C/E", "C Major", "Imperfect Authentic 3-4"},
+		{"C Bdim C", "C Major", "Imperfect Authentic 2-3"},
+		{"C F C", "C Major", "Plagal 2-3"},
+		{"Am Dm Am", "A Minor", "Plagal 2-3"},
+		{"C Dm G7 Am", "C Major", "Deceptive 3-4"},
+		{"Am Dm E7 F", "A Minor", "Deceptive 3-4"},
+		{"C Am F G", "C Major", "Half 3-4"},
+		{"C G G7 C", "C Major", "Perfect Authentic 3-4"},
+		{"Am C Dm/F E", "A Minor", "Phrygian Half 3-4"},
+		{"C G/B Am Dm G7 C", "C Major", "Deceptive 2-3, Perfect Authentic 5-6"},
+		{"C Em Am Dm", "C Major", ""},
+	}
+	for _, tt := range tests {
+		var symbols []ChordSymbol
+		for _, name := range strings.Fields(tt.chords) {
+			symbol, err := a.ParseChordSymbol(name)
+			if err != nil {
+				t.Fatalf("ParseChordSymbol(%q) returned error: %v", name, err)
+			}
+			symbols = append(symbols, symbol)
+		}
+		tonic, minor, _ := ParseKeyName(tt.key)
+		var got []string
+		for _, c := range DetectCadences(symbols, tonic, minor) {
+			got = append(got, fmt.Sprintf("%s %d-%d", c.Kind, c.From+1, c.To+1))
+		}
+		if strings.Join(got, ", ") != tt.want {
+			t.Errorf("DetectCadences(%s in %s) = %q, want %q", tt.chords, tt.key, strings.Join(got, ", "), tt.want)
+		}
+	}
+
+	// Chords without intervals break the progression.
+	g7, _ := a.ParseChordSymbol("G7")
+	c, _ := a.ParseChordSymbol("C")
+	if got := DetectCadences([]ChordSymbol{g7, {}, c}, c.Root, false); len(got) != 0 {
+		t.Errorf("DetectCadences(G7 ? C) = %v, want none", got)
+	}
+}
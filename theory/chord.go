@@ -0,0 +1,226 @@
+package theory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Chord is a dictionary entry: a display name, the suffixes that spell it in
+// chord names, and its interval formula in semitones above the root.
+type Chord struct {
+	Name      string
+	Suffixes  []string // Suffixes used for parsing chord names, e.g., "m", "min"
+	Intervals []int
+}
+
+// Match is a dictionary chord whose formula is contained in an input.
+type Match struct {
+	Name      string
+	Suffix    string // Preferred suffix for writing the chord symbol, e.g. "m7"
+	Intervals []int
+}
+
+var chordDictionary = []Chord{
+	{Name: "Major 7th", Suffixes: []string{"maj7", "M7"}, Intervals: []int{0, 4, 7, 11}},
+	{Name: "Minor-Major 7th", Suffixes: []string{"m(maj7)"}, Intervals: []int{0, 3, 7, 11}},
+	{Name: "Minor 7th", Suffixes: []string{"m7", "min7"}, Intervals: []int{0, 3, 7, 10}},
+	{Name: "Dominant 7th", Suffixes: []string{"7", "dom7"}, Intervals: []int{0, 4, 7, 10}},
+	{Name: "Major Triad", Suffixes: []string{"", "M"}, Intervals: []int{0, 4, 7}},
+	{Name: "Minor Triad", Suffixes: []string{"m", "min"}, Intervals: []int{0, 3, 7}},
+	{Name: "Diminished Triad", Suffixes: []string{"dim"}, Intervals: []int{0, 3, 6}},
+	{Name: "Augmented Triad", Suffixes: []string{"aug", "+"}, Intervals: []int{0, 4, 8}},
+	{Name: "Sus2", Suffixes: []string{"sus2"}, Intervals: []int{0, 2, 7}},
+	{Name: "Sus4", Suffixes: []string{"sus4"}, Intervals: []int{0, 5, 7}},
+}
+
+// Suffix returns the preferred suffix for writing the chord's symbol.
+func (c Chord) Suffix() string {
+	if len(c.Suffixes) == 0 {
+		return ""
+	}
+	return c.Suffixes[0]
+}
+
+// DefaultDictionary returns a copy of the built-in chord dictionary.
+func DefaultDictionary() []Chord {
+	return copyDictionary(chordDictionary)
+}
+
+func copyDictionary(dict []Chord) []Chord {
+	out := make([]Chord, len(dict))
+	for i, c := range dict {
+		out[i] = Chord{
+			Name:      c.Name,
+			Suffixes:  append([]string(nil), c.Suffixes...),
+			Intervals: append([]int(nil), c.Intervals...),
+		}
+	}
+	return out
+}
+
+// ParseChordName breaks a string like "F#m7" into a root note and a Chord
+// definition from the built-in dictionary.
+func ParseChordName(name string) (Note, Chord, error) {
+	return parseChordName(chordDictionary, NotationEnglish, name)
+}
+
+func parseChordName(dict []Chord, notation Notation, name string) (Note, Chord, error) {
+	rootNote, _, chordDef, err := splitKnownChordName(dict, notation, name)
+	return rootNote, chordDef, err
+}
+
+// splitKnownChordName separates the root note of a chord name in the given
+// notation from its quality suffix, and looks the quality up in the
+// dictionary. In notations with several candidate roots, such as German
+// "Esus4", the longest root whose quality is known wins.
+func splitKnownChordName(dict []Chord, notation Notation, name string) (Note, string, Chord, error) {
+	if notation != NotationEnglish {
+		roots, qualities := notation.splitChordName(name)
+		if len(roots) == 0 {
+			return Note{}, "", Chord{}, fmt.Errorf("invalid root note in chord name")
+		}
+		var firstErr error
+		for i, root := range roots {
+			chordDef, err := lookupQuality(dict, qualities[i])
+			if err == nil {
+				return root, qualities[i], chordDef, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		return Note{}, "", Chord{}, firstErr
+	}
+
+	rootNote, quality, err := splitChordName(name)
+	if err != nil {
+		return Note{}, "", Chord{}, err
+	}
+
+	chordDef, err := lookupQuality(dict, quality)
+	if err != nil {
+		return Note{}, "", Chord{}, err
+	}
+	return rootNote, quality, chordDef, nil
+}
+
+// SplitSlashChord separates a slash chord such as "Am7/G" into the chord name
+// and its bass note. The bass is nil when the name has no slash.
+func SplitSlashChord(name string) (string, *Note, error) {
+	return splitSlashChord(NotationEnglish, name)
+}
+
+func splitSlashChord(notation Notation, name string) (string, *Note, error) {
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return name, nil, nil
+	}
+	bass, err := notation.ParseNote(name[i+1:])
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid bass note in chord name")
+	}
+	return name[:i], &bass, nil
+}
+
+// splitChordName separates the root note of a chord name from its quality suffix.
+func splitChordName(name string) (Note, string, error) {
+	// First, try to parse the longest possible note name (e.g., "C#", "Db").
+	var rootNote Note
+	var quality string
+	var err error
+
+	if len(name) > 1 {
+		// Check for two-character note names like "C#" or "Db"
+		if rootNote, err = ParseNote(name[:2]); err == nil {
+			quality = name[2:]
+		} else if rootNote, err = ParseNote(name[:1]); err == nil {
+			quality = name[1:]
+		} else {
+			return Note{}, "", fmt.Errorf("invalid root note in chord name")
+		}
+	} else {
+		if rootNote, err = ParseNote(name); err == nil {
+			quality = ""
+		} else {
+			return Note{}, "", fmt.Errorf("invalid root note in chord name")
+		}
+	}
+	return rootNote, quality, nil
+}
+
+// lookupQuality finds the chord definition that matches a quality suffix.
+func lookupQuality(dict []Chord, quality string) (Chord, error) {
+	for _, chordDef := range dict {
+		for _, suffix := range chordDef.Suffixes {
+			if quality == suffix {
+				return chordDef, nil
+			}
+		}
+	}
+	return Chord{}, fmt.Errorf("unknown chord quality: '%s'", quality)
+}
+
+// GenerateNotes builds the notes of a chord from its root and interval formula.
+func GenerateNotes(root Note, intervals []int) []Note {
+	notes := make([]Note, len(intervals))
+	for i, interval := range intervals {
+		noteValue := (root.Value + interval) % 12
+		// We don't have the "original" spelling, so we create a canonical one.
+		notes[i] = Note{Original: valueToName[noteValue], Value: noteValue}
+	}
+	return notes
+}
+
+// CalculateIntervals returns the sorted semitone distances of each note above the root.
+func CalculateIntervals(root Note, notes []Note) []int {
+	var intervals []int
+	for _, n := range notes {
+		interval := n.Value - root.Value
+		if interval < 0 {
+			interval += 12
+		}
+		intervals = append(intervals, interval)
+	}
+	sort.Ints(intervals)
+	return intervals
+}
+
+// Check reports whether the input contains every interval of the chord, and
+// if not, why.
+func (c Chord) Check(inputIntervals []int, inputSet map[int]struct{}) (bool, string) {
+	if len(inputIntervals) < len(c.Intervals) {
+		return false, fmt.Sprintf("requires %d intervals, input has %d", len(c.Intervals), len(inputIntervals))
+	}
+	for _, requiredInterval := range c.Intervals {
+		if _, ok := inputSet[requiredInterval]; !ok {
+			return false, fmt.Sprintf("missing interval %d", requiredInterval)
+		}
+	}
+	return true, ""
+}
+
+// FindMatches returns every chord in the built-in dictionary contained in the intervals.
+func FindMatches(intervals []int) []Match {
+	return findMatches(chordDictionary, intervals)
+}
+
+func findMatches(dict []Chord, intervals []int) []Match {
+	var matches []Match
+	intervalSet := intervalSetOf(intervals)
+
+	for _, chordDef := range dict {
+		if ok, _ := chordDef.Check(intervals, intervalSet); ok {
+			matches = append(matches, Match{Name: chordDef.Name, Suffix: chordDef.Suffix(), Intervals: chordDef.Intervals})
+		}
+	}
+	return matches
+}
+
+func intervalSetOf(intervals []int) map[int]struct{} {
+	intervalSet := make(map[int]struct{})
+	for _, i := range intervals {
+		intervalSet[i] = struct{}{}
+	}
+	return intervalSet
+}
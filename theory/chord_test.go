@@ -0,0 +1,68 @@
+package theory
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseChordName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input         string
+		expectedRoot  string
+		expectedChord string
+		expectError   bool
+	}{
+		{"C", "C", "Major Triad", false},
+		{"Am", "A", "Minor Triad", false},
+		{"F#m7", "F#", "Minor 7th", false},
+		{"Bb7", "Bb", "Dominant 7th", false},
+		{"Gaug", "G", "Augmented Triad", false},
+		{"H", "", "", true},
+		{"Cmaj9", "", "", true}, // maj9 is not in our dictionary
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+			root, chord, err := ParseChordName(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected an error, but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Got unexpected error: %v", err)
+			}
+			if root.Original != tt.expectedRoot {
+				t.Errorf("Expected root %s, got %s", tt.expectedRoot, root.Original)
+			}
+			if chord.Name != tt.expectedChord {
+				t.Errorf("Expected chord name %s, got %s", tt.expectedChord, chord.Name)
+			}
+		})
+	}
+}
+
+func TestGenerateNotes(t *testing.T) {
+	t.Parallel()
+	root := Note{Original: "A", Value: 9}
+	intervals := []int{0, 3, 7} // Minor Triad
+	expectedNotes := []Note{
+		{Original: "A", Value: 9},
+		{Original: "C", Value: 0},
+		{Original: "E", Value: 4},
+	}
+
+	got := GenerateNotes(root, intervals)
+	// Sort for comparison
+	sort.Slice(got, func(i, j int) bool { return got[i].Value < got[j].Value })
+	sort.Slice(expectedNotes, func(i, j int) bool { return expectedNotes[i].Value < expectedNotes[j].Value })
+
+	if !reflect.DeepEqual(got, expectedNotes) {
+		t.Errorf("GenerateNotes() got = %v, want %v", got, expectedNotes)
+	}
+}
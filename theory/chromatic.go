@@ -0,0 +1,187 @@
+package theory
+
+// ChromaticChord explains a chord of a progression that is not diatonic to
+// its key. Index is the chord's position in the progression and Numeral its
+// label, such as "V7/V" or "bVI". For a chromatic mediant, Related is the
+// index of the chord it is a mediant of, or -1 for the tonic.
+type ChromaticChord struct {
+	Index   int
+	Kind    string
+	Numeral string
+	Related int
+}
+
+// Chromatic chord kinds.
+const (
+	ChromaticSecondaryDominant    = "Secondary Dominant"
+	ChromaticSecondaryLeadingTone = "Secondary Leading-Tone"
+	ChromaticBorrowed             = "Borrowed"
+	ChromaticMediant              = "Chromatic Mediant"
+)
+
+// AnalyzeChromaticChords labels the chords of a progression whose notes are
+// not all in a major or minor key. Minor keys take the natural minor scale
+// with its leading tone, so V and vii° are diatonic. Each chord gets the
+// first label that applies:
+//
+//   - Secondary Dominant: a major triad or dominant 7th a fifth above a
+//     major or minor diatonic triad other than the tonic, followed by a chord
+//     on that root (D7 -> G is V7/V in C major).
+//   - Secondary Leading-Tone: a diminished chord a semitone below such a
+//     triad, followed by a chord on its root (C#dim -> Dm is vii°/ii).
+//   - Borrowed: a chord whose notes are all in the parallel key, such as iv,
+//     bVI and bVII in a major key or IV in a minor key.
+//   - Chromatic Mediant: a major or minor triad a third from the previous
+//     chord, or from the tonic for the first chord, with the same quality
+//     and one common tone (C -> E or C -> Ab).
+//   - Secondary Dominant or Secondary Leading-Tone as above without the
+//     resolution.
+//
+// Chords without intervals and an opening or closing chord on the tonic are
+// skipped, and chromatic chords that fit none of these are not returned.
+func AnalyzeChromaticChords(chords []ChordSymbol, tonic Note, minor bool) []ChromaticChord {
+	steps, parallelSteps := majorSteps, minorSteps
+	if minor {
+		steps, parallelSteps = minorSteps, majorSteps
+	}
+	scale := make(map[int]bool)
+	parallel := make(map[int]bool)
+	for i := range steps {
+		scale[(tonic.Value+steps[i])%12] = true
+		parallel[(tonic.Value+parallelSteps[i])%12] = true
+	}
+	if minor {
+		scale[(tonic.Value+11)%12] = true
+	}
+	within := func(c ChordSymbol, set map[int]bool) bool {
+		for _, i := range c.Chord.Intervals {
+			if !set[(c.Root.Value+i)%12] {
+				return false
+			}
+		}
+		return c.Bass == nil || set[c.Bass.Value]
+	}
+
+	// targets maps the roots of the major and minor diatonic triads other
+	// than the tonic to their triads.
+	targets := make(map[int]ChordSymbol)
+	for degree := 1; degree < 7; degree++ {
+		intervals := make([]int, 3)
+		for i := range intervals {
+			intervals[i] = ((steps[(degree+2*i)%7] - steps[degree]) + 12) % 12
+		}
+		set := intervalSetOf(intervals)
+		if _, fifth := set[7]; !fifth {
+			continue
+		}
+		value := (tonic.Value + steps[degree]) % 12
+		targets[value] = ChordSymbol{Root: Note{Value: value}, Chord: Chord{Intervals: intervals}}
+	}
+
+	// secondary labels a chord as a secondary dominant or leading-tone
+	// chord of the triad it leads to, if any.
+	secondary := func(c ChordSymbol) (ChromaticChord, bool) {
+		set := intervalSetOf(c.Chord.Intervals)
+		_, third := set[4]
+		_, flatThird := set[3]
+		_, fifth := set[7]
+		_, flatFifth := set[6]
+		_, majorSeventh := set[11]
+		var kind string
+		var targetValue int
+		switch {
+		case third && !flatThird && fifth && !majorSeventh:
+			kind, targetValue = ChromaticSecondaryDominant, (c.Root.Value+5)%12
+		case flatThird && flatFifth && !fifth:
+			kind, targetValue = ChromaticSecondaryLeadingTone, (c.Root.Value+1)%12
+		default:
+			return ChromaticChord{}, false
+		}
+		target, ok := targets[targetValue]
+		if !ok {
+			return ChromaticChord{}, false
+		}
+		numeral := RomanNumeral(c, target.Root, false) + "/" + RomanNumeral(target, tonic, minor)
+		return ChromaticChord{Kind: kind, Numeral: numeral, Related: -1}, true
+	}
+
+	// mediant reports whether two chords are major or minor triads of the
+	// same quality a third apart with one common tone.
+	mediant := func(c, ref ChordSymbol) bool {
+		quality := func(s ChordSymbol) (int, bool) {
+			set := intervalSetOf(s.Chord.Intervals)
+			_, third := set[4]
+			_, flatThird := set[3]
+			_, fifth := set[7]
+			switch {
+			case third && !flatThird && fifth:
+				return 4, true
+			case flatThird && !third && fifth:
+				return 3, true
+			}
+			return 0, false
+		}
+		q1, ok1 := quality(c)
+		q2, ok2 := quality(ref)
+		if !ok1 || !ok2 || q1 != q2 {
+			return false
+		}
+		switch (c.Root.Value - ref.Root.Value + 12) % 12 {
+		case 3, 4, 8, 9:
+		default:
+			return false
+		}
+		common := 0
+		for _, a := range []int{0, q1, 7} {
+			for _, b := range []int{0, q2, 7} {
+				if (c.Root.Value+a)%12 == (ref.Root.Value+b)%12 {
+					common++
+				}
+			}
+		}
+		return common == 1
+	}
+
+	tonicTriad := ChordSymbol{Root: tonic, Chord: Chord{Intervals: majorTriadIntervals}}
+	if minor {
+		tonicTriad.Chord.Intervals = minorTriadIntervals
+	}
+
+	var result []ChromaticChord
+	for i, c := range chords {
+		if len(c.Chord.Intervals) == 0 || within(c, scale) {
+			continue
+		}
+		if (i == 0 || i == len(chords)-1) && c.Root.Value == tonic.Value {
+			continue
+		}
+		sec, isSecondary := secondary(c)
+		resolves := isSecondary && i+1 < len(chords) && len(chords[i+1].Chord.Intervals) > 0
+		if resolves {
+			next := chords[i+1].Root.Value
+			if sec.Kind == ChromaticSecondaryDominant {
+				resolves = next == (c.Root.Value+5)%12
+			} else {
+				resolves = next == (c.Root.Value+1)%12
+			}
+		}
+
+		ref, related := tonicTriad, -1
+		if i > 0 && len(chords[i-1].Chord.Intervals) > 0 {
+			ref, related = chords[i-1], i-1
+		}
+		switch {
+		case resolves:
+		case within(c, parallel):
+			sec = ChromaticChord{Kind: ChromaticBorrowed, Numeral: RomanNumeral(c, tonic, minor), Related: -1}
+		case mediant(c, ref):
+			sec = ChromaticChord{Kind: ChromaticMediant, Numeral: RomanNumeral(c, tonic, minor), Related: related}
+		case isSecondary:
+		default:
+			continue
+		}
+		sec.Index = i
+		result = append(result, sec)
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package theory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeChromaticChords(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tests := []struct {
+		chords string
+		key    string
+		want   string
+	}{
+		{"C D7 G C", "C Major", "2 Secondary Dominant V7/V"},
+		{"C E7 Am", "C Major", "2 Secondary Dominant V7/vi"},
+		{"C A7/C# Dm", "C Major", "2 Secondary Dominant V7/ii"},
+		{"C C#dim Dm", "C Major", "2 Secondary Leading-Tone vii°/ii"},
+		{"C F#dim G", "C Major", "2 Secondary Leading-Tone vii°/V"},
+		{"C Fm C", "C Major", "2 Borrowed iv"},
+		{"C Ab Bb C", "C Major", "2 Borrowed bVI, 3 Borrowed bVII"},
+		{"C Ab Bb C", "C Minor", ""},
+		{"Am D Am", "A Minor", "2 Borrowed IV"},
+		{"C E F", "C Major", "2 Chromatic Mediant III"},
+		{"E C", "C Major", "1 Chromatic Mediant III"},
+		{"C D7 C", "C Major", "2 Secondary Dominant V7/V"},
+		{"Am E7 Am G C", "A Minor", ""},
+		{"C F#7 C", "C Major", ""},
+	}
+	for _, tt := range tests {
+		var symbols []ChordSymbol
+		for _, name := range strings.Fields(tt.chords) {
+			symbol, err := a.ParseChordSymbol(name)
+			if err != nil {
+				t.Fatalf("ParseChordSymbol(%q) returned error: %v", name, err)
+			}
+			symbols = append(symbols, symbol)
+		}
+		tonic, minor, _ := ParseKeyName(tt.key)
+		var got []string
+		for _, c := range AnalyzeChromaticChords(symbols, tonic, minor) {
+			got = append(got, fmt.Sprintf("%d %s %s", c.Index+1, c.Kind, c.Numeral))
+		}
+		if strings.Join(got, ", ") != tt.want {
+			t.Errorf("AnalyzeChromaticChords(%s in %s) = %q, want %q", tt.chords, tt.key, strings.Join(got, ", "), tt.want)
+		}
+	}
+}
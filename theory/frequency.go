@@ -0,0 +1,68 @@
+package theory
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FrequencyNote is a frequency mapped to the nearest note of twelve-tone
+// equal temperament.
+type FrequencyNote struct {
+	Frequency float64
+	Note      Note    // named with sharps
+	Octave    int     // scientific pitch notation: middle C is C4
+	Cents     float64 // deviation from the note, from -50 to +50
+}
+
+// Name returns the note with its octave, e.g. "C#4".
+func (f FrequencyNote) Name() string {
+	return fmt.Sprintf("%s%d", f.Note.Original, f.Octave)
+}
+
+// Neighbor returns the name of the adjacent note the frequency leans
+// towards, e.g. "D#4" for D4 at +40 cents.
+func (f FrequencyNote) Neighbor() string {
+	key := 12*(f.Octave+1) + f.Note.Value + 1
+	if f.Cents < 0 {
+		key -= 2
+	}
+	return fmt.Sprintf("%s%d", NoteName(key%12, false), key/12-1)
+}
+
+// NoteFromFrequency maps a frequency in Hz to the nearest note, with A4
+// tuned to a4 Hz.
+func NoteFromFrequency(freq, a4 float64) (FrequencyNote, error) {
+	if a4 <= 0 || math.IsInf(a4, 0) || math.IsNaN(a4) {
+		return FrequencyNote{}, fmt.Errorf("invalid A4 reference %g Hz", a4)
+	}
+	if freq <= 0 || math.IsInf(freq, 0) || math.IsNaN(freq) {
+		return FrequencyNote{}, fmt.Errorf("invalid frequency %g Hz", freq)
+	}
+	semitones := 69 + 12*math.Log2(freq/a4)
+	key := int(math.Round(semitones))
+	if key < 0 || key > 127 {
+		return FrequencyNote{}, fmt.Errorf("frequency %g Hz is outside the MIDI note range", freq)
+	}
+	pc := key % 12
+	return FrequencyNote{
+		Frequency: freq,
+		Note:      Note{Original: NoteName(pc, false), Value: pc},
+		Octave:    key/12 - 1,
+		Cents:     100 * (semitones - float64(key)),
+	}, nil
+}
+
+// ParseFrequency parses a frequency such as "261.6" or "261.6Hz".
+func ParseFrequency(s string) (float64, error) {
+	trimmed := strings.TrimSpace(s)
+	if lower := strings.ToLower(trimmed); strings.HasSuffix(lower, "hz") {
+		trimmed = strings.TrimSpace(trimmed[:len(trimmed)-2])
+	}
+	freq, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || freq <= 0 || math.IsInf(freq, 0) {
+		return 0, fmt.Errorf("invalid frequency '%s'", s)
+	}
+	return freq, nil
+}
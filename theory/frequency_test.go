@@ -0,0 +1,56 @@
+package theory
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoteFromFrequency(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		freq     float64
+		a4       float64
+		name     string
+		cents    float64
+		neighbor string
+	}{
+		{440, 440, "A4", 0, "A#4"},
+		{261.63, 440, "C4", 0, "C#4"},
+		{300, 440, "D4", 36.95, "D#4"},
+		{27.5, 440, "A0", 0, "A#0"},
+		{432, 432, "A4", 0, "A#4"},
+		{440, 432, "A4", 31.77, "A#4"},
+		{254, 440, "B3", 48.79, "C4"},
+		{270, 440, "C#4", -45.45, "C4"},
+	}
+	for _, tt := range tests {
+		fn, err := NoteFromFrequency(tt.freq, tt.a4)
+		if err != nil {
+			t.Fatalf("NoteFromFrequency(%v, %v) returned error: %v", tt.freq, tt.a4, err)
+		}
+		if fn.Name() != tt.name || math.Abs(fn.Cents-tt.cents) > 0.05 || fn.Neighbor() != tt.neighbor {
+			t.Errorf("NoteFromFrequency(%v, %v) = %s %+.2f cents (next to %s), want %s %+.2f cents (next to %s)",
+				tt.freq, tt.a4, fn.Name(), fn.Cents, fn.Neighbor(), tt.name, tt.cents, tt.neighbor)
+		}
+	}
+
+	for _, freq := range []float64{0, -1, 1, 20000} {
+		if _, err := NoteFromFrequency(freq, 440); err == nil {
+			t.Errorf("NoteFromFrequency(%v, 440): expected an error", freq)
+		}
+	}
+}
+
+func TestParseFrequency(t *testing.T) {
+	t.Parallel()
+	for input, expected := range map[string]float64{"261.6": 261.6, "440Hz": 440, " 392 hz": 392} {
+		if got, err := ParseFrequency(input); err != nil || got != expected {
+			t.Errorf("ParseFrequency(%q) = %v, %v; want %v", input, got, err, expected)
+		}
+	}
+	for _, input := range []string{"", "Hz", "C4", "-440", "0"} {
+		if _, err := ParseFrequency(input); err == nil {
+			t.Errorf("ParseFrequency(%q): expected an error", input)
+		}
+	}
+}
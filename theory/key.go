@@ -0,0 +1,130 @@
+package theory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Key is a named scale, stored as the set of pitch classes it contains.
+type Key struct {
+	Name  string
+	Notes map[int]struct{}
+}
+
+// KeyMatch is a key together with how many input pitch classes it contains.
+type KeyMatch struct {
+	Name       string
+	MatchCount int
+}
+
+var keySignatures = buildKeySignatures()
+
+// buildKeySignatures creates the 12 major and 12 natural minor keys.
+func buildKeySignatures() []Key {
+	noteNames := []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNames := []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+	majorPattern := []int{0, 2, 4, 5, 7, 9, 11}
+	minorPattern := []int{0, 2, 3, 5, 7, 8, 10}
+
+	var keys []Key
+	for i := 0; i < 12; i++ {
+		majorNotes := make(map[int]struct{})
+		for _, interval := range majorPattern {
+			majorNotes[(i+interval)%12] = struct{}{}
+		}
+		keys = append(keys, Key{Name: flatNames[i] + " Major", Notes: majorNotes})
+
+		minorNotes := make(map[int]struct{})
+		for _, interval := range minorPattern {
+			minorNotes[(i+interval)%12] = struct{}{}
+		}
+		keys = append(keys, Key{Name: noteNames[i] + " Minor", Notes: minorNotes})
+	}
+	return keys
+}
+
+// DefaultKeys returns a copy of the built-in 24 major and natural minor keys.
+func DefaultKeys() []Key {
+	return copyKeys(keySignatures)
+}
+
+func copyKeys(keys []Key) []Key {
+	out := make([]Key, len(keys))
+	for i, k := range keys {
+		notes := make(map[int]struct{}, len(k.Notes))
+		for v := range k.Notes {
+			notes[v] = struct{}{}
+		}
+		out[i] = Key{Name: k.Name, Notes: notes}
+	}
+	return out
+}
+
+// Estimate ranks the built-in keys by how many of the notes they contain.
+func Estimate(notes []Note) []KeyMatch {
+	return estimate(keySignatures, notes)
+}
+
+func estimate(keys []Key, notes []Note) []KeyMatch {
+	if len(notes) == 0 {
+		return nil
+	}
+	var matches []KeyMatch
+	uniqueNotes := Unique(notes) // Ensure we only count each pitch class once
+	for _, keySig := range keys {
+		count := 0
+		for _, n := range uniqueNotes {
+			if _, ok := keySig.Notes[n.Value]; ok {
+				count++
+			}
+		}
+		if count > 0 {
+			matches = append(matches, KeyMatch{Name: keySig.Name, MatchCount: count})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].MatchCount != matches[j].MatchCount {
+			return matches[i].MatchCount > matches[j].MatchCount
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
+
+// ParseKeyName splits a key name such as "Bb Major" or "F# Minor" into its
+// tonic and whether the key is minor.
+func ParseKeyName(name string) (Note, bool, error) {
+	fields := strings.Fields(name)
+	if len(fields) != 2 {
+		return Note{}, false, fmt.Errorf("invalid key name '%s'", name)
+	}
+	tonic, err := ParseNote(fields[0])
+	if err != nil {
+		return Note{}, false, fmt.Errorf("invalid tonic in key name '%s'", name)
+	}
+	switch strings.ToLower(fields[1]) {
+	case "major":
+		return tonic, false, nil
+	case "minor":
+		return tonic, true, nil
+	}
+	return Note{}, false, fmt.Errorf("invalid mode in key name '%s'", name)
+}
+
+// KeySpelling returns the spelling of a major or minor key's signature:
+// SpellingFlats for keys with flats (including Gb major), SpellingSharps for
+// keys with sharps, and SpellingAuto for C major and A minor.
+func KeySpelling(tonic int, minor bool) Spelling {
+	relativeMajor := tonic
+	if minor {
+		relativeMajor += 3
+	}
+	switch ((relativeMajor % 12) + 12) % 12 {
+	case 0:
+		return SpellingAuto
+	case 1, 3, 5, 6, 8, 10:
+		return SpellingFlats
+	}
+	return SpellingSharps
+}
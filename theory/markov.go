@@ -0,0 +1,189 @@
+package theory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// romanNumerals are the degrees 1 to 7 as upper-case Roman numerals.
+var romanNumerals = [7]string{"I", "II", "III", "IV", "V", "VI", "VII"}
+
+// RomanNumeral writes a chord symbol as a Roman numeral in a major or
+// natural minor key: Dm7 in C major is "ii7", Bb is "bVII" and Bdim is
+// "vii°". Chords with a minor third and no major third get a lower-case
+// numeral without the "m" of their suffix; diminished chords are marked
+// "°" and augmented ones "+". The slash bass is left out.
+func RomanNumeral(symbol ChordSymbol, tonic Note, minor bool) string {
+	degree := NashvilleDegree(symbol.Root.Value, tonic, minor)
+	accidental := strings.TrimRight(degree, "1234567")
+	numeral := romanNumerals[degree[len(accidental)]-'1']
+
+	suffix := symbol.Chord.Suffix()
+	set := intervalSetOf(symbol.Chord.Intervals)
+	_, hasMinorThird := set[3]
+	_, hasMajorThird := set[4]
+	if hasMinorThird && !hasMajorThird {
+		numeral = strings.ToLower(numeral)
+		switch {
+		case strings.HasPrefix(suffix, "dim"):
+			suffix = "°" + strings.TrimPrefix(suffix, "dim")
+		case strings.HasPrefix(suffix, "m") && !strings.HasPrefix(suffix, "maj"):
+			suffix = strings.TrimPrefix(suffix, "m")
+		}
+	} else if strings.HasPrefix(suffix, "aug") {
+		suffix = "+" + strings.TrimPrefix(suffix, "aug")
+	}
+	return accidental + numeral + suffix
+}
+
+// ParseRomanNumeral reads a Roman numeral written by RomanNumeral, such as
+// "V7", "bVII" or "ii7", as a chord symbol in a major or natural minor key.
+// A lower-case numeral is a minor chord, or diminished when followed by "°".
+func (a *Analyzer) ParseRomanNumeral(numeral string, tonic Note, minor bool) (ChordSymbol, error) {
+	rest := strings.TrimLeft(numeral, "b#")
+	accidental := numeral[:len(numeral)-len(rest)]
+	degree := 0
+	for i, r := range romanNumerals {
+		upper, lower := strings.HasPrefix(rest, r), strings.HasPrefix(rest, strings.ToLower(r))
+		if (upper || lower) && (degree == 0 || len(r) > len(romanNumerals[degree-1])) {
+			degree = i + 1
+		}
+	}
+	if degree == 0 {
+		return ChordSymbol{}, fmt.Errorf("invalid Roman numeral '%s'", numeral)
+	}
+	suffix := rest[len(romanNumerals[degree-1]):]
+	if rest[0] == 'i' || rest[0] == 'v' {
+		if strings.HasPrefix(suffix, "°") {
+			suffix = "dim" + strings.TrimPrefix(suffix, "°")
+		} else {
+			suffix = "m" + suffix
+		}
+	}
+	symbol, err := a.ParseNashville(fmt.Sprintf("%s%d%s", accidental, degree, suffix), tonic, minor)
+	if err != nil {
+		return ChordSymbol{}, fmt.Errorf("invalid Roman numeral '%s'", numeral)
+	}
+	return symbol, nil
+}
+
+// ChordModel is an n-gram model of chord progressions written as Roman
+// numerals, so progressions in different keys share their statistics.
+// Major and minor keys are counted separately. Each table maps a context,
+// the numerals of up to Order-1 preceding chords joined by spaces, to the
+// number of times each numeral followed it; the empty context counts every
+// chord.
+type ChordModel struct {
+	Order        int                       `json:"order"`
+	Progressions int                       `json:"progressions"`
+	Major        map[string]map[string]int `json:"major"`
+	Minor        map[string]map[string]int `json:"minor"`
+}
+
+// ChordPrediction is a possible next chord with its share of the
+// continuations seen after the context.
+type ChordPrediction struct {
+	Numeral     string
+	Count       int
+	Probability float64
+}
+
+// NewChordModel returns an empty model that looks back up to order-1 chords.
+func NewChordModel(order int) (*ChordModel, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("order must be at least 1, got %d", order)
+	}
+	return &ChordModel{
+		Order: order,
+		Major: make(map[string]map[string]int),
+		Minor: make(map[string]map[string]int),
+	}, nil
+}
+
+// ReadChordModel reads a model written by ChordModel.Write.
+func ReadChordModel(r io.Reader) (*ChordModel, error) {
+	var m ChordModel
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid chord model: %v", err)
+	}
+	if m.Order < 1 {
+		return nil, fmt.Errorf("invalid chord model: order must be at least 1, got %d", m.Order)
+	}
+	if m.Major == nil {
+		m.Major = make(map[string]map[string]int)
+	}
+	if m.Minor == nil {
+		m.Minor = make(map[string]map[string]int)
+	}
+	return &m, nil
+}
+
+// Write writes the model as JSON.
+func (m *ChordModel) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// table returns the counts for major or minor keys.
+func (m *ChordModel) table(minor bool) map[string]map[string]int {
+	if minor {
+		return m.Minor
+	}
+	return m.Major
+}
+
+// Train counts the transitions of one progression of Roman numerals in a
+// major or minor key, after every context from none up to Order-1 chords.
+func (m *ChordModel) Train(numerals []string, minor bool) {
+	if len(numerals) == 0 {
+		return
+	}
+	m.Progressions++
+	table := m.table(minor)
+	for i, next := range numerals {
+		for n := 0; n < m.Order && n <= i; n++ {
+			context := strings.Join(numerals[i-n:i], " ")
+			if table[context] == nil {
+				table[context] = make(map[string]int)
+			}
+			table[context][next]++
+		}
+	}
+}
+
+// Predict returns the likeliest chords to follow a progression of Roman
+// numerals, most probable first, with ties in numeral order. It uses the
+// longest context of up to Order-1 final chords that the model has seen,
+// backing off to shorter ones, and returns the context it used. Without
+// training data for the mode it returns no predictions.
+func (m *ChordModel) Predict(numerals []string, minor bool) ([]ChordPrediction, []string) {
+	table := m.table(minor)
+	n := min(m.Order-1, len(numerals))
+	for ; n >= 0; n-- {
+		context := numerals[len(numerals)-n:]
+		counts := table[strings.Join(context, " ")]
+		if len(counts) == 0 {
+			continue
+		}
+		total := 0
+		for _, c := range counts {
+			total += c
+		}
+		predictions := make([]ChordPrediction, 0, len(counts))
+		for numeral, c := range counts {
+			predictions = append(predictions, ChordPrediction{Numeral: numeral, Count: c, Probability: float64(c) / float64(total)})
+		}
+		sort.Slice(predictions, func(i, j int) bool {
+			if predictions[i].Count != predictions[j].Count {
+				return predictions[i].Count > predictions[j].Count
+			}
+			return predictions[i].Numeral < predictions[j].Numeral
+		})
+		return predictions, context
+	}
+	return nil, nil
+}
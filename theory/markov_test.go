@@ -0,0 +1,87 @@
+package theory
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRomanNumeral(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tests := []struct {
+		chord   string
+		key     string
+		numeral string
+	}{
+		{"Cmaj7", "C Major", "Imaj7"},
+		{"Dm7", "C Major", "ii7"},
+		{"G7", "C Major", "V7"},
+		{"Bdim", "C Major", "vii°"},
+		{"Bb", "C Major", "bVII"},
+		{"Fm", "C Major", "iv"},
+		{"Eaug", "A Minor", "V+"},
+		{"Am/G", "A Minor", "i"},
+		{"G", "A Minor", "VII"},
+		{"Cm(maj7)", "C Minor", "i(maj7)"},
+	}
+	for _, tt := range tests {
+		symbol, err := a.ParseChordSymbol(tt.chord)
+		if err != nil {
+			t.Fatalf("ParseChordSymbol(%q) returned error: %v", tt.chord, err)
+		}
+		tonic, minor, _ := ParseKeyName(tt.key)
+		if got := RomanNumeral(symbol, tonic, minor); got != tt.numeral {
+			t.Errorf("RomanNumeral(%s in %s) = %s, want %s", tt.chord, tt.key, got, tt.numeral)
+		}
+		back, err := a.ParseRomanNumeral(tt.numeral, tonic, minor)
+		if err != nil {
+			t.Errorf("ParseRomanNumeral(%s in %s) returned error: %v", tt.numeral, tt.key, err)
+		} else if back.Root.Value != symbol.Root.Value || back.Chord.Name != symbol.Chord.Name {
+			t.Errorf("ParseRomanNumeral(%s in %s) = %s, want %s without its bass", tt.numeral, tt.key, back, tt.chord)
+		}
+	}
+	if _, err := a.ParseRomanNumeral("bX7", Note{}, false); err == nil {
+		t.Error("ParseRomanNumeral(bX7): expected an error")
+	}
+}
+
+func TestChordModel(t *testing.T) {
+	t.Parallel()
+	if _, err := NewChordModel(0); err == nil {
+		t.Error("NewChordModel(0): expected an error")
+	}
+	m, _ := NewChordModel(3)
+	m.Train([]string{"I", "vi", "IV", "V"}, false)
+	m.Train([]string{"ii7", "V7", "Imaj7"}, false)
+	m.Train([]string{"I", "vi", "IV", "I"}, false)
+	m.Train([]string{"i", "iv", "V7", "i"}, true)
+
+	var buf bytes.Buffer
+	if err := m.Write(&buf); err != nil {
+		t.Fatalf("Write returned error: %v", err)
+	}
+	m, err := ReadChordModel(&buf)
+	if err != nil {
+		t.Fatalf("ReadChordModel returned error: %v", err)
+	}
+	if m.Order != 3 || m.Progressions != 4 {
+		t.Errorf("ReadChordModel = order %d with %d progressions, want order 3 with 4", m.Order, m.Progressions)
+	}
+
+	predictions, context := m.Predict([]string{"I", "vi", "IV"}, false)
+	if len(context) != 2 || len(predictions) != 2 || predictions[0].Numeral != "I" || predictions[0].Probability != 0.5 {
+		t.Errorf("Predict(I vi IV) = %+v after %v, want I and V at 50%% after vi IV", predictions, context)
+	}
+	// An unseen chord backs off to every chord of the mode.
+	predictions, context = m.Predict([]string{"bVII"}, false)
+	if len(context) != 0 || len(predictions) != 7 || predictions[0].Numeral != "I" || predictions[0].Count != 3 {
+		t.Errorf("Predict(bVII) = %+v after %v, want the major chord counts led by I", predictions, context)
+	}
+	if predictions, _ = m.Predict([]string{"iv"}, true); len(predictions) != 1 || predictions[0].Numeral != "V7" {
+		t.Errorf("Predict(iv) in minor = %+v, want V7", predictions)
+	}
+
+	if _, err := ReadChordModel(bytes.NewBufferString(`{"order": 0}`)); err == nil {
+		t.Error("ReadChordModel with order 0: expected an error")
+	}
+}
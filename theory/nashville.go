@@ -0,0 +1,115 @@
+package theory
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scale steps of the degrees 1 to 7 in major and natural minor keys.
+var (
+	majorSteps = [7]int{0, 2, 4, 5, 7, 9, 11}
+	minorSteps = [7]int{0, 2, 3, 5, 7, 8, 10}
+)
+
+// chromaticDegrees names the semitones above the tonic that are not in the
+// key, as altered degrees.
+var chromaticDegrees = map[bool]map[int]string{
+	false: {1: "b2", 3: "b3", 6: "#4", 8: "b6", 10: "b7"},
+	true:  {1: "b2", 4: "#3", 6: "#4", 9: "#6", 11: "#7"},
+}
+
+// NashvilleDegree returns the Nashville number of a pitch class in a major
+// or natural minor key, e.g. "5" for G in C major or "b7" for Bb.
+func NashvilleDegree(value int, tonic Note, minor bool) string {
+	semitones := ((value-tonic.Value)%12 + 12) % 12
+	steps := majorSteps
+	if minor {
+		steps = minorSteps
+	}
+	for i, step := range steps {
+		if step == semitones {
+			return fmt.Sprint(i + 1)
+		}
+	}
+	return chromaticDegrees[minor][semitones]
+}
+
+// parseNashvilleDegree reads a degree such as "4", "b7" or "#4" at the start
+// of s and returns its pitch class in the key, whether it was written with a
+// flat, and the rest of s.
+func parseNashvilleDegree(s string, tonic Note, minor bool) (int, bool, string, error) {
+	accidental := 0
+	for len(s) > 0 && (s[0] == 'b' || s[0] == '#') {
+		if s[0] == 'b' {
+			accidental--
+		} else {
+			accidental++
+		}
+		s = s[1:]
+	}
+	if s == "" || s[0] < '1' || s[0] > '7' {
+		return 0, false, "", fmt.Errorf("expected a degree from 1 to 7")
+	}
+	steps := majorSteps
+	if minor {
+		steps = minorSteps
+	}
+	value := ((tonic.Value+steps[s[0]-'1']+accidental)%12 + 12) % 12
+	return value, accidental < 0, s[1:], nil
+}
+
+// ToNashville writes a chord symbol as a Nashville number in a major or
+// natural minor key, keeping its quality suffix and slash bass: "Am7/G" in
+// C major is "6m7/5". A suffix that starts with a digit is put in
+// parentheses so it cannot be read as part of the degree: G7 is "5(7)".
+func ToNashville(symbol ChordSymbol, tonic Note, minor bool) string {
+	suffix := symbol.Suffix
+	if suffix != "" && suffix[0] >= '0' && suffix[0] <= '9' {
+		suffix = "(" + suffix + ")"
+	}
+	number := NashvilleDegree(symbol.Root.Value, tonic, minor) + suffix
+	if symbol.Bass != nil {
+		number += "/" + NashvilleDegree(symbol.Bass.Value, tonic, minor)
+	}
+	return number
+}
+
+// ParseNashville reads a Nashville number such as "1", "5m", "b7", "4maj7",
+// "5(7)" or "1/3" in a major or natural minor key. Degrees count the key's
+// own scale, so 3 is the minor third in a minor key, and a leading 'b' or '#'
+// alters them. The suffix is a dictionary quality, optionally in
+// parentheses, with "-" accepted for "m".
+// Roots and basses are spelled with the key signature.
+func (a *Analyzer) ParseNashville(number string, tonic Note, minor bool) (ChordSymbol, error) {
+	body, bassNumber, hasBass := strings.Cut(number, "/")
+	value, flat, suffix, err := parseNashvilleDegree(body, tonic, minor)
+	if err != nil {
+		return ChordSymbol{}, fmt.Errorf("invalid Nashville number '%s': %v", number, err)
+	}
+	if len(suffix) > 2 && suffix[0] == '(' && suffix[len(suffix)-1] == ')' {
+		suffix = suffix[1 : len(suffix)-1]
+	}
+	if strings.HasPrefix(suffix, "-") {
+		suffix = "m" + suffix[1:]
+	}
+	chordDef, err := lookupQuality(a.dictionary, suffix)
+	if err != nil {
+		return ChordSymbol{}, fmt.Errorf("invalid Nashville number '%s': %v", number, err)
+	}
+
+	spelling := KeySpelling(tonic.Value, minor)
+	spell := func(value int, flat bool) Note {
+		flats := spelling == SpellingFlats || (spelling == SpellingAuto && flat)
+		return Note{Original: NoteName(value, flats), Value: value}
+	}
+	symbol := ChordSymbol{Root: spell(value, flat), Suffix: suffix, Chord: chordDef}
+	if hasBass {
+		bassValue, bassFlat, rest, err := parseNashvilleDegree(bassNumber, tonic, minor)
+		if err != nil || rest != "" {
+			return ChordSymbol{}, fmt.Errorf("invalid bass in Nashville number '%s'", number)
+		}
+		bass := spell(bassValue, bassFlat)
+		symbol.Bass = &bass
+	}
+	return symbol, nil
+}
@@ -0,0 +1,84 @@
+package theory
+
+import "testing"
+
+func TestParseNashville(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tests := []struct {
+		key    string
+		number string
+		symbol string
+	}{
+		{"G Major", "1", "G"},
+		{"G Major", "5m", "Dm"},
+		{"G Major", "6-7", "Em7"},
+		{"G Major", "1/3", "G/B"},
+		{"G Major", "4(7)", "C7"},
+		{"C Major", "b7", "Bb"},
+		{"C Major", "#4dim", "F#dim"},
+		{"F Major", "4", "Bb"},
+		{"A Minor", "1m", "Am"},
+		{"A Minor", "3", "C"},
+		{"A Minor", "5/7", "E/G"},
+		{"Eb Minor", "5", "Bb"},
+	}
+	for _, tt := range tests {
+		tonic, minor, err := ParseKeyName(tt.key)
+		if err != nil {
+			t.Fatalf("ParseKeyName(%q) returned error: %v", tt.key, err)
+		}
+		symbol, err := a.ParseNashville(tt.number, tonic, minor)
+		if err != nil {
+			t.Errorf("ParseNashville(%q) in %s returned error: %v", tt.number, tt.key, err)
+			continue
+		}
+		if symbol.String() != tt.symbol {
+			t.Errorf("ParseNashville(%q) in %s = %s, want %s", tt.number, tt.key, symbol, tt.symbol)
+		}
+		if got := ToNashville(symbol, tonic, minor); got != normalizeNashville(tt.number) {
+			t.Errorf("ToNashville(%s) in %s = %s, want %s", symbol, tt.key, got, normalizeNashville(tt.number))
+		}
+	}
+
+	tonic, _, _ := ParseKeyName("C Major")
+	if symbol, err := a.ParseNashville("57", tonic, false); err != nil || symbol.String() != "G7" {
+		t.Errorf("ParseNashville(\"57\") = %s, %v; want G7", symbol, err)
+	}
+	for _, number := range []string{"", "8", "m", "1maj13", "1/", "1/9", "1/3m", "1()"} {
+		if _, err := a.ParseNashville(number, tonic, false); err == nil {
+			t.Errorf("ParseNashville(%q): expected an error", number)
+		}
+	}
+}
+
+// normalizeNashville writes the "-" shorthand for minor as "m".
+func normalizeNashville(number string) string {
+	if len(number) > 1 && number[1] == '-' {
+		return number[:1] + "m" + number[2:]
+	}
+	return number
+}
+
+func TestToNashville(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+	tonic, _, _ := ParseKeyName("C Major")
+	for name, expected := range map[string]string{
+		"Am7/G": "6m7/5",
+		"Eb":    "b3",
+		"Ab":    "b6",
+		"F#m":   "#4m",
+		"G7/B":  "5(7)/7",
+		"B7":    "7(7)",
+		"Dm7":   "2m7",
+	} {
+		symbol, err := a.ParseChordSymbol(name)
+		if err != nil {
+			t.Fatalf("ParseChordSymbol(%q) returned error: %v", name, err)
+		}
+		if got := ToNashville(symbol, tonic, false); got != expected {
+			t.Errorf("ToNashville(%s) in C Major = %s, want %s", name, got, expected)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package theory
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Notation is a note-naming system. Notes are always stored with English
+// spellings; a Notation translates names when parsing and printing.
+type Notation int
+
+const (
+	// NotationEnglish names notes C D E F G A B with # and b.
+	NotationEnglish Notation = iota
+	// NotationGerman names B natural H and B flat B, and adds -is for sharps
+	// and -es for flats (Cis, Des, Es, As), as in German and Scandinavian use.
+	NotationGerman
+	// NotationDutch adds -is for sharps and -es for flats (Cis, Des, Es, As,
+	// Bes) and keeps B for B natural.
+	NotationDutch
+	// NotationSolfege is fixed-do solfège as used in Italian, French and
+	// Spanish: Do Re Mi Fa Sol La Si with # and b.
+	NotationSolfege
+)
+
+var notationNames = map[string]Notation{
+	"english":      NotationEnglish,
+	"german":       NotationGerman,
+	"scandinavian": NotationGerman,
+	"dutch":        NotationDutch,
+	"solfege":      NotationSolfege,
+	"fixed-do":     NotationSolfege,
+	"italian":      NotationSolfege,
+	"french":       NotationSolfege,
+}
+
+// ParseNotation converts a name such as "german" or "solfege" into a Notation.
+func ParseNotation(s string) (Notation, error) {
+	if n, ok := notationNames[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("unknown notation '%s' (expected english, german, dutch or solfege)", s)
+}
+
+func (n Notation) String() string {
+	switch n {
+	case NotationGerman:
+		return "german"
+	case NotationDutch:
+		return "dutch"
+	case NotationSolfege:
+		return "solfege"
+	}
+	return "english"
+}
+
+var solfegeSyllables = map[byte]string{'C': "Do", 'D': "Re", 'E': "Mi", 'F': "Fa", 'G': "Sol", 'A': "La", 'B': "Si"}
+
+// localNames maps each lower-cased name of a non-English notation to the
+// English spelling it stands for.
+var localNames = func() map[Notation]map[string]string {
+	names := make(map[Notation]map[string]string)
+	for _, n := range []Notation{NotationGerman, NotationDutch, NotationSolfege} {
+		names[n] = make(map[string]string)
+		for english := range noteMap {
+			spelled := english[:1] + strings.ToLower(english[1:])
+			names[n][strings.ToLower(n.Name(spelled))] = spelled
+		}
+	}
+	// Common alternative spellings.
+	names[NotationDutch]["ees"] = "Eb"
+	names[NotationDutch]["aes"] = "Ab"
+	names[NotationSolfege]["ut"] = "C"
+	names[NotationSolfege]["ré"] = "D"
+	names[NotationSolfege]["so"] = "G"
+	for _, acc := range []string{"#", "b"} {
+		names[NotationSolfege]["ut"+acc] = "C" + acc
+		names[NotationSolfege]["ré"+acc] = "D" + acc
+		names[NotationSolfege]["so"+acc] = "G" + acc
+	}
+	return names
+}()
+
+// ParseNote parses a note name in this notation, e.g. "Cis" or "H" in German
+// or "Sib" in solfège. English names keep their spelling; other names are
+// stored with the equivalent English spelling.
+func (n Notation) ParseNote(s string) (Note, error) {
+	if n == NotationEnglish {
+		return ParseNote(s)
+	}
+	if english, ok := localNames[n][strings.ToLower(s)]; ok {
+		return ParseNote(english)
+	}
+	if s == "" {
+		return Note{}, fmt.Errorf("cannot parse empty string")
+	}
+	return Note{}, fmt.Errorf("unrecognized note")
+}
+
+// ParseNotes parses a list of note names in this notation, skipping blanks
+// and removing duplicates.
+func (n Notation) ParseNotes(noteStrings []string) ([]Note, error) {
+	return parseNotes(n, noteStrings)
+}
+
+// Name translates an English note spelling such as "F#" or "Bb" into this
+// notation. Names that are not a single note are returned unchanged.
+func (n Notation) Name(english string) string {
+	letter, accidental, ok := splitEnglishName(english)
+	if n == NotationEnglish || !ok || len(english) != 1+len(accidental) {
+		return english
+	}
+
+	switch n {
+	case NotationSolfege:
+		return solfegeSyllables[letter] + accidental
+	case NotationGerman:
+		if letter == 'B' {
+			return map[string]string{"": "H", "#": "His", "b": "B"}[accidental]
+		}
+	}
+	name := string(letter)
+	switch accidental {
+	case "#":
+		name += "is"
+	case "b":
+		if letter == 'E' || letter == 'A' {
+			name += "s"
+		} else {
+			name += "es"
+		}
+	}
+	return name
+}
+
+// NoteName returns the name of a note in this notation.
+func (n Notation) NoteName(note Note) string {
+	if note.Original == "" {
+		return n.Name(valueToName[note.Value])
+	}
+	return n.Name(note.Original)
+}
+
+// SliceToString joins the names of the notes in this notation with spaces.
+func (n Notation) SliceToString(notes []Note) string {
+	parts := make([]string, len(notes))
+	for i, note := range notes {
+		parts[i] = n.NoteName(note)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Localize translates the note names in English text that starts with a
+// note: a chord symbol ("Bbm7/F"), a key name ("C# Minor") or a match
+// ("B Major Triad"). The root at the start and a bass note after a slash are
+// translated; everything else is kept.
+func (n Notation) Localize(text string) string {
+	if n == NotationEnglish {
+		return text
+	}
+	text = n.localizePrefix(text)
+	if i := strings.LastIndex(text, "/"); i >= 0 {
+		text = text[:i+1] + n.localizePrefix(text[i+1:])
+	}
+	return text
+}
+
+// localizePrefix translates the note name at the start of s.
+func (n Notation) localizePrefix(s string) string {
+	letter, accidental, ok := splitEnglishName(s)
+	if !ok {
+		return s
+	}
+	length := 1 + len(accidental)
+	return n.Name(string(letter)+accidental) + s[length:]
+}
+
+// splitEnglishName reads the letter and the accidental, if any, at the
+// start of an English note name. The letter is returned in upper case.
+func splitEnglishName(s string) (byte, string, bool) {
+	if s == "" {
+		return 0, "", false
+	}
+	letter := s[0] &^ ('a' - 'A')
+	if letter < 'A' || letter > 'G' {
+		return 0, "", false
+	}
+	if len(s) > 1 && (s[1] == '#' || s[1] == 'b') {
+		return letter, s[1:2], true
+	}
+	return letter, "", true
+}
+
+// splitChordName separates the root note of a chord name in this notation
+// from its quality suffix. Longer note names are tried first, so German
+// "Esus4" is tried as Es + "us4" before E + "sus4"; the caller picks the
+// first candidate whose quality is known.
+func (n Notation) splitChordName(name string) ([]Note, []string) {
+	var roots []Note
+	var qualities []string
+	for i := len(name); i > 0; i-- {
+		if i < len(name) && !utf8.RuneStart(name[i]) {
+			continue
+		}
+		if root, err := n.ParseNote(name[:i]); err == nil {
+			roots = append(roots, root)
+			qualities = append(qualities, name[i:])
+		}
+	}
+	return roots, qualities
+}
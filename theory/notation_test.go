@@ -0,0 +1,114 @@
+package theory
+
+import "testing"
+
+func TestNotationParseNote(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		notation Notation
+		input    string
+		expected string // English spelling, or "" for an error
+	}{
+		{NotationEnglish, "f#", "f#"},
+		{NotationEnglish, "H", ""},
+		{NotationGerman, "H", "B"},
+		{NotationGerman, "B", "Bb"},
+		{NotationGerman, "cis", "C#"},
+		{NotationGerman, "Des", "Db"},
+		{NotationGerman, "Es", "Eb"},
+		{NotationGerman, "As", "Ab"},
+		{NotationGerman, "His", "B#"},
+		{NotationGerman, "Bb", ""},
+		{NotationDutch, "B", "B"},
+		{NotationDutch, "Bes", "Bb"},
+		{NotationDutch, "Ees", "Eb"},
+		{NotationDutch, "H", ""},
+		{NotationSolfege, "Do", "C"},
+		{NotationSolfege, "sol", "G"},
+		{NotationSolfege, "Sib", "Bb"},
+		{NotationSolfege, "Fa#", "F#"},
+		{NotationSolfege, "Ré", "D"},
+		{NotationSolfege, "C", ""},
+	}
+	for _, tt := range tests {
+		n, err := tt.notation.ParseNote(tt.input)
+		switch {
+		case tt.expected == "" && err == nil:
+			t.Errorf("%s: ParseNote(%q) = %q, want an error", tt.notation, tt.input, n.Original)
+		case tt.expected != "" && (err != nil || n.Original != tt.expected):
+			t.Errorf("%s: ParseNote(%q) = %q, %v; want %q", tt.notation, tt.input, n.Original, err, tt.expected)
+		}
+	}
+}
+
+func TestNotationNames(t *testing.T) {
+	t.Parallel()
+	notes := []Note{{"C", 0}, {"C#", 1}, {"Eb", 3}, {"Ab", 8}, {"Bb", 10}, {"B", 11}, {"", 6}}
+	tests := map[Notation]string{
+		NotationEnglish: "C C# Eb Ab Bb B F#",
+		NotationGerman:  "C Cis Es As B H Fis",
+		NotationDutch:   "C Cis Es As Bes B Fis",
+		NotationSolfege: "Do Do# Mib Lab Sib Si Fa#",
+	}
+	for notation, expected := range tests {
+		if got := notation.SliceToString(notes); got != expected {
+			t.Errorf("%s: SliceToString() = %q, want %q", notation, got, expected)
+		}
+	}
+
+	localize := []struct {
+		notation Notation
+		input    string
+		expected string
+	}{
+		{NotationGerman, "Bbm7/F", "Bm7/F"},
+		{NotationGerman, "B Major Triad (subset)", "H Major Triad (subset)"},
+		{NotationGerman, "C# Minor", "Cis Minor"},
+		{NotationSolfege, "G7/B", "Sol7/Si"},
+		{NotationSolfege, "C6/9", "Do6/9"},
+		{NotationDutch, "Eb Major", "Es Major"},
+		{NotationEnglish, "Bbm7/F", "Bbm7/F"},
+	}
+	for _, tt := range localize {
+		if got := tt.notation.Localize(tt.input); got != tt.expected {
+			t.Errorf("%s: Localize(%q) = %q, want %q", tt.notation, tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := ParseNotation("klingon"); err == nil {
+		t.Error("ParseNotation(klingon): expected an error")
+	}
+}
+
+func TestNotationChordNames(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		notation Notation
+		name     string
+		root     string
+		quality  string
+	}{
+		{NotationGerman, "Hm7", "B", "Minor 7th"},
+		{NotationGerman, "Esm", "Eb", "Minor Triad"},
+		{NotationGerman, "Esus4", "E", "Sus4"},
+		{NotationGerman, "Essus4", "Eb", "Sus4"},
+		{NotationDutch, "Fisdim", "F#", "Diminished Triad"},
+		{NotationSolfege, "Sibmaj7", "Bb", "Major 7th"},
+		{NotationSolfege, "Sol", "G", "Major Triad"},
+	}
+	for _, tt := range tests {
+		a := NewAnalyzer(Options{Notation: tt.notation})
+		root, chord, err := a.ParseChordName(tt.name)
+		if err != nil || root.Original != tt.root || chord.Name != tt.quality {
+			t.Errorf("%s: ParseChordName(%q) = %s %s, %v; want %s %s", tt.notation, tt.name, root.Original, chord.Name, err, tt.root, tt.quality)
+		}
+	}
+
+	a := NewAnalyzer(Options{Notation: NotationGerman})
+	if got, err := a.TransposeChordName("B7/F", 1, SpellingAuto); err != nil || got != "H7/Fis" {
+		t.Errorf("TransposeChordName(B7/F, 1) = %q, %v; want H7/Fis", got, err)
+	}
+	if _, _, err := a.ParseChordName("Xm"); err == nil {
+		t.Error("ParseChordName(Xm): expected an error")
+	}
+}
@@ -0,0 +1,130 @@
+// Package theory contains Cordelia's note, chord and key logic. It has no
+// package-level mutable state and never prints; callers receive result structs.
+package theory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Note is a pitch class together with the spelling it was parsed from.
+type Note struct {
+	Original string
+	Value    int
+}
+
+var noteMap = map[string]int{
+	"C": 0, "B#": 0, "C#": 1, "DB": 1, "D": 2, "D#": 3, "EB": 3, "E": 4, "FB": 4,
+	"F": 5, "E#": 5, "F#": 6, "GB": 6, "G": 7, "G#": 8, "AB": 8, "A": 9, "A#": 10, "BB": 10,
+	"B": 11, "CB": 11,
+}
+
+var valueToName = []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+var valueToFlatName = []string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// Spelling selects how black-key pitch classes are named.
+type Spelling int
+
+const (
+	// SpellingAuto uses flats when the source note is spelled with a flat, and sharps otherwise.
+	SpellingAuto Spelling = iota
+	// SpellingSharps always names black keys with sharps (C#, D#, ...).
+	SpellingSharps
+	// SpellingFlats always names black keys with flats (Db, Eb, ...).
+	SpellingFlats
+)
+
+// ParseNote parses a single note name such as "C", "f#" or "Bb".
+func ParseNote(s string) (Note, error) {
+	if s == "" {
+		return Note{}, fmt.Errorf("cannot parse empty string")
+	}
+	runes := []rune(s)
+	normalized := string(unicode.ToTitle(runes[0]))
+	if len(runes) > 1 {
+		normalized += strings.ToUpper(string(runes[1:]))
+	}
+	normalized = strings.Replace(normalized, "b", "B", 1)
+
+	value, ok := noteMap[normalized]
+	if !ok {
+		return Note{}, fmt.Errorf("unrecognized note")
+	}
+	return Note{Original: s, Value: value}, nil
+}
+
+// ParseNotes parses a list of note names, skipping blanks and removing duplicates.
+func ParseNotes(noteStrings []string) ([]Note, error) {
+	return parseNotes(NotationEnglish, noteStrings)
+}
+
+func parseNotes(notation Notation, noteStrings []string) ([]Note, error) {
+	var notes []Note
+	for _, s := range noteStrings {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		n, err := notation.ParseNote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid note '%s' in input", s)
+		}
+		notes = append(notes, n)
+	}
+
+	if len(notes) == 0 {
+		return nil, errors.New("no valid notes provided")
+	}
+
+	return Unique(notes), nil
+}
+
+// Unique removes notes whose pitch class has already been seen, keeping the first spelling.
+func Unique(notes []Note) []Note {
+	seen := make(map[int]struct{})
+	var uniqueNotes []Note
+	for _, n := range notes {
+		if _, ok := seen[n.Value]; !ok {
+			seen[n.Value] = struct{}{}
+			uniqueNotes = append(uniqueNotes, n)
+		}
+	}
+	return uniqueNotes
+}
+
+// SliceToString joins note spellings with spaces, using canonical names for unspelled notes.
+func SliceToString(notes []Note) string {
+	var parts []string
+	for _, n := range notes {
+		if n.Original == "" {
+			parts = append(parts, valueToName[n.Value])
+		} else {
+			parts = append(parts, n.Original)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// NoteName returns the canonical name of a pitch class, spelled with flats if requested.
+func NoteName(value int, flats bool) string {
+	value = ((value % 12) + 12) % 12
+	if flats {
+		return valueToFlatName[value]
+	}
+	return valueToName[value]
+}
+
+// IsFlat reports whether a note is spelled with a flat, e.g. "Bb" or "eb".
+func IsFlat(n Note) bool {
+	return len(n.Original) > 1 && strings.ContainsRune(n.Original[1:], 'b')
+}
+
+// Transpose moves a note by the given number of semitones and respells it.
+func Transpose(n Note, semitones int, spelling Spelling) Note {
+	value := ((n.Value+semitones)%12 + 12) % 12
+	flats := spelling == SpellingFlats || (spelling == SpellingAuto && IsFlat(n))
+	return Note{Original: NoteName(value, flats), Value: value}
+}
@@ -0,0 +1,37 @@
+package theory
+
+import "testing"
+
+func TestTranspose(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		note      string
+		semitones int
+		spelling  Spelling
+		expected  string
+	}{
+		{"C", 2, SpellingAuto, "D"},
+		{"F", 1, SpellingAuto, "F#"},
+		{"Bb", 1, SpellingAuto, "B"},
+		{"Eb", 1, SpellingAuto, "E"},
+		{"Eb", 3, SpellingAuto, "Gb"},
+		{"C", -1, SpellingAuto, "B"},
+		{"C#", 1, SpellingFlats, "D"},
+		{"D", 1, SpellingFlats, "Eb"},
+		{"Ab", -13, SpellingSharps, "G"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.note, func(t *testing.T) {
+			t.Parallel()
+			n, err := ParseNote(tt.note)
+			if err != nil {
+				t.Fatalf("ParseNote(%q) returned error: %v", tt.note, err)
+			}
+			if got := Transpose(n, tt.semitones, tt.spelling).Original; got != tt.expected {
+				t.Errorf("Transpose(%s, %d) = %s, want %s", tt.note, tt.semitones, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package theory
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Scale is a named interval pattern above a root, in ascending order.
+type Scale struct {
+	Name      string
+	Intervals []int
+}
+
+// scaleDictionary lists the built-in scales, grouped by family. Families of
+// modes keep the order of their parent scale's degrees.
+var scaleDictionary = []Scale{
+	// Major modes
+	{Name: "Ionian", Intervals: []int{0, 2, 4, 5, 7, 9, 11}},
+	{Name: "Dorian", Intervals: []int{0, 2, 3, 5, 7, 9, 10}},
+	{Name: "Phrygian", Intervals: []int{0, 1, 3, 5, 7, 8, 10}},
+	{Name: "Lydian", Intervals: []int{0, 2, 4, 6, 7, 9, 11}},
+	{Name: "Mixolydian", Intervals: []int{0, 2, 4, 5, 7, 9, 10}},
+	{Name: "Aeolian", Intervals: []int{0, 2, 3, 5, 7, 8, 10}},
+	{Name: "Locrian", Intervals: []int{0, 1, 3, 5, 6, 8, 10}},
+	// Melodic minor modes
+	{Name: "Melodic Minor", Intervals: []int{0, 2, 3, 5, 7, 9, 11}},
+	{Name: "Dorian b2", Intervals: []int{0, 1, 3, 5, 7, 9, 10}},
+	{Name: "Lydian Augmented", Intervals: []int{0, 2, 4, 6, 8, 9, 11}},
+	{Name: "Lydian Dominant", Intervals: []int{0, 2, 4, 6, 7, 9, 10}},
+	{Name: "Mixolydian b6", Intervals: []int{0, 2, 4, 5, 7, 8, 10}},
+	{Name: "Locrian #2", Intervals: []int{0, 2, 3, 5, 6, 8, 10}},
+	{Name: "Altered", Intervals: []int{0, 1, 3, 4, 6, 8, 10}},
+	// Harmonic minor modes
+	{Name: "Harmonic Minor", Intervals: []int{0, 2, 3, 5, 7, 8, 11}},
+	{Name: "Locrian #6", Intervals: []int{0, 1, 3, 5, 6, 9, 10}},
+	{Name: "Ionian #5", Intervals: []int{0, 2, 4, 5, 8, 9, 11}},
+	{Name: "Dorian #4", Intervals: []int{0, 2, 3, 6, 7, 9, 10}},
+	{Name: "Phrygian Dominant", Intervals: []int{0, 1, 4, 5, 7, 8, 10}},
+	{Name: "Lydian #2", Intervals: []int{0, 3, 4, 6, 7, 9, 11}},
+	{Name: "Ultralocrian", Intervals: []int{0, 1, 3, 4, 6, 8, 9}},
+	// Other heptatonic scales
+	{Name: "Harmonic Major", Intervals: []int{0, 2, 4, 5, 7, 8, 11}},
+	{Name: "Double Harmonic", Intervals: []int{0, 1, 4, 5, 7, 8, 11}},
+	{Name: "Hungarian Minor", Intervals: []int{0, 2, 3, 6, 7, 8, 11}},
+	{Name: "Neapolitan Major", Intervals: []int{0, 1, 3, 5, 7, 9, 11}},
+	{Name: "Neapolitan Minor", Intervals: []int{0, 1, 3, 5, 7, 8, 11}},
+	{Name: "Enigmatic", Intervals: []int{0, 1, 4, 6, 8, 10, 11}},
+	// Pentatonic scales
+	{Name: "Major Pentatonic", Intervals: []int{0, 2, 4, 7, 9}},
+	{Name: "Minor Pentatonic", Intervals: []int{0, 3, 5, 7, 10}},
+	{Name: "Suspended Pentatonic", Intervals: []int{0, 2, 5, 7, 10}},
+	{Name: "Hirajoshi", Intervals: []int{0, 2, 3, 7, 8}},
+	{Name: "In Sen", Intervals: []int{0, 1, 5, 7, 10}},
+	{Name: "Iwato", Intervals: []int{0, 1, 5, 6, 10}},
+	// Blues scales
+	{Name: "Blues", Intervals: []int{0, 3, 5, 6, 7, 10}},
+	{Name: "Major Blues", Intervals: []int{0, 2, 3, 4, 7, 9}},
+	// Hexatonic scales
+	{Name: "Whole Tone", Intervals: []int{0, 2, 4, 6, 8, 10}},
+	{Name: "Augmented", Intervals: []int{0, 3, 4, 7, 8, 11}},
+	{Name: "Prometheus", Intervals: []int{0, 2, 4, 6, 9, 10}},
+	{Name: "Tritone", Intervals: []int{0, 1, 4, 6, 7, 10}},
+	{Name: "Major Hexatonic", Intervals: []int{0, 2, 4, 5, 7, 9}},
+	{Name: "Minor Hexatonic", Intervals: []int{0, 2, 3, 5, 7, 10}},
+	// Octatonic scales
+	{Name: "Half-Whole Diminished", Intervals: []int{0, 1, 3, 4, 6, 7, 9, 10}},
+	{Name: "Whole-Half Diminished", Intervals: []int{0, 2, 3, 5, 6, 8, 9, 11}},
+	// Bebop scales
+	{Name: "Bebop Dominant", Intervals: []int{0, 2, 4, 5, 7, 9, 10, 11}},
+	{Name: "Bebop Major", Intervals: []int{0, 2, 4, 5, 7, 8, 9, 11}},
+	{Name: "Bebop Dorian", Intervals: []int{0, 2, 3, 4, 5, 7, 9, 10}},
+	{Name: "Bebop Melodic Minor", Intervals: []int{0, 2, 3, 5, 7, 8, 9, 11}},
+	{Name: "Chromatic", Intervals: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+}
+
+// DefaultScales returns a copy of the built-in scale dictionary.
+func DefaultScales() []Scale {
+	out := make([]Scale, len(scaleDictionary))
+	for i, s := range scaleDictionary {
+		out[i] = Scale{Name: s.Name, Intervals: append([]int(nil), s.Intervals...)}
+	}
+	return out
+}
+
+// lookupScale finds a scale of the built-in dictionary by name.
+func lookupScale(name string) (Scale, bool) {
+	for _, s := range scaleDictionary {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scale{}, false
+}
+
+// chordScaleChoice names a scale recommended for a chord quality and, for
+// the altered dominant scales, the tensions it suits.
+type chordScaleChoice struct {
+	scale string
+	use   string
+}
+
+// chordScaleTable lists the usual scales for each built-in chord quality and
+// each quality of ChordScaleDictionary, most common first. Chords not listed get every scale that contains them.
+var chordScaleTable = map[string][]chordScaleChoice{
+	"Major Triad":      {{"Ionian", ""}, {"Lydian", ""}, {"Mixolydian", ""}},
+	"Major 7th":        {{"Ionian", ""}, {"Lydian", ""}},
+	"Minor Triad":      {{"Dorian", ""}, {"Aeolian", ""}, {"Phrygian", ""}, {"Melodic Minor", ""}, {"Harmonic Minor", ""}},
+	"Minor 7th":        {{"Dorian", ""}, {"Aeolian", ""}, {"Phrygian", ""}},
+	"Minor-Major 7th":  {{"Melodic Minor", ""}, {"Harmonic Minor", ""}},
+	"Diminished Triad": {{"Locrian", ""}, {"Locrian #2", ""}, {"Whole-Half Diminished", ""}},
+	"Augmented Triad":  {{"Whole Tone", ""}, {"Lydian Augmented", ""}},
+	"Sus2":             {{"Mixolydian", ""}, {"Dorian", ""}, {"Ionian", ""}},
+	"Sus4":             {{"Mixolydian", ""}, {"Dorian", ""}},
+	"Dominant 7th": {
+		{"Mixolydian", ""},
+		{"Lydian Dominant", "7#11"},
+		{"Mixolydian b6", "7b13"},
+		{"Phrygian Dominant", "7b9b13"},
+		{"Half-Whole Diminished", "7b9"},
+		{"Altered", "7alt"},
+		{"Whole Tone", "7#5"},
+	},
+	"Dominant 7th Sharp 11":       {{"Lydian Dominant", "7#11"}, {"Half-Whole Diminished", "7b9"}},
+	"Dominant 7th Flat 9":         {{"Half-Whole Diminished", "7b9"}, {"Phrygian Dominant", "7b9b13"}},
+	"Dominant 7th Flat 13":        {{"Mixolydian b6", "7b13"}, {"Phrygian Dominant", "7b9b13"}},
+	"Dominant 7th Flat 9 Flat 13": {{"Phrygian Dominant", "7b9b13"}},
+	"Dominant 7th Sharp 5":        {{"Whole Tone", "7#5"}, {"Altered", "7alt"}},
+	"Altered Dominant":            {{"Altered", "7alt"}},
+	"Half-Diminished 7th":         {{"Locrian", ""}, {"Locrian #2", ""}},
+}
+
+// tensionChords are the altered dominant and half-diminished qualities that
+// chord scales are often chosen for. They are not in the built-in dictionary,
+// where they would crowd chord identification.
+var tensionChords = []Chord{
+	{Name: "Altered Dominant", Suffixes: []string{"7alt"}, Intervals: []int{0, 4, 10, 1, 3, 6, 8}},
+	{Name: "Dominant 7th Sharp 11", Suffixes: []string{"7#11"}, Intervals: []int{0, 4, 7, 10, 6}},
+	{Name: "Dominant 7th Flat 9 Flat 13", Suffixes: []string{"7b9b13"}, Intervals: []int{0, 4, 7, 10, 1, 8}},
+	{Name: "Dominant 7th Flat 9", Suffixes: []string{"7b9"}, Intervals: []int{0, 4, 7, 10, 1}},
+	{Name: "Dominant 7th Flat 13", Suffixes: []string{"7b13"}, Intervals: []int{0, 4, 7, 10, 8}},
+	{Name: "Dominant 7th Sharp 5", Suffixes: []string{"7#5", "7+5", "aug7"}, Intervals: []int{0, 4, 8, 10}},
+	{Name: "Half-Diminished 7th", Suffixes: []string{"m7b5", "ø7"}, Intervals: []int{0, 3, 6, 10}},
+}
+
+// ChordScaleDictionary returns the altered dominant (7alt, 7#11, 7b9b13, 7b9,
+// 7b13, 7#5) and half-diminished (m7b5) qualities followed by the built-in
+// chord dictionary, for naming chords to recommend scales for. The tension
+// qualities come first so that they win over the chords they contain.
+func ChordScaleDictionary() []Chord {
+	return append(copyDictionary(tensionChords), DefaultDictionary()...)
+}
+
+// ChordScale is a scale recommended for a chord, spelled from the chord's
+// root. Avoid lists the scale notes that clash with a chord tone a semitone
+// below them. Outside counts the scale notes that are not in the key the
+// scales were ranked for, and is 0 without a key.
+type ChordScale struct {
+	Scale   Scale
+	Use     string // the tensions an altered dominant scale suits, e.g. "7alt"
+	Notes   []Note
+	Avoid   []Note
+	Outside int
+}
+
+// ChordScales recommends scales for a chord with the given root. With a key
+// name such as "C Major", scales with fewer notes outside the key come
+// first; otherwise, and between equals, the usual choice comes first.
+func (a *Analyzer) ChordScales(root Note, chordDef Chord, key string) ([]ChordScale, error) {
+	var keyNotes map[int]struct{}
+	if key != "" {
+		found := false
+		for _, k := range a.keys {
+			if k.Name == key {
+				keyNotes, found = k.Notes, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown key '%s'", key)
+		}
+	}
+
+	var recommended []ChordScale
+	for _, choice := range chordScaleChoices(chordDef) {
+		scale, _ := lookupScale(choice.scale)
+		cs := ChordScale{Scale: scale, Use: choice.use, Notes: SpellScale(root, scale)}
+		cs.Avoid = avoidNotes(cs.Notes, root, chordDef.Intervals)
+		if keyNotes != nil {
+			for _, n := range cs.Notes {
+				if _, ok := keyNotes[n.Value]; !ok {
+					cs.Outside++
+				}
+			}
+		}
+		recommended = append(recommended, cs)
+	}
+	sort.SliceStable(recommended, func(i, j int) bool {
+		return recommended[i].Outside < recommended[j].Outside
+	})
+	return recommended, nil
+}
+
+// chordScaleChoices returns the table entry for a chord quality, or every
+// dictionary scale short of the chromatic one that contains the chord's
+// intervals.
+func chordScaleChoices(chordDef Chord) []chordScaleChoice {
+	if choices, ok := chordScaleTable[chordDef.Name]; ok {
+		return choices
+	}
+	var choices []chordScaleChoice
+	for _, s := range scaleDictionary {
+		set := intervalSetOf(s.Intervals)
+		if ok, _ := chordDef.Check(s.Intervals, set); ok && len(s.Intervals) < 12 {
+			choices = append(choices, chordScaleChoice{scale: s.Name})
+		}
+	}
+	return choices
+}
+
+// avoidNotes returns the scale notes that are a semitone above a chord tone.
+// Over dominant chords (major third and minor seventh) the b9 and b13 are
+// tensions, so only the note above the third is avoided.
+func avoidNotes(scale []Note, root Note, chordIntervals []int) []Note {
+	chordSet := intervalSetOf(chordIntervals)
+	_, hasThird := chordSet[4]
+	_, hasSeventh := chordSet[10]
+	dominant := hasThird && hasSeventh
+
+	var avoid []Note
+	for _, n := range scale {
+		interval := ((n.Value-root.Value)%12 + 12) % 12
+		if _, ok := chordSet[interval]; ok {
+			continue
+		}
+		below := (interval + 11) % 12
+		if _, ok := chordSet[below]; !ok {
+			continue
+		}
+		if dominant && below != 4 {
+			continue
+		}
+		avoid = append(avoid, n)
+	}
+	return avoid
+}
+
+// scaleLetterSteps gives the letter distance from the root used to spell
+// each interval of scales that do not have seven notes: b2, 2, b3, 3, 4, #4,
+// 5, b6, 6, b7, 7. The tritone is a b5 instead in scales with a fourth.
+var scaleLetterSteps = [12]int{0, 1, 1, 2, 2, 3, 3, 4, 5, 5, 6, 6}
+
+// SpellScale spells a scale from its root. Seven-note scales use each letter
+// once, as in "G Ab Bb Cb Db Eb F"; other scales use natural names for white
+// keys and spell black keys as their usual degree. Notes that would need a
+// double accidental are named with flats or sharps as the root is.
+func SpellScale(root Note, scale Scale) []Note {
+	letterIndex := rootLetterIndex(root)
+
+	_, hasFourth := intervalSetOf(scale.Intervals)[5]
+	notes := make([]Note, len(scale.Intervals))
+	for i, interval := range scale.Intervals {
+		value := (root.Value + interval) % 12
+		step := scaleLetterSteps[interval%12]
+		if interval == 6 && hasFourth {
+			step = 4
+		}
+		if len(scale.Intervals) == 7 {
+			step = i
+		} else if natural := valueToName[value]; len(natural) == 1 {
+			notes[i] = Note{Original: natural, Value: value}
+			continue
+		}
+		notes[i] = letterNote(letters[(letterIndex+step)%7], value, root)
+	}
+	return notes
+}
+
+// rootLetterIndex returns the index in letters of the letter a note is
+// spelled with.
+func rootLetterIndex(root Note) int {
+	letter, _, ok := splitEnglishName(root.Original)
+	if !ok {
+		letter = valueToName[root.Value][0]
+	}
+	return indexOfLetter(letter)
+}
+
+// letterNote names a pitch class with a letter and at most one sharp or
+// flat. A pitch class that would need a double accidental is named with
+// flats or sharps as root is.
+func letterNote(l byte, value int, root Note) Note {
+	name := string(l)
+	switch (value - noteMap[name] + 12) % 12 {
+	case 0:
+	case 1:
+		name += "#"
+	case 11:
+		name += "b"
+	default:
+		name = NoteName(value, IsFlat(root))
+	}
+	return Note{Original: name, Value: value}
+}
+
+// Check reports whether the scale contains every input interval, and if
+// not, why. It is the reverse of Chord.Check: the input must be a subset of
+// the scale.
+func (s Scale) Check(inputIntervals []int) (bool, string) {
+	scaleSet := intervalSetOf(s.Intervals)
+	for _, interval := range inputIntervals {
+		if _, ok := scaleSet[interval]; !ok {
+			return false, fmt.Sprintf("does not contain interval %d", interval)
+		}
+	}
+	return true, ""
+}
+
+// ScaleMatch is a scale on a root that contains every input note. Exact is
+// set when the scale has no other notes; Extra lists the ones it adds.
+type ScaleMatch struct {
+	Root  Note
+	Scale Scale
+	Notes []Note
+	Extra []Note
+	Exact bool
+}
+
+// IdentifyScales tries every built-in scale on all twelve roots and returns
+// those that contain the notes. Exact matches come first, then scales that
+// add fewer notes; between equals, scales on the first input note come
+// first, then the dictionary order and the root's distance above the first
+// note. The chromatic scale is only tried on the first note. Roots that are
+// input notes keep their spelling; other roots are spelled with flats if any
+// input note is.
+func IdentifyScales(notes []Note) []ScaleMatch {
+	notes = Unique(notes)
+	if len(notes) == 0 {
+		return nil
+	}
+	flats := false
+	for _, n := range notes {
+		flats = flats || IsFlat(n)
+	}
+
+	roots := make([]Note, 12)
+	for offset := range roots {
+		value := (notes[0].Value + offset) % 12
+		roots[offset] = Note{Original: NoteName(value, flats), Value: value}
+		for _, n := range notes {
+			if n.Value == value {
+				roots[offset] = n
+			}
+		}
+	}
+
+	var matches []ScaleMatch
+	for _, scale := range scaleDictionary {
+		for offset, root := range roots {
+			if len(scale.Intervals) == 12 && offset > 0 {
+				break
+			}
+			intervals := CalculateIntervals(root, notes)
+			if ok, _ := scale.Check(intervals); !ok {
+				continue
+			}
+			m := ScaleMatch{Root: root, Scale: scale, Notes: SpellScale(root, scale)}
+			inputSet := intervalSetOf(intervals)
+			for i, interval := range scale.Intervals {
+				if _, ok := inputSet[interval]; !ok {
+					m.Extra = append(m.Extra, m.Notes[i])
+				}
+			}
+			m.Exact = len(m.Extra) == 0
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if len(matches[i].Extra) != len(matches[j].Extra) {
+			return len(matches[i].Extra) < len(matches[j].Extra)
+		}
+		return matches[i].Root.Value == notes[0].Value && matches[j].Root.Value != notes[0].Value
+	})
+	return matches
+}
+
+var letters = []byte("CDEFGAB")
+
+func indexOfLetter(letter byte) int {
+	for i, l := range letters {
+		if l == letter {
+			return i
+		}
+	}
+	return 0
+}
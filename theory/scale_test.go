@@ -0,0 +1,125 @@
+package theory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpellScale(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		root  string
+		scale string
+		notes string
+	}{
+		{"C", "Ionian", "C D E F G A B"},
+		{"F#", "Ionian", "F# G# A# B C# D# E#"},
+		{"Bb", "Dorian", "Bb C Db Eb F G Ab"},
+		{"G", "Altered", "G Ab Bb Cb Db Eb F"},
+		{"C", "Whole Tone", "C D E F# Ab Bb"},
+		{"B", "Whole-Half Diminished", "B C# D E F G G# A#"},
+		{"Bb", "Half-Whole Diminished", "Bb B Db D E F G Ab"},
+	}
+	for _, tt := range tests {
+		root, _ := ParseNote(tt.root)
+		scale, ok := lookupScale(tt.scale)
+		if !ok {
+			t.Fatalf("lookupScale(%q) found nothing", tt.scale)
+		}
+		if got := SliceToString(SpellScale(root, scale)); got != tt.notes {
+			t.Errorf("SpellScale(%s %s) = %s, want %s", tt.root, tt.scale, got, tt.notes)
+		}
+	}
+}
+
+func TestChordScales(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+
+	root, chordDef, _ := a.ParseChordName("G7")
+	scales, err := a.ChordScales(root, chordDef, "")
+	if err != nil {
+		t.Fatalf("ChordScales(G7) returned error: %v", err)
+	}
+	if scales[0].Scale.Name != "Mixolydian" || SliceToString(scales[0].Avoid) != "C" {
+		t.Errorf("ChordScales(G7)[0] = %s avoiding %s, want Mixolydian avoiding C", scales[0].Scale.Name, SliceToString(scales[0].Avoid))
+	}
+	uses := make(map[string]string)
+	for _, s := range scales {
+		uses[s.Use] = s.Scale.Name
+	}
+	if uses["7#11"] != "Lydian Dominant" || uses["7alt"] != "Altered" {
+		t.Errorf("ChordScales(G7) suggests %q for 7#11 and %q for 7alt", uses["7#11"], uses["7alt"])
+	}
+
+	// In A minor, E7 is the dominant of the harmonic minor scale.
+	root, chordDef, _ = a.ParseChordName("E7")
+	scales, err = a.ChordScales(root, chordDef, "A Minor")
+	if err != nil {
+		t.Fatalf("ChordScales(E7, A Minor) returned error: %v", err)
+	}
+	if scales[0].Scale.Name != "Phrygian Dominant" || scales[0].Outside != 1 {
+		t.Errorf("ChordScales(E7, A Minor)[0] = %s with %d notes outside, want Phrygian Dominant with 1", scales[0].Scale.Name, scales[0].Outside)
+	}
+
+	root, chordDef, _ = a.ParseChordName("Dm7")
+	scales, _ = a.ChordScales(root, chordDef, "C Major")
+	if scales[0].Scale.Name != "Dorian" || len(scales[0].Avoid) != 0 || scales[0].Outside != 0 {
+		t.Errorf("ChordScales(Dm7, C Major)[0] = %+v, want a diatonic Dorian without avoid notes", scales[0])
+	}
+
+	if _, err := a.ChordScales(root, chordDef, "H Major"); err == nil {
+		t.Error("ChordScales with an unknown key: expected an error")
+	}
+
+	tensions := NewAnalyzer(Options{Dictionary: ChordScaleDictionary()})
+	for _, tt := range []struct{ name, first string }{{"C7#11", "Lydian Dominant"}, {"G7b13", "Mixolydian b6"}, {"Bm7b5", "Locrian"}} {
+		root, chordDef, err := tensions.ParseChordName(tt.name)
+		if err != nil {
+			t.Fatalf("ParseChordName(%q) with ChordScaleDictionary returned error: %v", tt.name, err)
+		}
+		if scales, _ := tensions.ChordScales(root, chordDef, ""); scales[0].Scale.Name != tt.first {
+			t.Errorf("ChordScales(%s)[0] = %s, want %s", tt.name, scales[0].Scale.Name, tt.first)
+		}
+	}
+	if results, _ := tensions.IdentifyStrings([]string{"C", "E", "F#", "G", "Bb"}); results[0].Matches[0].Name != "Dominant 7th Sharp 11" {
+		t.Errorf("IdentifyStrings(C E F# G Bb) with ChordScaleDictionary = %s, want Dominant 7th Sharp 11", results[0].Matches[0].Name)
+	}
+
+	custom := Chord{Name: "Seven Sharp Eleven", Suffixes: []string{"7#11"}, Intervals: []int{0, 4, 6, 7, 10}}
+	scales, _ = a.ChordScales(root, custom, "")
+	var names []string
+	for _, s := range scales {
+		names = append(names, s.Scale.Name)
+	}
+	if strings.Join(names, ", ") != "Lydian Dominant, Tritone, Half-Whole Diminished" {
+		t.Errorf("ChordScales(custom 7#11) = %v, want the scales containing it", names)
+	}
+}
+
+func TestIdentifyScales(t *testing.T) {
+	t.Parallel()
+	notes, _ := ParseNotes([]string{"A", "C", "D", "E", "G"})
+	matches := IdentifyScales(notes)
+	var exact []string
+	for _, m := range matches {
+		if m.Exact {
+			exact = append(exact, m.Root.Original+" "+m.Scale.Name)
+		}
+	}
+	if got := strings.Join(exact, ", "); got != "A Minor Pentatonic, C Major Pentatonic, D Suspended Pentatonic" {
+		t.Errorf("IdentifyScales(A C D E G) exact matches = %s", got)
+	}
+	if m := matches[3]; m.Exact || m.Root.Original != "A" || m.Scale.Name != "Blues" || SliceToString(m.Extra) != "Eb" {
+		t.Errorf("IdentifyScales(A C D E G)[3] = %s %s adding %s, want A Blues adding Eb", m.Root.Original, m.Scale.Name, SliceToString(m.Extra))
+	}
+	for _, m := range matches {
+		if m.Scale.Name == "Chromatic" && m.Root.Original != "A" {
+			t.Errorf("IdentifyScales(A C D E G) tried the chromatic scale on %s", m.Root.Original)
+		}
+	}
+
+	if ok, reason := (Scale{Name: "Major Pentatonic", Intervals: []int{0, 2, 4, 7, 9}}).Check([]int{0, 4, 5}); ok || reason != "does not contain interval 5" {
+		t.Errorf("Scale.Check = %v, %q; want false, \"does not contain interval 5\"", ok, reason)
+	}
+}
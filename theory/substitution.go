@@ -0,0 +1,290 @@
+package theory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Substitution is a reharmonization idea for one chord of a progression.
+// Chords holds the suggested chords in playing order; for a passing
+// diminished chord they are the original chord followed by the passing one.
+// Notes holds the notes of each chord. Outside counts the distinct pitch
+// classes of the chords that are not in the key the substitutions were made
+// for, and is 0 without a key.
+type Substitution struct {
+	Kind    string
+	Chords  []ChordSymbol
+	Notes   [][]Note
+	Outside int
+}
+
+// Interval patterns the substitutions are built from. Each is looked up in
+// the Analyzer's dictionary, and a substitution whose chord is missing is
+// not suggested.
+var (
+	majorTriadIntervals      = []int{0, 4, 7}
+	minorTriadIntervals      = []int{0, 3, 7}
+	diminishedTriadIntervals = []int{0, 3, 6}
+	major7Intervals          = []int{0, 4, 7, 11}
+	minor7Intervals          = []int{0, 3, 7, 10}
+	dominant7Intervals       = []int{0, 4, 7, 10}
+)
+
+// Substitutions suggests reharmonizations for each chord of a progression:
+//
+//   - Tritone Substitution: a dominant chord a tritone away (G7 -> Db7).
+//   - Relative Minor/Major: the chord a minor third below a major chord or
+//     above a minor one, in the same size (C -> Am, Am7 -> Cmaj7).
+//   - Diatonic Third: with a key, the key's chords a third above and below
+//     the chord's degree, in the same size where the dictionary has it.
+//   - Passing Diminished: before a next chord on another root, a diminished
+//     triad a semitone below its root (C -> C#dim -> Dm).
+//   - Backdoor Dominant: a dominant chord that resolves up a fourth to a major
+//     chord is replaced by the dominant a whole step below the target
+//     (G7 -> C becomes Bb7 -> C).
+//   - ii-V Expansion: a dominant chord is preceded by the minor 7th a fifth
+//     above it (G7 -> Dm7 G7).
+//
+// Roots are spelled with the signature of the key, a name such as
+// "C Major"; without a key, or in C major and A minor, tritone substitutes
+// and backdoor dominants use flats, passing chords sharps and the others
+// follow the original root. Other notes are spelled from the root with one
+// letter per degree, so Db7 is "Db F Ab Cb". A chord suggested twice is
+// kept under its first kind only. Within each chord's list, substitutions
+// with fewer notes outside the key come first.
+func (a *Analyzer) Substitutions(chords []ChordSymbol, key string) ([][]Substitution, error) {
+	var keyNotes map[int]struct{}
+	var tonic Note
+	minor := false
+	spelling := SpellingAuto
+	if key != "" {
+		found := false
+		for _, k := range a.keys {
+			if k.Name == key {
+				keyNotes, found = k.Notes, true
+				break
+			}
+		}
+		var err error
+		if tonic, minor, err = ParseKeyName(key); !found || err != nil {
+			return nil, fmt.Errorf("unknown key '%s'", key)
+		}
+		spelling = KeySpelling(tonic.Value, minor)
+	}
+
+	build := func(root Note, semitones int, defaultSpelling Spelling, intervals []int) (ChordSymbol, bool) {
+		chordDef, ok := chordWithIntervals(a.dictionary, intervals)
+		if !ok {
+			return ChordSymbol{}, false
+		}
+		s := spelling
+		if s == SpellingAuto {
+			s = defaultSpelling
+		}
+		return ChordSymbol{Root: Transpose(root, semitones, s), Suffix: chordDef.Suffix(), Chord: chordDef}, true
+	}
+
+	all := make([][]Substitution, len(chords))
+	for i, c := range chords {
+		var subs []Substitution
+		suggested := make(map[string]bool)
+		add := func(kind string, symbols ...ChordSymbol) {
+			names := make([]string, len(symbols))
+			for j, c := range symbols {
+				names[j] = c.String()
+			}
+			symbolKey := strings.Join(names, " ")
+			if suggested[symbolKey] {
+				return
+			}
+			suggested[symbolKey] = true
+			sub := Substitution{Kind: kind, Chords: symbols}
+			for _, c := range symbols {
+				sub.Notes = append(sub.Notes, spellChord(c.Root, c.Chord.Intervals))
+			}
+			subs = append(subs, sub)
+		}
+		set := intervalSetOf(c.Chord.Intervals)
+		_, hasMajorThird := set[4]
+		_, hasMinorThird := set[3]
+		_, hasMinorSeventh := set[10]
+		_, hasMajorSeventh := set[11]
+		_, hasFlatFifth := set[6]
+		dominant := hasMajorThird && hasMinorSeventh
+		seventh := len(c.Chord.Intervals) == 4
+
+		if dominant {
+			if sub, ok := build(c.Root, 6, SpellingFlats, c.Chord.Intervals); ok {
+				add("Tritone Substitution", sub)
+			}
+		}
+
+		switch {
+		case hasMajorThird && !hasMinorSeventh && (!seventh || hasMajorSeventh):
+			intervals := minorTriadIntervals
+			if seventh {
+				intervals = minor7Intervals
+			}
+			if sub, ok := build(c.Root, -3, SpellingAuto, intervals); ok {
+				add("Relative Minor", sub)
+			}
+		case hasMinorThird && !hasMajorSeventh && !hasFlatFifth:
+			intervals := majorTriadIntervals
+			if seventh {
+				intervals = major7Intervals
+			}
+			if sub, ok := build(c.Root, 3, SpellingAuto, intervals); ok {
+				add("Relative Major", sub)
+			}
+		}
+
+		if keyNotes != nil {
+			for _, step := range []int{2, -2} {
+				if sub, ok := a.diatonicChord(c.Root, step, seventh, tonic, minor, spelling); ok {
+					add("Diatonic Third", sub)
+				}
+			}
+		}
+
+		if i+1 < len(chords) {
+			next := chords[i+1]
+			if next.Root.Value != c.Root.Value {
+				if passing, ok := build(next.Root, -1, SpellingSharps, diminishedTriadIntervals); ok {
+					add("Passing Diminished", c, passing)
+				}
+			}
+			_, nextMajor := intervalSetOf(next.Chord.Intervals)[4]
+			_, nextDominant := intervalSetOf(next.Chord.Intervals)[10]
+			if dominant && nextMajor && !nextDominant && (next.Root.Value-c.Root.Value+12)%12 == 5 {
+				if sub, ok := build(next.Root, -2, SpellingFlats, dominant7Intervals); ok {
+					add("Backdoor Dominant", sub)
+				}
+			}
+		}
+
+		if dominant {
+			if ii, ok := build(c.Root, 7, SpellingAuto, minor7Intervals); ok {
+				add("ii-V Expansion", ii, c)
+			}
+		}
+
+		if keyNotes != nil {
+			for j := range subs {
+				seen := make(map[int]bool)
+				for _, notes := range subs[j].Notes {
+					for _, n := range notes {
+						if _, ok := keyNotes[n.Value]; !ok && !seen[n.Value] {
+							seen[n.Value] = true
+							subs[j].Outside++
+						}
+					}
+				}
+			}
+			sortSubstitutions(subs)
+		}
+		all[i] = subs
+	}
+	return all, nil
+}
+
+// diatonicChord builds the chord of a key on the degree a number of scale
+// steps from the root's degree, stacking thirds of the key's scale. A 7th
+// chord is built when seventh is set and the dictionary has its quality;
+// otherwise a triad. It fails when the root is not in the key or the
+// dictionary lacks the quality.
+func (a *Analyzer) diatonicChord(root Note, step int, seventh bool, tonic Note, minor bool, spelling Spelling) (ChordSymbol, bool) {
+	steps := majorSteps
+	if minor {
+		steps = minorSteps
+	}
+	semitones := ((root.Value-tonic.Value)%12 + 12) % 12
+	degree := -1
+	for i, s := range steps {
+		if s == semitones {
+			degree = i
+		}
+	}
+	if degree < 0 {
+		return ChordSymbol{}, false
+	}
+	degree = (degree + step + 7) % 7
+
+	size := 3
+	if seventh {
+		size = 4
+	}
+	for ; size >= 3; size-- {
+		intervals := make([]int, size)
+		for i := range intervals {
+			d := degree + 2*i
+			intervals[i] = ((steps[d%7] - steps[degree]) + 12) % 12
+		}
+		if chordDef, ok := chordWithIntervals(a.dictionary, intervals); ok {
+			value := (tonic.Value + steps[degree]) % 12
+			newRoot := Note{Original: NoteName(value, spelling == SpellingFlats), Value: value}
+			return ChordSymbol{Root: newRoot, Suffix: chordDef.Suffix(), Chord: chordDef}, true
+		}
+	}
+	return ChordSymbol{}, false
+}
+
+// chordLetterSteps gives the letter distance from the root used to spell
+// each chord interval: b9, 9, b3, 3, 11, b5, 5, #5, 6, b7, 7.
+var chordLetterSteps = [12]int{0, 1, 1, 2, 2, 3, 4, 4, 4, 5, 6, 6}
+
+// spellChord spells the notes of a chord from its root with one letter per
+// degree, as in "Db F Ab Cb". The minor third is a #9 next to a major third,
+// and with a perfect fifth the tritone is a #11 and the minor sixth a b13.
+func spellChord(root Note, intervals []int) []Note {
+	set := intervalSetOf(intervals)
+	_, majorThird := set[4]
+	_, fifth := set[7]
+	letterIndex := rootLetterIndex(root)
+	notes := make([]Note, len(intervals))
+	for i, interval := range intervals {
+		interval %= 12
+		step := chordLetterSteps[interval]
+		switch {
+		case interval == 3 && majorThird:
+			step = 1
+		case interval == 6 && fifth:
+			step = 3
+		case interval == 8 && fifth:
+			step = 5
+		}
+		notes[i] = letterNote(letters[(letterIndex+step)%7], (root.Value+interval)%12, root)
+	}
+	notes[0] = root
+	return notes
+}
+
+// chordWithIntervals finds the dictionary chord with exactly the given
+// intervals, in any order.
+func chordWithIntervals(dict []Chord, intervals []int) (Chord, bool) {
+	want := intervalSetOf(intervals)
+	for _, c := range dict {
+		if len(c.Intervals) != len(want) {
+			continue
+		}
+		ok := true
+		for _, i := range c.Intervals {
+			if _, found := want[i]; !found {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return c, true
+		}
+	}
+	return Chord{}, false
+}
+
+// sortSubstitutions puts substitutions with fewer notes outside the key
+// first, keeping the order of the kinds between equals.
+func sortSubstitutions(subs []Substitution) {
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].Outside < subs[j].Outside
+	})
+}
@@ -0,0 +1,101 @@
+package theory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubstitutions(t *testing.T) {
+	t.Parallel()
+	a := NewAnalyzer(Options{})
+
+	var chords []ChordSymbol
+	for _, name := range []string{"Dm7", "G7", "C"} {
+		symbol, err := a.ParseChordSymbol(name)
+		if err != nil {
+			t.Fatalf("ParseChordSymbol(%q) returned error: %v", name, err)
+		}
+		chords = append(chords, symbol)
+	}
+	subs, err := a.Substitutions(chords, "C Major")
+	if err != nil {
+		t.Fatalf("Substitutions returned error: %v", err)
+	}
+
+	describe := func(s Substitution) string {
+		names := make([]string, len(s.Chords))
+		for i, c := range s.Chords {
+			names[i] = c.String()
+		}
+		return s.Kind + ": " + strings.Join(names, " ")
+	}
+	find := func(list []Substitution, want string) (Substitution, bool) {
+		for _, s := range list {
+			if describe(s) == want {
+				return s, true
+			}
+		}
+		return Substitution{}, false
+	}
+
+	tests := []struct {
+		chord   int
+		want    string
+		notes   string
+		outside int
+	}{
+		{0, "Relative Major: Fmaj7", "F A C E", 0},
+		{0, "Diatonic Third: Bdim", "B D F", 0},
+		{0, "Passing Diminished: Dm7 F#dim", "D F A C | F# A C", 1},
+		{1, "Tritone Substitution: Db7", "Db F Ab Cb", 2},
+		{1, "Backdoor Dominant: Bb7", "Bb D F Ab", 2},
+		{1, "ii-V Expansion: Dm7 G7", "D F A C | G B D F", 0},
+		{2, "Relative Minor: Am", "A C E", 0},
+		{2, "Diatonic Third: Em", "E G B", 0},
+	}
+	for _, tt := range tests {
+		s, ok := find(subs[tt.chord], tt.want)
+		if !ok {
+			t.Errorf("Substitutions for chord %d: missing %q", tt.chord+1, tt.want)
+			continue
+		}
+		notes := make([]string, len(s.Notes))
+		for i, n := range s.Notes {
+			notes[i] = SliceToString(n)
+		}
+		if got := strings.Join(notes, " | "); got != tt.notes {
+			t.Errorf("%s: notes %s, want %s", tt.want, got, tt.notes)
+		}
+		if s.Outside != tt.outside {
+			t.Errorf("%s: %d notes outside the key, want %d", tt.want, s.Outside, tt.outside)
+		}
+	}
+	for i, list := range subs {
+		seen := make(map[string]bool)
+		for _, s := range list {
+			chords := strings.TrimPrefix(describe(s), s.Kind+": ")
+			if seen[chords] {
+				t.Errorf("Substitutions for chord %d suggest %s twice", i+1, chords)
+			}
+			seen[chords] = true
+		}
+		for j := 1; j < len(list); j++ {
+			if list[j].Outside < list[j-1].Outside {
+				t.Errorf("Substitutions for chord %d are not sorted by fit: %s before %s", i+1, describe(list[j-1]), describe(list[j]))
+			}
+		}
+	}
+
+	// Without a key there are no diatonic thirds and no backdoor dominant
+	// for a chord at the end of the progression.
+	subs, _ = a.Substitutions(chords[1:2], "")
+	for _, s := range subs[0] {
+		if s.Kind == "Diatonic Third" || s.Kind == "Backdoor Dominant" || s.Outside != 0 {
+			t.Errorf("Substitutions(G7) without a key suggested %s with %d notes outside", describe(s), s.Outside)
+		}
+	}
+
+	if _, err := a.Substitutions(chords, "H Major"); err == nil {
+		t.Error("Substitutions with an unknown key: expected an error")
+	}
+}
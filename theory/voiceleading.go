@@ -0,0 +1,155 @@
+package theory
+
+import "sort"
+
+// VoiceMove is one voice moving between MIDI keys from one chord to the next.
+type VoiceMove struct {
+	From      int
+	To        int
+	Semitones int // To - From
+}
+
+// VoiceLeading describes the smoothest move from a voiced chord to the pitch
+// classes of the next one. Common lists the pitch classes the chords share.
+// Moves has one entry per voice, lowest starting key first: when the next chord has more notes, some
+// voices split; when it has fewer, some voices meet on the same pitch class.
+// Distance is the total motion in semitones, and Voicing the resulting
+// MIDI keys of the next chord, lowest first, with voices that meet on the
+// same key sounding once.
+type VoiceLeading struct {
+	Common   []Note
+	Moves    []VoiceMove
+	Distance int
+	Voicing  []int
+}
+
+// LeadVoices finds the voice leading from the MIDI keys of a chord to the
+// notes of the next chord with the least total motion. Every voice moves to
+// the nearest octave of its target, downward on a tritone, and every note
+// of both chords takes part. Between equal totals the first assignment in
+// the order of the voices and notes wins. Voices that would leave the MIDI
+// range 0-127 move an octave the other way instead, so a long progression
+// cannot drift out of it.
+func LeadVoices(from []int, to []Note) VoiceLeading {
+	var vl VoiceLeading
+	if len(from) == 0 || len(to) == 0 {
+		return vl
+	}
+	fromSet := make(map[int]struct{})
+	for _, key := range from {
+		fromSet[key%12] = struct{}{}
+	}
+	for _, n := range to {
+		if _, ok := fromSet[n.Value]; ok {
+			vl.Common = append(vl.Common, n)
+		}
+	}
+
+	cost := func(voice, note int) int {
+		return abs(nearestMotion(from[voice], to[note].Value))
+	}
+	var pairs [][2]int
+	if len(from) >= len(to) {
+		for voice, note := range surjection(len(from), len(to), cost) {
+			pairs = append(pairs, [2]int{voice, note})
+		}
+	} else {
+		for note, voice := range surjection(len(to), len(from), func(n, v int) int { return cost(v, n) }) {
+			pairs = append(pairs, [2]int{voice, note})
+		}
+	}
+
+	for _, p := range pairs {
+		key := from[p[0]] + nearestMotion(from[p[0]], to[p[1]].Value)
+		for key < 0 {
+			key += 12
+		}
+		for key > 127 {
+			key -= 12
+		}
+		move := VoiceMove{From: from[p[0]], To: key, Semitones: key - from[p[0]]}
+		vl.Moves = append(vl.Moves, move)
+		vl.Distance += abs(move.Semitones)
+	}
+	sort.SliceStable(vl.Moves, func(i, j int) bool {
+		if vl.Moves[i].From != vl.Moves[j].From {
+			return vl.Moves[i].From < vl.Moves[j].From
+		}
+		return vl.Moves[i].To < vl.Moves[j].To
+	})
+	seen := make(map[int]bool)
+	for _, m := range vl.Moves {
+		if !seen[m.To] {
+			seen[m.To] = true
+			vl.Voicing = append(vl.Voicing, m.To)
+		}
+	}
+	sort.Ints(vl.Voicing)
+	return vl
+}
+
+// nearestMotion returns the signed semitones from a MIDI key to the nearest
+// key of a pitch class, from -6 to +5.
+func nearestMotion(key, pitchClass int) int {
+	motion := ((pitchClass-key)%12 + 12) % 12
+	if motion >= 6 {
+		motion -= 12
+	}
+	return motion
+}
+
+// surjection maps each of n domain elements to one of m codomain elements,
+// using every codomain element at least once, with the least total cost.
+func surjection(n, m int, cost func(i, j int) int) []int {
+	best := make([]int, n)
+	bestCost := -1
+	current := make([]int, n)
+	used := make([]int, m)
+	uncovered := m
+
+	// minCost is a lower bound for the elements not yet assigned.
+	minCost := make([]int, n+1)
+	for i := n - 1; i >= 0; i-- {
+		lowest := cost(i, 0)
+		for j := 1; j < m; j++ {
+			lowest = min(lowest, cost(i, j))
+		}
+		minCost[i] = minCost[i+1] + lowest
+	}
+
+	var search func(i, total int)
+	search = func(i, total int) {
+		if bestCost >= 0 && total+minCost[i] >= bestCost {
+			return
+		}
+		if n-i < uncovered {
+			return
+		}
+		if i == n {
+			bestCost = total
+			copy(best, current)
+			return
+		}
+		for j := 0; j < m; j++ {
+			current[i] = j
+			if used[j] == 0 {
+				uncovered--
+			}
+			used[j]++
+			search(i+1, total+cost(i, j))
+			used[j]--
+			if used[j] == 0 {
+				uncovered++
+			}
+		}
+	}
+	search(0, 0)
+	return best
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,61 @@
+package theory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLeadVoices(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		from     []int
+		to       []string
+		common   string
+		motion   []int
+		distance int
+		voicing  []int
+	}{
+		// C4 E4 G4 to F: C holds, E and G move up.
+		{"triad to triad", []int{60, 64, 67}, []string{"F", "A", "C"}, "C", []int{0, 1, 2}, 3, []int{60, 65, 69}},
+		// F4 A4 C5 to G7: one voice splits to cover the fourth note.
+		{"triad to seventh", []int{65, 69, 72}, []string{"G", "B", "D", "F"}, "F", []int{0, -2, -1, 2}, 5, []int{65, 67, 71, 74}},
+		// G3 B3 D4 F4 to C: two voices meet on C.
+		{"seventh to triad", []int{55, 59, 62, 65}, []string{"C", "E", "G"}, "G", []int{0, 1, -2, -1}, 4, []int{55, 60, 64}},
+		// A tritone moves down.
+		{"tritone", []int{60}, []string{"F#"}, "", []int{-6}, 6, []int{54}},
+		// Voices stay within the MIDI range.
+		{"lowest key", []int{0, 4}, []string{"B", "D#"}, "", []int{11, -1}, 12, []int{3, 11}},
+		{"highest key", []int{127}, []string{"G#"}, "", []int{-11}, 11, []int{116}},
+	}
+	for _, tt := range tests {
+		to, _ := ParseNotes(tt.to)
+		vl := LeadVoices(tt.from, to)
+		var motion []int
+		for _, m := range vl.Moves {
+			motion = append(motion, m.Semitones)
+		}
+		if SliceToString(vl.Common) != tt.common || !reflect.DeepEqual(motion, tt.motion) ||
+			vl.Distance != tt.distance || !reflect.DeepEqual(vl.Voicing, tt.voicing) {
+			t.Errorf("%s: LeadVoices = common %q, motion %v, distance %d, voicing %v; want %q, %v, %d, %v",
+				tt.name, SliceToString(vl.Common), motion, vl.Distance, vl.Voicing, tt.common, tt.motion, tt.distance, tt.voicing)
+		}
+	}
+
+	// A long descending progression folds back instead of leaving 0-127.
+	keys := []int{60, 64, 67}
+	for step := 1; step <= 120; step++ {
+		root := ((-step)%12 + 12) % 12
+		to := []Note{{Value: root}, {Value: (root + 4) % 12}, {Value: (root + 7) % 12}}
+		keys = LeadVoices(keys, to).Voicing
+		for _, key := range keys {
+			if key < 0 || key > 127 {
+				t.Fatalf("descending step %d: voicing %v leaves the MIDI range", step, keys)
+			}
+		}
+	}
+
+	if vl := LeadVoices(nil, nil); vl.Distance != 0 || vl.Moves != nil {
+		t.Errorf("LeadVoices(nil, nil) = %+v, want the zero value", vl)
+	}
+}
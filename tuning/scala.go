@@ -0,0 +1,146 @@
+package tuning
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseScale reads a Scala .scl file: a description line, the number of
+// degrees, then one pitch per degree, either in cents (with a '.') or as a
+// ratio such as 5/4 or 2. Lines starting with '!' are comments.
+func ParseScale(r io.Reader) (Scale, error) {
+	lines, err := scalaLines(r, true)
+	if err != nil {
+		return Scale{}, err
+	}
+	if len(lines) < 2 {
+		return Scale{}, fmt.Errorf("missing description or number of notes")
+	}
+
+	scale := Scale{Description: strings.TrimSpace(lines[0].text)}
+	count, err := strconv.Atoi(firstField(lines[1].text))
+	if err != nil || count < 1 {
+		return Scale{}, fmt.Errorf("line %d: invalid number of notes '%s'", lines[1].number, lines[1].text)
+	}
+	if len(lines)-2 < count {
+		return Scale{}, fmt.Errorf("expected %d notes, found %d", count, len(lines)-2)
+	}
+	for _, l := range lines[2 : 2+count] {
+		cents, err := parsePitch(firstField(l.text))
+		if err != nil {
+			return Scale{}, fmt.Errorf("line %d: %v", l.number, err)
+		}
+		scale.Cents = append(scale.Cents, cents)
+	}
+	return scale, nil
+}
+
+// parsePitch converts a Scala pitch into cents.
+func parsePitch(s string) (float64, error) {
+	if strings.Contains(s, ".") {
+		cents, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cents value '%s'", s)
+		}
+		return cents, nil
+	}
+	num, den, found := strings.Cut(s, "/")
+	if !found {
+		den = "1"
+	}
+	n, errNum := strconv.Atoi(num)
+	d, errDen := strconv.Atoi(den)
+	if errNum != nil || errDen != nil || n <= 0 || d <= 0 {
+		return 0, fmt.Errorf("invalid ratio '%s'", s)
+	}
+	return Ratio{n, d}.Cents(), nil
+}
+
+// ParseMapping reads a Scala .kbm keyboard mapping: the map size, first and
+// last keys, middle key, reference key, reference frequency and octave
+// degree, then one degree per key of the map, or 'x' for an unmapped key.
+// Missing map entries are unmapped.
+func ParseMapping(r io.Reader) (Mapping, error) {
+	lines, err := scalaLines(r, false)
+	if err != nil {
+		return Mapping{}, err
+	}
+	if len(lines) < 7 {
+		return Mapping{}, fmt.Errorf("expected 7 header values, found %d", len(lines))
+	}
+
+	var header [7]float64
+	for i := range header {
+		v, err := strconv.ParseFloat(firstField(lines[i].text), 64)
+		if err != nil || (i != 5 && v != float64(int(v))) {
+			return Mapping{}, fmt.Errorf("line %d: invalid value '%s'", lines[i].number, lines[i].text)
+		}
+		header[i] = v
+	}
+	m := Mapping{
+		Size:         int(header[0]),
+		First:        int(header[1]),
+		Last:         int(header[2]),
+		Middle:       int(header[3]),
+		Reference:    int(header[4]),
+		Frequency:    header[5],
+		OctaveDegree: int(header[6]),
+	}
+	if m.Size < 0 || m.First < 0 || m.Last > 127 || m.First > m.Last || m.OctaveDegree < 0 {
+		return Mapping{}, fmt.Errorf("invalid keyboard mapping header")
+	}
+
+	entries := lines[7:]
+	if len(entries) > m.Size {
+		entries = entries[:m.Size]
+	}
+	m.Degrees = make([]int, m.Size)
+	for i := range m.Degrees {
+		m.Degrees[i] = -1
+	}
+	for i, l := range entries {
+		field := firstField(l.text)
+		if field == "x" || field == "X" {
+			continue
+		}
+		degree, err := strconv.Atoi(field)
+		if err != nil || degree < 0 {
+			return Mapping{}, fmt.Errorf("line %d: invalid degree '%s'", l.number, l.text)
+		}
+		m.Degrees[i] = degree
+	}
+	return m, nil
+}
+
+type scalaLine struct {
+	number int
+	text   string
+}
+
+// scalaLines returns the lines that are not comments, skipping blank lines
+// unless keepBlank is set (a .scl description may be empty).
+func scalaLines(r io.Reader, keepBlank bool) ([]scalaLine, error) {
+	var lines []scalaLine
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(text, "!") {
+			continue
+		}
+		if strings.TrimSpace(text) == "" && (!keepBlank || len(lines) > 0) {
+			continue
+		}
+		lines = append(lines, scalaLine{number, text})
+	}
+	return lines, scanner.Err()
+}
+
+func firstField(s string) string {
+	if fields := strings.Fields(s); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
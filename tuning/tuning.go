@@ -0,0 +1,256 @@
+// Package tuning gives the frequencies of MIDI keys under historical
+// temperaments and Scala scales, and measures chord intervals against pure
+// ratios.
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Scale is a scale in the Scala sense: the pitches of its degrees above the
+// tonic, in cents. The last pitch is the period, usually the octave.
+type Scale struct {
+	Description string
+	Cents       []float64
+}
+
+// degreeCents returns the pitch of a scale degree in cents above degree 0,
+// counting whole periods for degrees outside the first.
+func (s Scale) degreeCents(degree int) float64 {
+	n := len(s.Cents)
+	period := s.Cents[n-1]
+	octaves, step := floorDiv(degree, n), mod(degree, n)
+	cents := float64(octaves) * period
+	if step > 0 {
+		cents += s.Cents[step-1]
+	}
+	return cents
+}
+
+// Mapping assigns scale degrees to MIDI keys, as a Scala .kbm file does.
+type Mapping struct {
+	// Size is the number of keys before the pattern repeats; 0 maps
+	// successive keys to successive degrees.
+	Size int
+	// First and Last are the lowest and highest keys that are mapped.
+	First, Last int
+	// Middle is the key that plays degree 0.
+	Middle int
+	// Reference is the key tuned to Frequency Hz.
+	Reference int
+	Frequency float64
+	// OctaveDegree is the degree reached when the pattern repeats; 0 uses
+	// the number of degrees in the scale.
+	OctaveDegree int
+	// Degrees holds the degree of each of the Size keys from Middle, or -1
+	// for keys that are not mapped.
+	Degrees []int
+}
+
+// LinearMapping maps successive keys to successive degrees with the tonic,
+// a pitch class, on its key in octave 4 and A4 tuned to a4 Hz.
+func LinearMapping(tonic int, a4 float64) Mapping {
+	return Mapping{First: 0, Last: 127, Middle: 60 + mod(tonic, 12), Reference: 69, Frequency: a4}
+}
+
+// degree returns the scale degree of a key, counted from Middle.
+func (m Mapping) degree(key, scaleSize int) (int, bool) {
+	if key < m.First || key > m.Last {
+		return 0, false
+	}
+	offset := key - m.Middle
+	if m.Size == 0 {
+		return offset, true
+	}
+	index := mod(offset, m.Size)
+	if index >= len(m.Degrees) || m.Degrees[index] < 0 {
+		return 0, false
+	}
+	octaveDegree := m.OctaveDegree
+	if octaveDegree == 0 {
+		octaveDegree = scaleSize
+	}
+	return floorDiv(offset, m.Size)*octaveDegree + m.Degrees[index], true
+}
+
+// Tuning is a scale laid out on the keyboard.
+type Tuning struct {
+	Name    string
+	Scale   Scale
+	Mapping Mapping
+}
+
+// New returns a tuning, checking that the scale has degrees and that the
+// mapping's reference key is mapped.
+func New(name string, scale Scale, mapping Mapping) (*Tuning, error) {
+	if len(scale.Cents) == 0 {
+		return nil, fmt.Errorf("scale has no degrees")
+	}
+	if mapping.Frequency <= 0 {
+		return nil, fmt.Errorf("invalid reference frequency %g Hz", mapping.Frequency)
+	}
+	if _, ok := mapping.degree(mapping.Reference, len(scale.Cents)); !ok {
+		return nil, fmt.Errorf("reference key %d is not mapped", mapping.Reference)
+	}
+	return &Tuning{Name: name, Scale: scale, Mapping: mapping}, nil
+}
+
+// Frequency returns the frequency of a MIDI key in Hz, or false if the key is
+// not mapped.
+func (t *Tuning) Frequency(key int) (float64, bool) {
+	degree, ok := t.Mapping.degree(key, len(t.Scale.Cents))
+	if !ok {
+		return 0, false
+	}
+	reference, _ := t.Mapping.degree(t.Mapping.Reference, len(t.Scale.Cents))
+	cents := t.Scale.degreeCents(degree) - t.Scale.degreeCents(reference)
+	return t.Mapping.Frequency * math.Pow(2, cents/1200), true
+}
+
+// --- Built-in Temperaments ---
+
+// Builtins lists the names accepted by Builtin.
+var Builtins = []string{"12tet", "just", "pythagorean", "meantone"}
+
+var builtinTitles = map[string]string{
+	"12tet":       "12-TET",
+	"just":        "Just intonation",
+	"pythagorean": "Pythagorean",
+	"meantone":    "Quarter-comma meantone",
+}
+
+// justRatios are the five-limit ratios of each semitone above the tonic, the
+// octave last. They also serve as the pure intervals of PureRatio.
+var justRatios = [12]Ratio{
+	{16, 15}, {9, 8}, {6, 5}, {5, 4}, {4, 3}, {45, 32},
+	{3, 2}, {8, 5}, {5, 3}, {9, 5}, {15, 8}, {2, 1},
+}
+
+// pythagoreanRatios stack pure fifths from Db to F#.
+var pythagoreanRatios = [12]Ratio{
+	{256, 243}, {9, 8}, {32, 27}, {81, 64}, {4, 3}, {729, 512},
+	{3, 2}, {128, 81}, {27, 16}, {16, 9}, {243, 128}, {2, 1},
+}
+
+// Builtin returns a twelve-note temperament built on the tonic, a pitch
+// class, with A4 tuned to a4 Hz. Meantone stacks fifths narrowed by a
+// quarter of the syntonic comma from Eb to G#.
+func Builtin(name string, tonic int, a4 float64) (*Tuning, error) {
+	var cents []float64
+	switch name {
+	case "12tet":
+		for i := 1; i <= 12; i++ {
+			cents = append(cents, float64(100*i))
+		}
+	case "just":
+		for _, r := range justRatios {
+			cents = append(cents, r.Cents())
+		}
+	case "pythagorean":
+		for _, r := range pythagoreanRatios {
+			cents = append(cents, r.Cents())
+		}
+	case "meantone":
+		fifth := Ratio{3, 2}.Cents() - Ratio{81, 80}.Cents()/4
+		cents = make([]float64, 12)
+		for n := -3; n <= 8; n++ {
+			if pc := mod(7*n, 12); pc > 0 {
+				cents[pc-1] = math.Mod(float64(n)*fifth+1200*4, 1200)
+			}
+		}
+		cents[11] = 1200
+	default:
+		return nil, fmt.Errorf("unknown tuning '%s' (expected %s or a .scl file)", name, strings.Join(Builtins, ", "))
+	}
+	return New(builtinTitles[name], Scale{Description: builtinTitles[name], Cents: cents}, LinearMapping(tonic, a4))
+}
+
+// --- Intervals ---
+
+// Ratio is a frequency ratio Num/Den.
+type Ratio struct {
+	Num, Den int
+}
+
+// Cents returns the size of the ratio in cents.
+func (r Ratio) Cents() float64 {
+	return 1200 * math.Log2(float64(r.Num)/float64(r.Den))
+}
+
+func (r Ratio) String() string {
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
+// PureRatio returns the just ratio of an interval in semitones, with
+// compound intervals widened by whole octaves.
+func PureRatio(semitones int) Ratio {
+	octaves, step := floorDiv(semitones, 12), mod(semitones, 12)
+	r := Ratio{1, 1}
+	if step > 0 {
+		r = justRatios[step-1]
+	}
+	for ; octaves > 0; octaves-- {
+		if r.Den%2 == 0 {
+			r.Den /= 2
+		} else {
+			r.Num *= 2
+		}
+	}
+	return r
+}
+
+// Interval is the interval between two keys of a chord in a tuning.
+type Interval struct {
+	Lower, Upper int // MIDI keys
+	Cents        float64
+	Pure         Ratio
+	// Deviation is Cents minus the size of Pure.
+	Deviation float64
+	// Beats is the rate per second at which the partials that coincide in
+	// the pure interval beat against each other.
+	Beats float64
+}
+
+// Intervals returns the interval between every pair of keys, lower key
+// first, in the order the keys are given.
+func (t *Tuning) Intervals(keys []int) ([]Interval, error) {
+	freqs := make([]float64, len(keys))
+	for i, key := range keys {
+		f, ok := t.Frequency(key)
+		if !ok {
+			return nil, fmt.Errorf("key %d is not mapped in %s", key, t.Name)
+		}
+		freqs[i] = f
+	}
+
+	var intervals []Interval
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			lo, hi := i, j
+			if keys[hi] < keys[lo] {
+				lo, hi = hi, lo
+			}
+			pure := PureRatio(keys[hi] - keys[lo])
+			cents := 1200 * math.Log2(freqs[hi]/freqs[lo])
+			intervals = append(intervals, Interval{
+				Lower:     keys[lo],
+				Upper:     keys[hi],
+				Cents:     cents,
+				Pure:      pure,
+				Deviation: cents - pure.Cents(),
+				Beats:     math.Abs(float64(pure.Den)*freqs[hi] - float64(pure.Num)*freqs[lo]),
+			})
+		}
+	}
+	return intervals, nil
+}
+
+func mod(a, n int) int {
+	return (a%n + n) % n
+}
+
+func floorDiv(a, n int) int {
+	return (a - mod(a, n)) / n
+}
@@ -0,0 +1,200 @@
+package tuning
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinFrequencies(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		tonic    int
+		key      int
+		expected float64
+	}{
+		{"12tet", 0, 69, 440},
+		{"12tet", 0, 60, 261.626},
+		{"12tet", 0, 81, 880},
+		{"just", 0, 60, 264},
+		{"just", 0, 64, 330},
+		{"just", 0, 67, 396},
+		{"just", 9, 64, 330},
+		{"just", 9, 61, 275},
+		{"pythagorean", 0, 60, 260.741},
+		{"pythagorean", 0, 67, 391.111},
+		{"meantone", 0, 60, 263.181},
+		{"meantone", 0, 64, 328.977},
+	}
+	for _, tt := range tests {
+		tuning, err := Builtin(tt.name, tt.tonic, 440)
+		if err != nil {
+			t.Fatalf("Builtin(%s) returned error: %v", tt.name, err)
+		}
+		if got, ok := tuning.Frequency(tt.key); !ok || math.Abs(got-tt.expected) > 0.001 {
+			t.Errorf("%s on %d: Frequency(%d) = %.3f, want %.3f", tt.name, tt.tonic, tt.key, got, tt.expected)
+		}
+	}
+
+	if _, err := Builtin("werckmeister", 0, 440); err == nil {
+		t.Error("Builtin(werckmeister): expected an error")
+	}
+}
+
+func TestIntervals(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		deviation, beats []float64
+	}{
+		// C4-E4, C4-G4, E4-G4
+		{"just", []float64{0, 0, 0}, []float64{0, 0, 0}},
+		{"12tet", []float64{13.69, -1.96, -15.64}, []float64{10.38, 0.89, 17.79}},
+		{"meantone", []float64{0, -5.38, -5.38}, []float64{0, 2.45, 6.12}},
+	}
+	for _, tt := range tests {
+		tuning, _ := Builtin(tt.name, 0, 440)
+		intervals, err := tuning.Intervals([]int{60, 64, 67})
+		if err != nil {
+			t.Fatalf("%s: Intervals() returned error: %v", tt.name, err)
+		}
+		if len(intervals) != 3 {
+			t.Fatalf("%s: Intervals() returned %d intervals, want 3", tt.name, len(intervals))
+		}
+		for i, iv := range intervals {
+			if math.Abs(iv.Deviation-tt.deviation[i]) > 0.01 || math.Abs(iv.Beats-tt.beats[i]) > 0.01 {
+				t.Errorf("%s: interval %d-%d = %+.2f cents, %.2f beats/s; want %+.2f cents, %.2f beats/s",
+					tt.name, iv.Lower, iv.Upper, iv.Deviation, iv.Beats, tt.deviation[i], tt.beats[i])
+			}
+		}
+	}
+	if intervals, _ := mustBuiltin(t).Intervals([]int{67, 60}); intervals[0].Lower != 60 || intervals[0].Pure.String() != "3/2" {
+		t.Errorf("Intervals(67, 60) = %+v, want 60-67 against 3/2", intervals[0])
+	}
+}
+
+func mustBuiltin(t *testing.T) *Tuning {
+	tuning, err := Builtin("12tet", 0, 440)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tuning
+}
+
+func TestPureRatio(t *testing.T) {
+	t.Parallel()
+	tests := map[int]string{0: "1/1", 4: "5/4", 7: "3/2", 10: "9/5", 12: "2/1", 16: "5/2", 19: "3/1", 26: "9/2"}
+	for semitones, expected := range tests {
+		if got := PureRatio(semitones).String(); got != expected {
+			t.Errorf("PureRatio(%d) = %s, want %s", semitones, got, expected)
+		}
+	}
+}
+
+func TestParseScale(t *testing.T) {
+	t.Parallel()
+	input := `! 19edo.scl
+!
+19-tone equal temperament
+ 19
+!
+63.15789
+126.31579 comment after the pitch
+189.47368
+252.63158
+315.78947
+378.94737
+442.10526
+505.26316
+568.42105
+631.57895
+694.73684
+757.89474
+821.05263
+884.21053
+947.36842
+1010.52632
+1073.68421
+1136.84211
+2/1
+`
+	scale, err := ParseScale(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseScale() returned error: %v", err)
+	}
+	if scale.Description != "19-tone equal temperament" || len(scale.Cents) != 19 || scale.Cents[18] != 1200 {
+		t.Errorf("ParseScale() = %q with %d notes ending %v", scale.Description, len(scale.Cents), scale.Cents[len(scale.Cents)-1])
+	}
+
+	tuning, err := New("19edo", scale, LinearMapping(0, 440))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if f, _ := tuning.Frequency(69 + 19); math.Abs(f-880) > 0.001 {
+		t.Errorf("Frequency(88) = %.3f, want 880", f)
+	}
+
+	for _, input := range []string{"", "desc\n", "desc\nthree\n", "desc\n2\n100.0\n", "desc\n1\n-3/2\n", "desc\n1\nabc\n"} {
+		if _, err := ParseScale(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseScale(%q): expected an error", input)
+		}
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	t.Parallel()
+	// A white-key mapping of a seven-note scale, with A4 at 432 Hz.
+	input := `! white.kbm
+12
+0
+127
+60
+69
+432.0
+7
+! Mapping
+0
+x
+1
+x
+2
+3
+x
+4
+x
+5
+x
+6
+`
+	m, err := ParseMapping(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseMapping() returned error: %v", err)
+	}
+	scale, _ := ParseScale(strings.NewReader("just major\n7\n9/8\n5/4\n4/3\n3/2\n5/3\n15/8\n2/1\n"))
+	tuning, err := New("just major", scale, m)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	tests := map[int]float64{69: 432, 60: 259.2, 64: 324, 72: 518.4, 48: 129.6}
+	for key, expected := range tests {
+		if got, ok := tuning.Frequency(key); !ok || math.Abs(got-expected) > 0.001 {
+			t.Errorf("Frequency(%d) = %.3f, want %.3f", key, got, expected)
+		}
+	}
+	if _, ok := tuning.Frequency(61); ok {
+		t.Error("Frequency(61): expected the key to be unmapped")
+	}
+	if _, err := tuning.Intervals([]int{60, 61}); err == nil {
+		t.Error("Intervals() with an unmapped key: expected an error")
+	}
+
+	if _, err := ParseMapping(strings.NewReader("12\n0\n127\n60\n")); err == nil {
+		t.Error("ParseMapping() with a short header: expected an error")
+	}
+	m.Reference = 61
+	if _, err := New("bad", scale, m); err == nil {
+		t.Error("New() with an unmapped reference key: expected an error")
+	}
+}
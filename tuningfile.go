@@ -0,0 +1,198 @@
+// tuningfile.go
+// This file contains the --tuning flags of identify and spell, which show
+// each chord note's frequency and each interval's deviation from its pure
+// ratio under built-in temperaments or Scala .scl/.kbm files.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cordelia/midi"
+	"cordelia/theory"
+	"cordelia/tuning"
+)
+
+// tuningFlags holds the flags that select the tunings to show.
+type tuningFlags struct {
+	tunings *string
+	kbm     *string
+	tonic   *string
+}
+
+// addTuningFlags defines the tuning flags on a flag set. The A4 reference is
+// left to the caller, which may share it with other flags.
+func addTuningFlags(fs *flag.FlagSet) *tuningFlags {
+	return &tuningFlags{
+		tunings: fs.String("tuning", "", "Comma-separated tunings to show: 12tet, just, pythagorean, meantone, all, or a Scala .scl file."),
+		kbm:     fs.String("kbm", "", "Scala .kbm keyboard mapping for .scl tunings."),
+		tonic:   fs.String("tonic", "C", "Tonic of the built-in tunings and of .scl tunings without --kbm."),
+	}
+}
+
+func (t *tuningFlags) enabled() bool {
+	return *t.tunings != ""
+}
+
+// load builds every requested tuning, with A4 tuned to a4 Hz unless a .kbm
+// file sets the reference. The tonic is read and labelled in notation n.
+func (t *tuningFlags) load(n theory.Notation, a4 float64) ([]*tuning.Tuning, error) {
+	tonic, err := n.ParseNote(*t.tonic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --tonic: %v", err)
+	}
+
+	var names []string
+	hasScala := false
+	for _, name := range strings.Split(*t.tunings, ",") {
+		if name = strings.TrimSpace(name); name == "all" {
+			names = append(names, tuning.Builtins...)
+		} else {
+			names = append(names, name)
+			hasScala = hasScala || isScalaFile(name)
+		}
+	}
+	if *t.kbm != "" && !hasScala {
+		return nil, fmt.Errorf("--kbm requires a .scl file in --tuning")
+	}
+
+	var tunings []*tuning.Tuning
+	for _, name := range names {
+		if !isScalaFile(name) {
+			tn, err := tuning.Builtin(strings.ToLower(name), tonic.Value, a4)
+			if err != nil {
+				return nil, err
+			}
+			tn.Name = fmt.Sprintf("%s on %s, A4 = %g Hz", tn.Name, n.NoteName(tonic), a4)
+			tunings = append(tunings, tn)
+			continue
+		}
+		tn, err := t.loadScala(name, n.NoteName(tonic), tonic.Value, a4)
+		if err != nil {
+			return nil, err
+		}
+		tunings = append(tunings, tn)
+	}
+	return tunings, nil
+}
+
+// loadScala reads a .scl file and lays it out with the --kbm mapping, or
+// linearly from the tonic.
+func (t *tuningFlags) loadScala(filename, tonicName string, tonic int, a4 float64) (*tuning.Tuning, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("File not found: %s", filename)
+	}
+	defer file.Close()
+	scale, err := tuning.ParseScale(file)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %v", filename, err)
+	}
+
+	name := scale.Description
+	if name == "" {
+		name = filepath.Base(filename)
+	}
+	mapping := tuning.LinearMapping(tonic, a4)
+	label := fmt.Sprintf("%s on %s, A4 = %g Hz", name, tonicName, a4)
+	if *t.kbm != "" {
+		kbm, err := os.Open(*t.kbm)
+		if err != nil {
+			return nil, fmt.Errorf("File not found: %s", *t.kbm)
+		}
+		defer kbm.Close()
+		if mapping, err = tuning.ParseMapping(kbm); err != nil {
+			return nil, fmt.Errorf("Could not read %s: %v", *t.kbm, err)
+		}
+		label = fmt.Sprintf("%s, mapped by %s", name, filepath.Base(*t.kbm))
+	}
+
+	tn, err := tuning.New(label, scale, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("Could not use %s: %v", filename, err)
+	}
+	return tn, nil
+}
+
+// loadTunings loads the requested tunings, reporting errors and setting the
+// exit code. It returns no tunings if --tuning is not set.
+func loadTunings(t *tuningFlags, n theory.Notation, a4 float64) ([]*tuning.Tuning, bool) {
+	if !t.enabled() {
+		if *t.kbm != "" {
+			fmt.Fprintln(os.Stderr, "Error: --kbm requires --tuning.")
+			exitCode = 1
+			return nil, false
+		}
+		return nil, true
+	}
+	tunings, err := t.load(n, a4)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v.\n", err)
+		exitCode = 1
+		return nil, false
+	}
+	return tunings, true
+}
+
+// printTunings voices the notes, root first, in close position from octave 4
+// and prints their frequencies and intervals in each tuning.
+func printTunings(w io.Writer, n theory.Notation, tunings []*tuning.Tuning, notes []theory.Note) error {
+	pitchClasses := make([]int, len(notes))
+	names := make(map[int]string)
+	for i, note := range notes {
+		pitchClasses[i] = note.Value
+		names[note.Value] = n.NoteName(note)
+	}
+	keys := midi.Voice(pitchClasses, 4, midi.VoicingClose)
+	keyName := func(key int) string {
+		return fmt.Sprintf("%s%d", names[key%12], key/12-1)
+	}
+
+	for _, tn := range tunings {
+		intervals, err := tn.Intervals(keys)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Tuning: %s\n", tn.Name)
+		for _, key := range keys {
+			f, _ := tn.Frequency(key)
+			fmt.Fprintf(w, " %-4s %8.2f Hz\n", keyName(key), f)
+		}
+		for _, iv := range intervals {
+			deviation := iv.Deviation
+			if math.Abs(deviation) < 0.05 {
+				deviation = 0 // avoid printing -0.0 for pure intervals
+			}
+			fmt.Fprintf(w, " %-9s %6.1f cents, %+5.1f from %s, %.2f beats/s\n",
+				keyName(iv.Lower)+"-"+keyName(iv.Upper), iv.Cents, deviation, iv.Pure, iv.Beats)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// rootFirst returns the notes with the root moved to the front and repeated
+// pitch classes removed, as chord voicings expect.
+func rootFirst(root theory.Note, notes []theory.Note) []theory.Note {
+	ordered := []theory.Note{root}
+	seen := map[int]bool{root.Value: true}
+	for _, n := range notes {
+		if !seen[n.Value] {
+			seen[n.Value] = true
+			ordered = append(ordered, n)
+		}
+	}
+	return ordered
+}
+
+// isScalaFile reports whether a --tuning name is a Scala .scl file rather
+// than a built-in tuning.
+func isScalaFile(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".scl")
+}
@@ -0,0 +1,88 @@
+// voiceleading.go
+// This file contains the --voice-leading output of keys and batch, which
+// compares each chord of a progression with the next.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"cordelia/midi"
+	"cordelia/theory"
+)
+
+// voiceLeadingStep is a chord of a progression with the position and name
+// it is reported under.
+type voiceLeadingStep struct {
+	position int
+	name     string
+	notes    []theory.Note
+}
+
+// printVoiceLeading voices the first chord in close position from octave 4
+// and leads each following chord from the previous chord's voicing, printing
+// the common tones, the motion of each voice, the total distance and the
+// resulting voicing.
+func printVoiceLeading(w io.Writer, n theory.Notation, steps []voiceLeadingStep) {
+	if len(steps) < 2 {
+		return
+	}
+	fmt.Fprintln(w, "\nVoice Leading:")
+
+	pitchClasses := make([]int, len(steps[0].notes))
+	for i, note := range steps[0].notes {
+		pitchClasses[i] = note.Value
+	}
+	keys := midi.Voice(pitchClasses, 4, midi.VoicingClose)
+	names := pitchClassNames(n, steps[0].notes)
+
+	for i := 1; i < len(steps); i++ {
+		prev, next := steps[i-1], steps[i]
+		nextNames := pitchClassNames(n, next.notes)
+		vl := theory.LeadVoices(keys, next.notes)
+
+		fmt.Fprintf(w, "[%d -> %d] %s -> %s\n", prev.position, next.position, prev.name, next.name)
+		common := "none"
+		if len(vl.Common) > 0 {
+			common = n.SliceToString(vl.Common)
+		}
+		fmt.Fprintf(w, " Common Tones: %s\n", common)
+		moves := make([]string, len(vl.Moves))
+		for j, m := range vl.Moves {
+			motion := "0"
+			if m.Semitones != 0 {
+				motion = fmt.Sprintf("%+d", m.Semitones)
+			}
+			moves[j] = fmt.Sprintf("%s -> %s (%s)", keyName(names, m.From), keyName(nextNames, m.To), motion)
+		}
+		fmt.Fprintf(w, " Motion: %s\n", strings.Join(moves, ", "))
+		unit := "semitones"
+		if vl.Distance == 1 {
+			unit = "semitone"
+		}
+		fmt.Fprintf(w, " Distance: %d %s\n", vl.Distance, unit)
+		voicing := make([]string, len(vl.Voicing))
+		for j, key := range vl.Voicing {
+			voicing[j] = keyName(nextNames, key)
+		}
+		fmt.Fprintf(w, " Voicing: %s\n", strings.Join(voicing, " "))
+
+		keys, names = vl.Voicing, nextNames
+	}
+}
+
+// pitchClassNames maps the pitch classes of a chord to their names in a notation.
+func pitchClassNames(n theory.Notation, notes []theory.Note) map[int]string {
+	names := make(map[int]string, len(notes))
+	for _, note := range notes {
+		names[note.Value] = n.NoteName(note)
+	}
+	return names
+}
+
+// keyName writes a MIDI key as a note name with its octave, e.g. "C4".
+func keyName(names map[int]string, key int) string {
+	return fmt.Sprintf("%s%d", names[key%12], key/12-1)
+}